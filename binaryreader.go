@@ -12,21 +12,41 @@ type binaryReader struct {
 	bits bitstream
 	cat  Catalog
 	lst  SymbolTable
+	raw  []byte
+	tag  byte
 }
 
-func newBinaryReaderBuf(in *bufio.Reader, cat Catalog) Reader {
+func newBinaryReaderBuf(in *bufio.Reader, cat Catalog, opts ...ReaderOption) Reader {
 	r := &binaryReader{
 		cat: cat,
 	}
+	r.init(opts)
 	r.bits.Init(in)
+	r.bits.strict = r.strict
+	if r.maxValueSize > 0 {
+		r.bits.maxLen = uint64(r.maxValueSize)
+	}
 	return r
 }
 
+// Encoding returns FormatBinary.
+func (r *binaryReader) Encoding() Format {
+	return FormatBinary
+}
+
 // SymbolTable returns the current symbol table.
 func (r *binaryReader) SymbolTable() SymbolTable {
 	return r.lst
 }
 
+// SymbolTableBuilder returns a builder seeded with the current symbol table.
+func (r *binaryReader) SymbolTableBuilder() SymbolTableBuilder {
+	if r.lst == nil || r.lst == V1SystemSymbolTable {
+		return NewSymbolTableBuilder()
+	}
+	return copySymbolTable(r.lst)
+}
+
 // Next moves the reader to the next value.
 func (r *binaryReader) Next() bool {
 	if r.eof || r.err != nil {
@@ -43,9 +63,35 @@ func (r *binaryReader) Next() bool {
 		}
 	}
 
+	r.tag = r.bits.Tag()
 	return !r.eof
 }
 
+// BinaryTypeDescriptor returns the type descriptor byte of the current value.
+func (r *binaryReader) BinaryTypeDescriptor() (byte, bool) {
+	if r.valueType == NoType {
+		return 0, false
+	}
+	return r.tag, true
+}
+
+// RawToken is not supported by binary readers, which have no text to return.
+func (r *binaryReader) RawToken() (string, error) {
+	return "", &UsageError{"Reader.RawToken", "only supported by text readers"}
+}
+
+// ValueBytes returns the raw bytes of the current scalar value.
+func (r *binaryReader) ValueBytes() ([]byte, error) {
+	if !r.valueBytes {
+		return nil, &UsageError{"Reader.ValueBytes", "reader was not created with WithValueBytes"}
+	}
+	switch r.valueType {
+	case NoType, ListType, SexpType, StructType:
+		return nil, &UsageError{"Reader.ValueBytes", "not positioned on a scalar value"}
+	}
+	return r.raw, nil
+}
+
 // Next consumes the next raw value from the stream, returning true if it
 // represents a user-facing value and false if it does not.
 func (r *binaryReader) next() (bool, error) {
@@ -53,6 +99,21 @@ func (r *binaryReader) next() (bool, error) {
 		return false, err
 	}
 
+	if !r.valueBytes {
+		return r.nextValue()
+	}
+
+	// Scalar values are decoded eagerly, so hang on to the bytes they were
+	// decoded from in case someone asks for them.
+	r.bits.Record()
+	done, err := r.nextValue()
+	r.raw = r.bits.Recorded()
+
+	return done, err
+}
+
+// NextValue handles the raw value the bitstream is currently positioned on.
+func (r *binaryReader) nextValue() (bool, error) {
 	code := r.bits.Code()
 	switch code {
 	case bitcodeEOF:
@@ -73,7 +134,11 @@ func (r *binaryReader) next() (bool, error) {
 
 	case bitcodeNull:
 		if !r.bits.IsNull() {
-			// NOP padding; skip it and keep going.
+			// NOP padding; skip it and keep going. Padding isn't a value, so it
+			// can't be annotated.
+			if len(r.annotations) > 0 {
+				return false, &SyntaxError{"annotated NOP padding", r.bits.Pos() - 1}
+			}
 			err := r.bits.SkipValue()
 			return false, err
 		}
@@ -123,11 +188,12 @@ func (r *binaryReader) next() (bool, error) {
 	case bitcodeTimestamp:
 		r.valueType = TimestampType
 		if !r.bits.IsNull() {
-			val, err := r.bits.ReadTimestamp()
+			val, precision, err := r.bits.ReadTimestamp()
 			if err != nil {
 				return false, err
 			}
 			r.value = val
+			r.precision = precision
 		}
 		return true, nil
 
@@ -138,6 +204,7 @@ func (r *binaryReader) next() (bool, error) {
 			if err != nil {
 				return false, err
 			}
+			r.symbol = r.resolveToken(id)
 			r.value = r.resolve(id)
 		}
 		return true, nil
@@ -217,13 +284,13 @@ func (r *binaryReader) readBVM() error {
 		return err
 	}
 
-	switch major {
-	case 1:
-		switch minor {
-		case 0:
-			r.lst = V1SystemSymbolTable
-			return nil
+	if supportedVersion(int(major), int(minor)) {
+		r.lst = V1SystemSymbolTable
+		if r.sharedLST != nil {
+			r.lst = r.sharedLST
 		}
+		r.versionMarker(int(major), int(minor))
+		return nil
 	}
 
 	return &UnsupportedVersionError{
@@ -247,6 +314,7 @@ func (r *binaryReader) readLocalSymbolTable() error {
 
 	imps := []SharedSymbolTable{}
 	syms := []string{}
+	var unknown map[int]bool
 
 	for r.Next() {
 		var err error
@@ -254,7 +322,7 @@ func (r *binaryReader) readLocalSymbolTable() error {
 		case "imports":
 			imps, err = r.readImports()
 		case "symbols":
-			syms, err = r.readSymbols()
+			syms, unknown, err = r.readSymbols()
 		}
 		if err != nil {
 			return err
@@ -265,7 +333,7 @@ func (r *binaryReader) readLocalSymbolTable() error {
 		return err
 	}
 
-	r.lst = NewLocalSymbolTable(imps, syms)
+	r.lst = newLocalSymbolTable(imps, syms, unknown)
 	return nil
 }
 
@@ -383,30 +451,38 @@ func (r *binaryReader) readImport() (SharedSymbolTable, error) {
 	return imp, nil
 }
 
-// ReadSymbols reads the symbols from a symbol table.
-func (r *binaryReader) readSymbols() ([]string, error) {
-	if r.Type() != ListType {
-		return nil, nil
+// ReadSymbols reads the symbols from a symbol table, along with the indexes of
+// any null or non-string entries, which reserve a slot for a symbol with unknown
+// text. Per the spec, a symbols field that is anything other than a non-null list
+// is ignored.
+func (r *binaryReader) readSymbols() ([]string, map[int]bool, error) {
+	if r.Type() != ListType || r.IsNull() {
+		return nil, nil, nil
 	}
 	if err := r.StepIn(); err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	syms := []string{}
+	var unknown map[int]bool
 	for r.Next() {
-		if r.Type() == StringType {
+		if r.Type() == StringType && !r.IsNull() {
 			sym, err := r.StringValue()
 			if err != nil {
-				return nil, err
+				return nil, nil, err
 			}
 			syms = append(syms, sym)
 		} else {
+			if unknown == nil {
+				unknown = make(map[int]bool)
+			}
+			unknown[len(syms)] = true
 			syms = append(syms, "")
 		}
 	}
 
 	err := r.StepOut()
-	return syms, err
+	return syms, unknown, err
 }
 
 // ReadFieldName reads and resolves a field name.
@@ -416,7 +492,9 @@ func (r *binaryReader) readFieldName() error {
 		return err
 	}
 
+	tok := r.resolveToken(id)
 	r.fieldName = r.resolve(id)
+	r.fieldNameSym = &tok
 	return nil
 }
 
@@ -428,16 +506,19 @@ func (r *binaryReader) readAnnotations() error {
 	}
 
 	as := make([]string, len(ids))
+	toks := make([]SymbolToken, len(ids))
 	for i, id := range ids {
 		as[i] = r.resolve(id)
+		toks[i] = r.resolveToken(id)
 	}
 
 	r.annotations = as
+	r.annotationSyms = toks
 	return nil
 }
 
 // Resolve resolves a symbol ID to a symbol value (possibly ${id} if we're
-// missing the appropriate symbol table).
+// missing the appropriate symbol table, or the symbol has unknown text).
 func (r *binaryReader) resolve(id uint64) string {
 	s, ok := r.lst.FindByID(id)
 	if !ok {
@@ -446,6 +527,18 @@ func (r *binaryReader) resolve(id uint64) string {
 	return s
 }
 
+// ResolveToken resolves a symbol ID to a SymbolToken, whose text is nil if the
+// ID is $0 or we're missing the appropriate symbol table.
+func (r *binaryReader) resolveToken(id uint64) SymbolToken {
+	s, ok := r.lst.FindByID(id)
+	if !ok {
+		// Either out of range, or a slot reserved by a null or non-string
+		// entry in the symbol table, which has unknown text.
+		return SymbolToken{nil, int64(id)}
+	}
+	return SymbolToken{&s, int64(id)}
+}
+
 // StepIn steps in to a container-type value
 func (r *binaryReader) StepIn() error {
 	if r.err != nil {
@@ -458,6 +551,10 @@ func (r *binaryReader) StepIn() error {
 	if r.value == nil {
 		return &UsageError{"Reader.StepIn", "cannot step in to a null container"}
 	}
+	if err := r.checkDepth(r.bits.Pos()); err != nil {
+		r.err = err
+		return err
+	}
 
 	r.ctx.push(containerTypeToCtx(r.valueType))
 	r.clear()
@@ -466,6 +563,11 @@ func (r *binaryReader) StepIn() error {
 	return nil
 }
 
+// FindField advances to the next field in the current struct with the given name.
+func (r *binaryReader) FindField(name string) (bool, error) {
+	return findStructField(r, r.ctx.peek(), name)
+}
+
 // StepOut steps out of a container-type value.
 func (r *binaryReader) StepOut() error {
 	if r.err != nil {
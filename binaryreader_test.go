@@ -1,9 +1,11 @@
 package ion
 
 import (
+	"bytes"
 	"fmt"
 	"math"
 	"math/big"
+	"strings"
 	"testing"
 	"time"
 )
@@ -39,6 +41,26 @@ func TestReadBadBVMs(t *testing.T) {
 		if uve.Minor != 0 {
 			t.Errorf("expected minor=0, got %v", uve.Minor)
 		}
+		if msg := uve.Error(); msg != "ion: unsupported version 2.0 (offset 0)" {
+			t.Errorf("unexpected message: %v", msg)
+		}
+	})
+
+	t.Run("E00101EA", func(t *testing.T) {
+		// Values before an unsupported minor version are still read.
+		r := NewReaderBytes([]byte{0xE0, 0x01, 0x00, 0xEA, 0x21, 0x01, 0xE0, 0x01, 0x01, 0xEA, 0x21, 0x02})
+		_int(t, r, 1)
+		if r.Next() {
+			t.Errorf("next returned true")
+		}
+
+		uve, ok := r.Err().(*UnsupportedVersionError)
+		if !ok {
+			t.Fatalf("expected an UnsupportedVersionError, got %v", r.Err())
+		}
+		if uve.Major != 1 || uve.Minor != 1 || uve.Offset != 6 {
+			t.Errorf("expected version 1.1 at offset 6, got %v", uve)
+		}
 	})
 }
 
@@ -82,6 +104,36 @@ func TestReadBadLST(t *testing.T) {
 	}
 }
 
+func TestReadLSTNonListSymbols(t *testing.T) {
+	test := func(name string, lst []byte) {
+		t.Run(name, func(t *testing.T) {
+			ion := append([]byte{0xE0, 0x01, 0x00, 0xEA}, lst...)
+			ion = append(ion, 0x71, 0x0A) // $10
+
+			r := NewReaderBytes(ion)
+			_symbol(t, r, "$10")
+			_eof(t, r)
+		})
+	}
+
+	test("string", []byte{
+		0xE9, 0x81, 0x83, 0xD6, // $ion_symbol_table::{
+		0x87, 0x84, 'o', 'o', 'p', 's', // symbols:"oops"}
+	})
+	test("symbol", []byte{
+		0xE6, 0x81, 0x83, 0xD3, // $ion_symbol_table::{
+		0x87, 0x71, 0x04, // symbols:name}
+	})
+	test("null.list", []byte{
+		0xE5, 0x81, 0x83, 0xD2, // $ion_symbol_table::{
+		0x87, 0xBF, // symbols:null.list}
+	})
+	test("struct", []byte{
+		0xE8, 0x81, 0x83, 0xD5, // $ion_symbol_table::{
+		0x87, 0xD3, 0x84, 0x81, 'a', // symbols:{name:"a"}}
+	})
+}
+
 func TestReadMultipleLSTs(t *testing.T) {
 	r := readBinary([]byte{
 		0x71, 0x0B, // $11
@@ -114,6 +166,115 @@ func TestReadMultipleLSTs(t *testing.T) {
 	_eof(t, r)
 }
 
+func TestReadBinaryLSTNonStringSymbols(t *testing.T) {
+	r := NewReaderBytes([]byte{
+		0xE0, 0x01, 0x00, 0xEA, // $ion_1_0
+		0xEB, 0x81, 0x83, 0xD8, // $ion_symbol_table::{
+		0x87, 0xB6, // symbols:[
+		0x81, 'a', // "a",
+		0x21, 0x05, // 5,
+		0x81, 'c', // "c" ]}
+		0x71, 0x0A, // a
+		0x71, 0x0B, // $11
+		0x71, 0x0C, // c
+	})
+
+	_symbol(t, r, "a")
+
+	// The 5 doesn't stop the table from being read, it just reserves $11
+	// as a symbol with unknown text.
+	lst := r.SymbolTable()
+	if lst.MaxID() != 12 {
+		t.Errorf("expected maxid=12, got %v", lst.MaxID())
+	}
+	if sym, ok := lst.FindByID(11); ok {
+		t.Errorf("expected $11 to have unknown text, got %q", sym)
+	}
+
+	if sym, ok := r.SymbolTableBuilder().FindByID(12); !ok || sym != "c" {
+		t.Errorf("expected the builder to have $12=c, got %v", sym)
+	}
+
+	_symbol(t, r, "$11")
+	if tok, err := r.SymbolValue(); err != nil || tok.Text != nil || tok.LocalSID != 11 {
+		t.Errorf("expected $11 with no text, got %v, %v", tok, err)
+	}
+	_symbol(t, r, "c")
+	_eof(t, r)
+}
+func TestReadBinaryLSTEmptySymbol(t *testing.T) {
+	r := NewReaderBytes([]byte{
+		0xE0, 0x01, 0x00, 0xEA, // $ion_1_0
+		0xEA, 0x81, 0x83, 0xD7, // $ion_symbol_table::{
+		0x87, 0xB5, // symbols:[
+		0x81, 'a', // "a",
+		0x80,      // "",
+		0x81, 'c', // "c" ]}
+		0x71, 0x0A, // a
+		0x71, 0x0B, // ''
+		0x71, 0x0C, // c
+	})
+
+	_symbol(t, r, "a")
+
+	// Unlike a non-string entry, the empty string is a symbol with known,
+	// empty text.
+	if sym, ok := r.SymbolTable().FindByID(11); !ok || sym != "" {
+		t.Errorf("expected $11 to have empty text, got %q, %v", sym, ok)
+	}
+
+	_symbol(t, r, "")
+	empty := ""
+	_symbolToken(t, r.SymbolValue, &empty, 11)
+	_symbol(t, r, "c")
+	_eof(t, r)
+}
+
+func TestReadBinarySymbolTableImports(t *testing.T) {
+	shared := NewSharedSymbolTable("shared", 1, []string{"foo", "bar"})
+
+	buf := bytes.Buffer{}
+	w := NewBinaryWriter(&buf, shared)
+	w.WriteSymbol("bar")
+	w.WriteSymbol("baz")
+	if err := w.Finish(); err != nil {
+		t.Fatal(err)
+	}
+	w = NewBinaryWriter(&buf)
+	w.WriteSymbol("qux")
+	if err := w.Finish(); err != nil {
+		t.Fatal(err)
+	}
+
+	r := NewReaderCat(bytes.NewReader(buf.Bytes()), NewCatalog(shared))
+	if r.SymbolTable() != nil {
+		t.Errorf("expected no symbol table before Next, got %v", r.SymbolTable())
+	}
+
+	_next(t, r, SymbolType)
+	lst := r.SymbolTable()
+	for id, esym := range map[uint64]string{4: "name", 9: "$ion_shared_symbol_table", 10: "foo", 11: "bar", 12: "baz"} {
+		if sym, ok := lst.FindByID(id); !ok || sym != esym {
+			t.Errorf("expected $%v=%v, got %v", id, esym, sym)
+		}
+	}
+	if lst.MaxID() != 12 {
+		t.Errorf("expected maxid=12, got %v", lst.MaxID())
+	}
+	_symbol(t, r, "baz")
+
+	// The second document's symbol table replaces the first's.
+	_next(t, r, SymbolType)
+	lst = r.SymbolTable()
+	if sym, ok := lst.FindByID(10); !ok || sym != "qux" {
+		t.Errorf("expected $10=qux, got %v", sym)
+	}
+	if _, ok := lst.FindByName("baz"); ok {
+		t.Error("found a symbol for baz")
+	}
+	_eof(t, r)
+}
+
 func TestReadBinaryLST(t *testing.T) {
 	r := readBinary([]byte{0x0F})
 	_next(t, r, NullType)
@@ -293,6 +454,61 @@ func TestReadBinarySymbols(t *testing.T) {
 	_eof(t, r)
 }
 
+func TestReadBinarySymbolZero(t *testing.T) {
+	r := readBinary([]byte{
+		0x70,       // $0
+		0x71, 0x00, // $0
+		0xD2, 0x80, 0x20, // {$0:0}
+		0xE4, 0x82, 0x80, 0xEE, 0x70, // $0::foo::$0
+	})
+
+	_symbol(t, r, "$0")
+	_symbolToken(t, r.SymbolValue, nil, 0)
+
+	_symbol(t, r, "$0")
+	_symbolToken(t, r.SymbolValue, nil, 0)
+
+	_struct(t, r, func(t *testing.T, r Reader) {
+		_nextAF(t, r, IntType, "$0", nil)
+		_symbolToken(t, _noErr(r.FieldNameSymbol), nil, 0)
+	})
+
+	_nextAF(t, r, SymbolType, "", []string{"$0", "foo"})
+	as := r.AnnotationSymbols()
+	if len(as) != 2 {
+		t.Fatalf("expected 2 annotations, got %v", len(as))
+	}
+	_symbolToken(t, func() (SymbolToken, error) { return as[0], nil }, nil, 0)
+	_symbolToken(t, func() (SymbolToken, error) { return as[1], nil }, _str("foo"), 110)
+	_symbolToken(t, r.SymbolValue, nil, 0)
+
+	_eof(t, r)
+}
+
+func TestReadBinaryUnresolvedFieldNames(t *testing.T) {
+	r := readBinary([]byte{
+		0xD9,             // {
+		0x8F, 0x21, 0x01, //   $15:1, from the import, whose text we don't have
+		0xEE, 0x21, 0x02, //   foo:2
+		0x01, 0xC8, 0x20, //   $200:0, beyond the end of the symbol table }
+	})
+
+	_struct(t, r, func(t *testing.T, r Reader) {
+		_intAF(t, r, "$15", nil, 1)
+		_symbolToken(t, _noErr(r.FieldNameSymbol), nil, 15)
+
+		_intAF(t, r, "foo", nil, 2)
+		_symbolToken(t, _noErr(r.FieldNameSymbol), _str("foo"), 110)
+
+		_intAF(t, r, "$200", nil, 0)
+		_symbolToken(t, _noErr(r.FieldNameSymbol), nil, 200)
+
+		_eof(t, r)
+		_symbolToken(t, _noErr(r.FieldNameSymbol), nil, SymbolIDUnknown)
+	})
+	_eof(t, r)
+}
+
 func TestReadBinaryTimestamps(t *testing.T) {
 	r := readBinary([]byte{
 		0x6F,
@@ -315,12 +531,82 @@ func TestReadBinaryTimestamps(t *testing.T) {
 
 	_null(t, r, TimestampType)
 
-	for i := 0; i < 5; i++ {
-		_timestamp(t, r, time.Time{})
+	precisions := []TimestampPrecision{
+		TimestampPrecisionYear,
+		TimestampPrecisionMonth,
+		TimestampPrecisionDay,
+		TimestampPrecisionMinute,
+		TimestampPrecisionSecond,
+	}
+	for _, p := range precisions {
+		_timestampP(t, r, time.Time{}, p)
 	}
 
 	nowish, _ := time.Parse(time.RFC3339Nano, "2019-08-04T18:15:43.863494+10:00")
-	_timestamp(t, r, nowish)
+	_timestampP(t, r, nowish, TimestampPrecisionNanosecond)
+	_eof(t, r)
+}
+
+func TestReadBinaryLeapSecond(t *testing.T) {
+	r := readBinary([]byte{
+		0x68, 0x80, // offset: Z
+		0x0F, 0xE0, // year:   2016
+		0x8C, 0x9F, // 12-31
+		0x97, 0xBB, 0xBC, // 23:59:60
+	})
+
+	// Normalized to the following second, same as text.
+	eval := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+	_timestampP(t, r, eval, TimestampPrecisionSecond)
+	_eof(t, r)
+
+	tr := NewReaderStr("2016-12-31T23:59:60Z")
+	_timestampP(t, tr, eval, TimestampPrecisionSecond)
+	_eof(t, tr)
+}
+
+func TestReadBinaryVarUintLengths(t *testing.T) {
+	r := readBinary([]byte{
+		0x8E, 0x83, 'a', 'b', 'c', // "abc"
+		0xAE, 0x82, 0x01, 0x02, // {{AQI=}}
+		0xBE, 0x84, // [
+		0x21, 0x01, // 1,
+		0x8E, 0x80, // "" ]
+		0xCE, 0x80, // ()
+		0xDE, 0x89, // {
+		0x8A, 0x21, 0x01, // $10: 1,
+		0x8B, 0xDE, 0x83, // $11: {
+		0x8A, 0x21, 0x02, // $10: 2 }}
+		0xD1, 0x86, // {  (sorted)
+		0x8A, 0x20, // $10: 0,
+		0x8B, 0x8E, 0x81, 'x', // $11: "x" }
+		0x2E, 0x81, 0x05, // 5
+	})
+
+	_string(t, r, "abc")
+	_blob(t, r, []byte{1, 2})
+	_list(t, r, func(t *testing.T, r Reader) {
+		_int(t, r, 1)
+		_string(t, r, "")
+		_eof(t, r)
+	})
+	_sexp(t, r, func(t *testing.T, r Reader) {
+		_eof(t, r)
+	})
+	_struct(t, r, func(t *testing.T, r Reader) {
+		_intAF(t, r, "$10", nil, 1)
+		_structAF(t, r, "$11", nil, func(t *testing.T, r Reader) {
+			_intAF(t, r, "$10", nil, 2)
+			_eof(t, r)
+		})
+		_eof(t, r)
+	})
+	_struct(t, r, func(t *testing.T, r Reader) {
+		_intAF(t, r, "$10", nil, 0)
+		_stringAF(t, r, "$11", nil, "x")
+		_eof(t, r)
+	})
+	_int(t, r, 5)
 	_eof(t, r)
 }
 
@@ -333,6 +619,8 @@ func TestReadBinaryDecimals(t *testing.T) {
 		0x53, 0xC3, 0x83, 0xE8, // -1.000, aka -1000 x 10^-3
 		0x53, 0x00, 0xE4, 0x01, // 1d100, aka 1 * 10^100
 		0x53, 0x00, 0xE4, 0x81, // -1d100, aka -1 * 10^100
+		0x52, 0x80, 0x0C, // 12.
+		0x52, 0xC2, 0x80, // -0.00
 	})
 
 	_decimal(t, r, MustParseDecimal("0."))
@@ -342,6 +630,11 @@ func TestReadBinaryDecimals(t *testing.T) {
 	_decimal(t, r, MustParseDecimal("-1.000"))
 	_decimal(t, r, MustParseDecimal("1d100"))
 	_decimal(t, r, MustParseDecimal("-1d100"))
+	_decimal(t, r, MustParseDecimal("12."))
+	_decimal(t, r, MustParseDecimal("-0.00"))
+	if d, _ := r.DecimalValue(); !d.IsNegativeZero() {
+		t.Errorf("expected negative zero, got %v", d)
+	}
 	_eof(t, r)
 }
 
@@ -397,6 +690,34 @@ func TestReadBinaryInts(t *testing.T) {
 	_eof(t, r)
 }
 
+func TestReadBinaryNonCanonicalInts(t *testing.T) {
+	bs := []byte{
+		0xE0, 0x01, 0x00, 0xEA, // $ion_1_0
+		0x22, 0x00, 0x05, // 5, with a leading zero byte
+		0x32, 0x00, 0x05, // -5
+		0x21, 0x00, // 0
+		0x29, 0x00, 0x7F, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, // 0x7FFFFFFFFFFFFFFF
+	}
+
+	r := NewReaderBytes(bs)
+	_int(t, r, 5)
+	_int(t, r, -5)
+	_int(t, r, 0)
+	_int64(t, r, math.MaxInt64)
+	if size, _ := r.IntSize(); size != Int64 {
+		t.Errorf("expected %v, got %v", Int64, size)
+	}
+	_eof(t, r)
+
+	r = NewReaderBytes(bs, WithStrict())
+	if r.Next() {
+		t.Fatal("strict reader accepted a non-canonical int")
+	}
+	if _, ok := r.Err().(*SyntaxError); !ok {
+		t.Errorf("expected a SyntaxError, got %v", r.Err())
+	}
+}
+
 func TestReadBinaryBools(t *testing.T) {
 	r := readBinary([]byte{
 		0x10, // false
@@ -434,7 +755,181 @@ func TestReadEmptyBinary(t *testing.T) {
 	_eof(t, r)
 }
 
-func readBinary(ion []byte) Reader {
+func TestReadBinaryEncoding(t *testing.T) {
+	r := NewReaderBytes([]byte{0xE0, 0x01, 0x00, 0xEA, 0x20})
+	if f := r.Encoding(); f != FormatBinary {
+		t.Errorf("expected %v, got %v", FormatBinary, f)
+	}
+}
+
+func TestReadBinaryValueBytes(t *testing.T) {
+	r := readBinary([]byte{
+		0x0F,             // null
+		0x11,             // true
+		0x22, 0x01, 0x00, // 256
+		0x31, 0x01, // -1
+		0x44, 0x3F, 0x80, 0, 0, // 1e0
+		0x52, 0xC1, 0x0F, // 1.5
+		0x63, 0xC0, 0x0F, 0xE4, // 2020T
+		0x71, 0x0A, // $10
+		0x83, 'a', 'b', 'c', // "abc"
+		0x92, 0x01, 0x02, // {{"\x01\x02"}}
+		0xA1, 0xFF, // {{/w==}}
+		0xB1, 0x20, // [0]
+	}, WithValueBytes())
+
+	test := func(etype Type, ebytes []byte) {
+		if !r.Next() {
+			t.Fatalf("expected %v, got %v", etype, r.Err())
+		}
+		if r.Type() != etype {
+			t.Fatalf("expected %v, got %v", etype, r.Type())
+		}
+		bs, err := r.ValueBytes()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(bs, ebytes) {
+			t.Errorf("expected %v, got %v", fmtbytes(ebytes), fmtbytes(bs))
+		}
+	}
+
+	test(NullType, nil)
+	test(BoolType, nil)
+	test(IntType, []byte{0x01, 0x00})
+	test(IntType, []byte{0x01})
+	test(FloatType, []byte{0x3F, 0x80, 0, 0})
+	test(DecimalType, []byte{0xC1, 0x0F})
+	test(TimestampType, []byte{0xC0, 0x0F, 0xE4})
+	test(SymbolType, []byte{0x0A})
+	test(StringType, []byte("abc"))
+	test(ClobType, []byte{0x01, 0x02})
+	test(BlobType, []byte{0xFF})
+
+	_next(t, r, ListType)
+	if _, err := r.ValueBytes(); err == nil {
+		t.Error("expected an error for a list")
+	}
+	r.StepIn()
+	test(IntType, nil)
+	r.StepOut()
+	_eof(t, r)
+
+	// Without the option, nothing is kept.
+	r = readBinary([]byte{0x83, 'a', 'b', 'c'})
+	_next(t, r, StringType)
+	if _, err := r.ValueBytes(); err == nil {
+		t.Error("expected an error without WithValueBytes")
+	}
+}
+
+func TestReadBinaryTypeDescriptor(t *testing.T) {
+	r := readBinary([]byte{
+		0x2F,       // null.int
+		0x10,       // false
+		0x21, 0x05, // 5
+		0x23, 0x00, 0x00, 0x05, // 5, padded to three bytes
+		0x8E, 0x81, 'a', // "a", with a separate length
+		0xE4, 0x81, 0x84, 0x21, 0x07, // name::7
+		0xD3, 0x84, 0x21, 0x08, // {name:8}
+	})
+
+	if _, ok := r.BinaryTypeDescriptor(); ok {
+		t.Error("expected no descriptor before the first value")
+	}
+
+	test := func(etype Type, etag byte) {
+		if !r.Next() {
+			t.Fatalf("expected %v, got %v", etype, r.Err())
+		}
+		if r.Type() != etype {
+			t.Fatalf("expected %v, got %v", etype, r.Type())
+		}
+		tag, ok := r.BinaryTypeDescriptor()
+		if !ok || tag != etag {
+			t.Errorf("expected 0x%02X, got 0x%02X (%v)", etag, tag, ok)
+		}
+	}
+
+	test(IntType, 0x2F)
+	test(BoolType, 0x10)
+	test(IntType, 0x21)
+	test(IntType, 0x23)
+	test(StringType, 0x8E)
+	test(IntType, 0x21)
+	test(StructType, 0xD3)
+	r.StepIn()
+	test(IntType, 0x21)
+	r.StepOut()
+	_eof(t, r)
+
+	if _, ok := r.BinaryTypeDescriptor(); ok {
+		t.Error("expected no descriptor at the end")
+	}
+
+	r = NewReaderStr("1")
+	r.Next()
+	if _, ok := r.BinaryTypeDescriptor(); ok {
+		t.Error("expected no descriptor from a text reader")
+	}
+}
+
+func TestReadBinaryMaxDepth(t *testing.T) {
+	buf := bytes.Buffer{}
+	w := NewBinaryWriter(&buf)
+	for i := 0; i <= DefaultMaxDepth; i++ {
+		w.BeginList()
+	}
+	for i := 0; i <= DefaultMaxDepth; i++ {
+		w.EndList()
+	}
+	if err := w.Finish(); err != nil {
+		t.Fatal(err)
+	}
+
+	r := NewReaderBytes(buf.Bytes())
+	depth, err := _stepInAll(r)
+	if _, ok := err.(*DepthLimitError); !ok {
+		t.Fatalf("expected DepthLimitError, got %v", err)
+	}
+	if depth != DefaultMaxDepth {
+		t.Errorf("expected depth %v, got %v", DefaultMaxDepth, depth)
+	}
+	if r.Next() {
+		t.Error("Next returned true after exceeding max depth")
+	}
+
+	r = NewReaderBytes(buf.Bytes(), WithMaxDepth(0))
+	depth, err = _stepInAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if depth != DefaultMaxDepth+1 {
+		t.Errorf("expected depth %v, got %v", DefaultMaxDepth+1, depth)
+	}
+}
+
+func TestReadBinaryFindField(t *testing.T) {
+	testFindField(t, func(s string) Reader {
+		vs, err := ReadValues(NewReaderStr(s))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		buf := bytes.Buffer{}
+		w := NewBinaryWriter(&buf)
+		for i := range vs {
+			vs[i].WriteTo(w)
+		}
+		if err := w.Finish(); err != nil {
+			t.Fatal(err)
+		}
+
+		return NewReaderBytes(buf.Bytes())
+	})
+}
+
+func readBinary(ion []byte, opts ...ReaderOption) Reader {
 	prefix := []byte{
 		0xE0, 0x01, 0x00, 0xEA, // $ion_1_0
 		0xEE, 0x9F, 0x81, 0x83, 0xDE, 0x9B, // $ion_symbol_table::{
@@ -450,5 +945,143 @@ func readBinary(ion []byte) Reader {
 		// ]
 		// }
 	}
-	return NewReaderBytes(append(prefix, ion...))
+	return NewReaderBytes(append(prefix, ion...), opts...)
+}
+
+func TestReadBinaryNOPPadding(t *testing.T) {
+	ion := []byte{
+		0xE0, 0x01, 0x00, 0xEA, // $ion_1_0
+		0x00,             // one-byte pad
+		0x02, 0xAA, 0xAA, // three-byte pad
+		0x21, 0x01, // 1
+		0x0E, 0x90, // sixteen-byte pad, with its length in a VarUInt
+		0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+		0xB6,       // [
+		0x00,       //   pad
+		0x21, 0x02, //   2,
+		0x01, 0xFF, //   pad
+		0x00,       //   pad ]
+		0xD7,       // {
+		0x84, 0x00, //   name: pad
+		0x85, 0x21, 0x03, //   version:3
+		0x86, 0x00, //   imports: pad }
+		0x00, // pad
+	}
+
+	r := NewReaderBytes(ion)
+	_int(t, r, 1)
+	_list(t, r, func(t *testing.T, r Reader) {
+		_int(t, r, 2)
+		_eof(t, r)
+	})
+	_struct(t, r, func(t *testing.T, r Reader) {
+		_intAF(t, r, "version", nil, 3)
+		_eof(t, r)
+	})
+	_eof(t, r)
+
+	// Padding isn't a value, so it can't be annotated.
+	r = NewReaderBytes([]byte{0xE0, 0x01, 0x00, 0xEA, 0xE3, 0x81, 0x84, 0x00, 0x21, 0x01})
+	if r.Next() {
+		t.Error("next returned true")
+	}
+	if _, ok := r.Err().(*SyntaxError); !ok {
+		t.Errorf("expected a SyntaxError, got %v", r.Err())
+	}
+}
+
+func TestReadBinaryAcrossBufferBoundary(t *testing.T) {
+	// Enough values to span several of the underlying bufio.Reader's buffers, so some
+	// of them straddle the boundary between one and the next.
+	buf := bytes.Buffer{}
+	w := NewBinaryWriter(&buf)
+	for i := 0; i < 1000; i++ {
+		w.BeginStruct()
+		w.FieldName("seq")
+		w.WriteInt(int64(i))
+		w.FieldName("level")
+		w.WriteString("info")
+		w.EndStruct()
+	}
+	if err := w.Finish(); err != nil {
+		t.Fatal(err)
+	}
+
+	r := NewReaderBytes(buf.Bytes())
+	for i := 0; i < 1000; i++ {
+		_struct(t, r, func(t *testing.T, r Reader) {
+			_intAF(t, r, "seq", nil, i)
+			_stringAF(t, r, "level", nil, "info")
+			_eof(t, r)
+		})
+	}
+	_eof(t, r)
+}
+
+func TestReadBinaryMaxValueSize(t *testing.T) {
+	// A string claiming to be 4GB long, followed by not nearly that much.
+	huge := []byte{0xE0, 0x01, 0x00, 0xEA, 0x8E, 0x10, 0x00, 0x00, 0x00, 0x80, 'a', 'b', 'c'}
+
+	r := NewReaderBytes(huge, WithMaxValueSize(1<<20))
+	if r.Next() {
+		t.Fatal("expected no value")
+	}
+	if err, ok := r.Err().(*SizeLimitError); !ok {
+		t.Errorf("expected a SizeLimitError, got %v", r.Err())
+	} else if err.Max != 1<<20 || err.Size != 1<<32 || err.Offset != 4 {
+		t.Errorf("expected max %v, size %v and offset 4, got %+v", 1<<20, 1<<32, err)
+	}
+
+	// Without a limit it runs out of input, rather than memory, trying to read it.
+	r = NewReaderBytes(huge)
+	if r.Next() {
+		t.Fatal("expected no value")
+	}
+	if _, ok := r.Err().(*UnexpectedEOFError); !ok {
+		t.Errorf("expected an UnexpectedEOFError, got %v", r.Err())
+	}
+
+	// Values exactly at the limit are fine; containers count too.
+	bs := []byte{0xE0, 0x01, 0x00, 0xEA, 0x83, 'a', 'b', 'c', 0xB2, 0x20, 0x20}
+	r = NewReaderBytes(bs, WithMaxValueSize(3))
+	_string(t, r, "abc")
+	_list(t, r, func(t *testing.T, r Reader) {
+		_int(t, r, 0)
+		_int(t, r, 0)
+		_eof(t, r)
+	})
+	_eof(t, r)
+
+	r = NewReaderBytes(bs, WithMaxValueSize(2))
+	if r.Next() {
+		t.Fatal("expected no value")
+	}
+	if _, ok := r.Err().(*SizeLimitError); !ok {
+		t.Errorf("expected a SizeLimitError, got %v", r.Err())
+	}
+}
+
+func TestReadBinaryLargeString(t *testing.T) {
+	// Long enough to be read in several chunks.
+	str := strings.Repeat("abcdefghij", 30000)
+
+	buf := bytes.Buffer{}
+	w := NewBinaryWriter(&buf)
+	w.WriteString(str)
+	if err := w.Finish(); err != nil {
+		t.Fatal(err)
+	}
+
+	r := NewReaderBytes(buf.Bytes())
+	_string(t, r, str)
+	_eof(t, r)
+
+	// And truncated partway through a later chunk.
+	r = NewReaderBytes(buf.Bytes()[:buf.Len()-1000])
+	if r.Next() {
+		t.Fatal("expected no value")
+	}
+	if _, ok := r.Err().(*UnexpectedEOFError); !ok {
+		t.Errorf("expected an UnexpectedEOFError, got %v", r.Err())
+	}
 }
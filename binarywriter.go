@@ -19,12 +19,22 @@ type binaryWriter struct {
 	lst  SymbolTable
 	lstb SymbolTableBuilder
 
-	wroteLST bool
+	wroteLST  bool
+	appending bool
+
+	// When appending, the number of symbols in the table being appended to.
+	prior int
 }
 
 // NewBinaryWriter creates a new binary writer that will construct a
 // local symbol table as it is written to.
 func NewBinaryWriter(out io.Writer, sts ...SharedSymbolTable) Writer {
+	return NewBinaryWriterOpts(out, sts)
+}
+
+// NewBinaryWriterOpts creates a new binary writer that will construct a
+// local symbol table as it is written to, with the given options.
+func NewBinaryWriterOpts(out io.Writer, sts []SharedSymbolTable, opts ...WriterOption) Writer {
 	w := &binaryWriter{
 		writer: writer{
 			out: out,
@@ -32,18 +42,75 @@ func NewBinaryWriter(out io.Writer, sts ...SharedSymbolTable) Writer {
 		lstb: NewSymbolTableBuilder(sts...),
 	}
 	w.bufs.push(&datagram{})
-	return w
+	for _, o := range opts {
+		o(&w.writer)
+	}
+	return w.wrap()
 }
 
 // NewBinaryWriterLST creates a new binary writer with a pre-built local
 // symbol table.
-func NewBinaryWriterLST(out io.Writer, lst SymbolTable) Writer {
-	return &binaryWriter{
+func NewBinaryWriterLST(out io.Writer, lst SymbolTable, opts ...WriterOption) Writer {
+	w := &binaryWriter{
 		writer: writer{
 			out: out,
 		},
 		lst: lst,
 	}
+	for _, o := range opts {
+		o(&w.writer)
+	}
+	return w.wrap()
+}
+
+// NewBinaryWriterAppend creates a new binary writer that continues a stream
+// whose local symbol table is currently lst, such as one returned by the
+// SymbolTable method of a finished binary writer. It writes no binary version
+// marker, and the local symbol table it constructs appends to lst rather than
+// replacing it, so only symbols that lst does not already define are written.
+// A nil lst, as for a stream whose values have used no symbols so far, stands
+// for a table with only the system symbols.
+//
+// To append to an existing binary document whose writer is long gone, read it to
+// the end and pass the reader's SymbolTable, which is the table in effect at the
+// end of the document; the reader needs a catalog with any shared tables the
+// document imports. The new values are then written, without a version marker,
+// straight after the old ones, so the two read back as a single document.
+func NewBinaryWriterAppend(out io.Writer, lst SymbolTable, opts ...WriterOption) Writer {
+	w := &binaryWriter{
+		writer: writer{
+			out: out,
+		},
+		appending: true,
+	}
+	if lst == nil {
+		w.lstb = NewSymbolTableBuilder()
+	} else {
+		w.lstb = NewSymbolTableBuilderFromLST(lst)
+		w.prior = len(lst.Symbols())
+	}
+	w.bufs.push(&datagram{})
+	for _, o := range opts {
+		o(&w.writer)
+	}
+	return w.wrap()
+}
+
+// Wrap wraps a new binary writer in whatever its options call for.
+func (w *binaryWriter) wrap() Writer {
+	if !w.canonical {
+		return w.wrapErrors(w)
+	}
+	cw := &validatingWriter{w: w, canonical: true}
+	return w.wrapErrors(cw, &cw.err)
+}
+
+// SymbolTable returns the local symbol table values have been written with.
+func (w *binaryWriter) SymbolTable() SymbolTable {
+	if w.lst != nil {
+		return w.lst
+	}
+	return w.lstb.Build()
 }
 
 // WriteNull writes an untyped null.
@@ -67,8 +134,13 @@ func (w *binaryWriter) WriteBool(val bool) error {
 
 // WriteInt writes an integer.
 func (w *binaryWriter) WriteInt(val int64) error {
+	return w.writeValue("Writer.WriteInt", appendIntValue(make([]byte, 0, 9), val))
+}
+
+// AppendIntValue appends the encoding of an int value, tag and all, to b.
+func appendIntValue(b []byte, val int64) []byte {
 	if val == 0 {
-		return w.writeValue("Writer.WriteInt", []byte{0x20})
+		return append(b, 0x20)
 	}
 
 	code := byte(0x20)
@@ -79,14 +151,8 @@ func (w *binaryWriter) WriteInt(val int64) error {
 		mag = uint64(-val)
 	}
 
-	len := uintLen(mag)
-	buflen := len + tagLen(len)
-
-	buf := make([]byte, 0, buflen)
-	buf = appendTag(buf, code, len)
-	buf = appendUint(buf, mag)
-
-	return w.writeValue("Writer.WriteInt", buf)
+	b = appendTag(b, code, uintLen(mag))
+	return appendUint(b, mag)
 }
 
 // WriteUint writes an unsigned integer.
@@ -155,28 +221,45 @@ func (w *binaryWriter) writeBigInt(val *big.Int) error {
 
 // WriteFloat writes a floating-point value.
 func (w *binaryWriter) WriteFloat(val float64) error {
+	return w.writeValue("Writer.WriteFloat", appendFloatValue(make([]byte, 0, 9), val, w.canonical))
+}
+
+// AppendFloatValue appends the encoding of a float value, tag and all, to b. If
+// short is true, it uses four bytes rather than eight if they can hold the value
+// exactly.
+func appendFloatValue(b []byte, val float64, short bool) []byte {
 	if val == 0 {
-		return w.writeValue("Writer.WriteFloat", []byte{0x40})
+		return append(b, 0x40)
 	}
 
-	bs := make([]byte, 9)
-	bs[0] = 0x48
-
-	bits := math.Float64bits(val)
-	binary.BigEndian.PutUint64(bs[1:], bits)
+	if short && float64(float32(val)) == val {
+		var bs [4]byte
+		binary.BigEndian.PutUint32(bs[:], math.Float32bits(float32(val)))
+		return append(append(b, 0x44), bs[:]...)
+	}
 
-	return w.writeValue("Writer.WriteFloat", bs)
+	var bs [8]byte
+	binary.BigEndian.PutUint64(bs[:], math.Float64bits(val))
+	return append(append(b, 0x48), bs[:]...)
 }
 
 // WriteDecimal writes a decimal value.
 func (w *binaryWriter) WriteDecimal(val *Decimal) error {
 	coef, exp := val.CoEx()
+	negZero := val.IsNegativeZero()
+
+	// 0d0 is written with no representation at all. Otherwise the exponent is
+	// always present, followed by the coefficient unless it's (positive) zero.
+	// Negative zero needs a coefficient to hold its sign bit.
+	hasCoef := coef.Sign() != 0 || negZero
 
 	vlen := uint64(0)
-	if exp != 0 {
+	if exp != 0 || hasCoef {
 		vlen += varIntLen(int64(exp))
 	}
-	if coef.Sign() != 0 {
+	if negZero {
+		vlen++
+	} else {
 		vlen += bigIntLen(coef)
 	}
 
@@ -184,63 +267,165 @@ func (w *binaryWriter) WriteDecimal(val *Decimal) error {
 	buf := make([]byte, 0, buflen)
 
 	buf = appendTag(buf, 0x50, vlen)
-	if exp != 0 {
+	if exp != 0 || hasCoef {
 		buf = appendVarInt(buf, int64(exp))
 	}
-	buf = appendBigInt(buf, coef)
+	if negZero {
+		buf = append(buf, 0x80)
+	} else {
+		buf = appendBigInt(buf, coef)
+	}
 
 	return w.writeValue("Writer.WriteDecimal", buf)
 }
 
+// WriteMoney writes an annotated monetary amount.
+func (w *binaryWriter) WriteMoney(annotation string, amount string) error {
+	d, err := w.parseMoney(amount)
+	if err != nil {
+		return err
+	}
+	w.Annotation(annotation)
+	return w.WriteDecimal(d)
+}
+
 // WriteTimestamp writes a timestamp value.
 func (w *binaryWriter) WriteTimestamp(val time.Time) error {
+	return w.writeTimestamp("Writer.WriteTimestamp", val, TimestampPrecisionNanosecond)
+}
+
+// WriteTimestampWithPrecision writes a timestamp value with the given precision.
+func (w *binaryWriter) WriteTimestampWithPrecision(val time.Time, precision TimestampPrecision) error {
+	return w.writeTimestamp("Writer.WriteTimestampWithPrecision", val, precision)
+}
+
+// writeTimestamp writes a timestamp value with the given precision.
+func (w *binaryWriter) writeTimestamp(api string, val time.Time, precision TimestampPrecision) error {
+	if w.err != nil {
+		return w.err
+	}
+	if w.err = checkPrecision(api, precision); w.err != nil {
+		return w.err
+	}
+
 	_, offset := val.Zone()
 	offset /= 60
 	utc := val.In(time.UTC)
 
-	vlen := timeLen(offset, utc)
+	if precision <= TimestampPrecisionDay {
+		// Dates don't have an offset, so take them as-is.
+		utc = time.Date(val.Year(), val.Month(), val.Day(), 0, 0, 0, 0, time.UTC)
+	}
+
+	vlen := timeLen(offset, utc, precision)
 	buflen := vlen + tagLen(vlen)
 
 	buf := make([]byte, 0, buflen)
 
 	buf = appendTag(buf, 0x60, vlen)
-	buf = appendTime(buf, offset, utc)
+	buf = appendTime(buf, offset, utc, precision)
 
-	return w.writeValue("Writer.WriteTimestamp", buf)
+	return w.writeValue(api, buf)
 }
 
 // WriteSymbol writes a symbol value.
 func (w *binaryWriter) WriteSymbol(val string) error {
-	id, err := w.resolve("Writer.WriteSymbol", val)
-	if err != nil {
-		w.err = err
-		return err
+	id, ok := w.resolve(val)
+	if !ok {
+		w.err = undefinedSymbolError("Writer.WriteSymbol", fmt.Sprintf("symbol '%v'", val))
+		return w.err
 	}
+	return w.writeSymbolID("Writer.WriteSymbol", id)
+}
+
+// WriteSymbolByID writes a symbol value given its symbol ID.
+func (w *binaryWriter) WriteSymbolByID(id uint64) error {
+	return w.writeSymbolID("Writer.WriteSymbolByID", id)
+}
 
-	vlen := uintLen(uint64(id))
+func (w *binaryWriter) writeSymbolID(api string, id uint64) error {
+	if w.err != nil {
+		return w.err
+	}
+	if !w.sidInRange(id) {
+		w.err = w.sidRangeError(api, fmt.Sprintf("symbol $%v", id), id)
+		return w.err
+	}
+
+	vlen := uintLen(id)
 	buflen := vlen + tagLen(vlen)
 	buf := make([]byte, 0, buflen)
 
 	buf = appendTag(buf, 0x70, vlen)
-	buf = appendUint(buf, uint64(id))
+	buf = appendUint(buf, id)
 
-	return w.writeValue("Writer.WriteSymbol", buf)
+	return w.writeValue(api, buf)
 }
 
 // WriteString writes a string.
 func (w *binaryWriter) WriteString(val string) error {
-	if len(val) == 0 {
-		return w.writeValue("Writer.WriteString", []byte{0x80})
-	}
+	return w.writeValue("Writer.WriteString", appendStringValue(make([]byte, 0, stringValueLen(val)), val))
+}
 
+// StringValueLen returns the length of the encoding of a string value.
+func stringValueLen(val string) uint64 {
 	vlen := uint64(len(val))
-	buflen := vlen + tagLen(vlen)
+	return vlen + tagLen(vlen)
+}
+
+// AppendStringValue appends the encoding of a string value, tag and all, to b.
+func appendStringValue(b []byte, val string) []byte {
+	b = appendTag(b, 0x80, uint64(len(val)))
+	return append(b, val...)
+}
+
+// WriteIntList writes a list of ints.
+func (w *binaryWriter) WriteIntList(vals []int64) error {
+	buf := make([]byte, 0, 9*len(vals))
+	for _, val := range vals {
+		buf = appendIntValue(buf, val)
+	}
+	return w.writeList("Writer.WriteIntList", buf)
+}
+
+// WriteFloatList writes a list of floats.
+func (w *binaryWriter) WriteFloatList(vals []float64) error {
+	buf := make([]byte, 0, 9*len(vals))
+	for _, val := range vals {
+		buf = appendFloatValue(buf, val, w.canonical)
+	}
+	return w.writeList("Writer.WriteFloatList", buf)
+}
+
+// WriteStringList writes a list of strings.
+func (w *binaryWriter) WriteStringList(vals []string) error {
+	buflen := uint64(0)
+	for _, val := range vals {
+		buflen += stringValueLen(val)
+	}
+
 	buf := make([]byte, 0, buflen)
+	for _, val := range vals {
+		buf = appendStringValue(buf, val)
+	}
+	return w.writeList("Writer.WriteStringList", buf)
+}
 
-	buf = appendTag(buf, 0x80, vlen)
-	buf = append(buf, val...)
+// WriteList writes a list given the already-encoded values it contains.
+func (w *binaryWriter) writeList(api string, contents []byte) error {
+	if w.err != nil {
+		return w.err
+	}
+	if w.err = w.beginValue(api); w.err != nil {
+		return w.err
+	}
 
-	return w.writeValue("Writer.WriteString", buf)
+	if w.err = w.writeLob(0xB0, contents); w.err != nil {
+		return w.err
+	}
+
+	w.err = w.endValue()
+	return w.err
 }
 
 // WriteClob writes a clob.
@@ -277,6 +462,66 @@ func (w *binaryWriter) WriteBlob(val []byte) error {
 	return w.err
 }
 
+// WriteBlobString writes a blob given as a base64 string.
+func (w *binaryWriter) WriteBlobString(base64Str string) error {
+	val, err := w.parseBlobString(base64Str)
+	if err != nil {
+		return err
+	}
+	return w.WriteBlob(val)
+}
+
+// WriteRawText writes the value held by the given Ion text.
+func (w *binaryWriter) WriteRawText(s string) error {
+	if w.err != nil {
+		return w.err
+	}
+
+	val, _, err := parseRawText(s)
+	if err != nil {
+		w.err = err
+		return w.err
+	}
+	return val.WriteTo(w)
+}
+
+// WritePadding writes n bytes of NOP padding.
+func (w *binaryWriter) WritePadding(n int) error {
+	if w.err != nil {
+		return w.err
+	}
+	if n < 0 {
+		w.err = &UsageError{"Writer.WritePadding", "negative length"}
+		return w.err
+	}
+	if n == 0 {
+		return nil
+	}
+
+	// The field name and annotations are for the next value, so put them aside
+	// while writing the symbol table, if need be.
+	name, as := w.fieldName, w.annotations
+	w.clear()
+	if w.err = w.writeFixedLST(); w.err != nil {
+		return w.err
+	}
+	w.fieldName, w.annotations = name, as
+
+	buf := make([]byte, 0, n)
+	if w.inStruct() {
+		if n < 2 {
+			w.err = &UsageError{"Writer.WritePadding", "padding in a struct takes at least two bytes"}
+			return w.err
+		}
+		// Field $0, which has no text.
+		buf = append(buf, 0x80)
+	}
+	buf = appendPadding(buf, uint64(n-len(buf)))
+
+	w.err = w.write(buf)
+	return w.err
+}
+
 func (w *binaryWriter) writeLob(code byte, val []byte) error {
 	vlen := uint64(len(val))
 
@@ -372,7 +617,8 @@ func (w *binaryWriter) Finish() error {
 		}
 	}
 
-	return nil
+	w.err = w.finalize()
+	return w.err
 }
 
 // Emit emits the given node. If we're currently at the top level, that
@@ -420,14 +666,38 @@ func (w *binaryWriter) writeTag(code byte, len uint64) error {
 	return w.write(tag)
 }
 
-// WriteLST writes out a local symbol table.
+// WriteLST writes out a local symbol table, if there is one, preceded by a binary
+// version marker unless we're appending.
 func (w *binaryWriter) writeLST(lst SymbolTable) error {
-	if err := w.write([]byte{0xE0, 0x01, 0x00, 0xEA}); err != nil {
+	if w.appending {
+		// Only the symbols added to the table we're appending to.
+		return writeAppendedLST(w, lst.Symbols()[w.prior:])
+	}
+
+	if err := w.write(binaryVersionMarker(w.version())); err != nil {
 		return err
 	}
+	if lst == nil {
+		return nil
+	}
 	return lst.WriteTo(w)
 }
 
+// WriteFixedLST writes out our fixed local symbol table, if we have one and haven't
+// written it out yet, or just the version marker if a reader will already have it.
+func (w *binaryWriter) writeFixedLST() error {
+	if w.lst == nil || w.wroteLST {
+		return nil
+	}
+
+	w.wroteLST = true
+	lst := w.lst
+	if w.omitLST {
+		lst = nil
+	}
+	return w.writeLST(lst)
+}
+
 // BeginValue begins the process of writing a value by writing out
 // its field name and annotations.
 func (w *binaryWriter) beginValue(api string) error {
@@ -437,12 +707,8 @@ func (w *binaryWriter) beginValue(api string) error {
 	as := w.annotations
 	w.clear()
 
-	// If we have a local symbol table and haven't written it out yet, do that now.
-	if w.lst != nil && !w.wroteLST {
-		w.wroteLST = true
-		if err := w.writeLST(w.lst); err != nil {
-			return err
-		}
+	if err := w.writeFixedLST(); err != nil {
+		return err
 	}
 
 	if w.inStruct() {
@@ -450,9 +716,9 @@ func (w *binaryWriter) beginValue(api string) error {
 			return &UsageError{api, "field name not set"}
 		}
 
-		id, err := w.resolve(api, name)
-		if err != nil {
-			return err
+		id, ok := w.resolve(name)
+		if !ok || !w.sidInRange(id) {
+			return w.symbolError(api, fmt.Sprintf("field name '%v'", name), id, ok)
 		}
 
 		buf := make([]byte, 0, 10)
@@ -467,9 +733,13 @@ func (w *binaryWriter) beginValue(api string) error {
 		idlen := uint64(0)
 
 		for i, a := range as {
-			id, err := w.resolve(api, a)
-			if err != nil {
-				return err
+			id, ok := w.resolve(a)
+			if !ok || !w.sidInRange(id) {
+				desc := fmt.Sprintf("annotation '%v'", a)
+				if name != "" {
+					desc += fmt.Sprintf(" on field '%v'", name)
+				}
+				return w.symbolError(api, desc, id, ok)
 			}
 
 			ids[i] = id
@@ -540,23 +810,53 @@ func (w *binaryWriter) end(api string, t ctx) error {
 	return w.endValue()
 }
 
-// Resolve resolves a symbol to its ID.
-func (w *binaryWriter) resolve(api, sym string) (uint64, error) {
+// Resolve resolves a symbol to its ID, interning it in the local symbol table being
+// built if there is one. It returns false if the writer has a fixed local symbol
+// table that doesn't define the symbol.
+func (w *binaryWriter) resolve(sym string) (uint64, bool) {
 	if strings.HasPrefix(sym, "$") {
 		id, err := strconv.ParseUint(sym[1:], 10, 64)
 		if err == nil {
-			return id, nil
+			return id, true
 		}
 	}
 
 	if w.lst != nil {
-		id, ok := w.lst.FindByName(sym)
-		if !ok {
-			return 0, &UsageError{api, fmt.Sprintf("symbol '%v' not defined", sym)}
-		}
-		return id, nil
+		return w.lst.FindByName(sym)
 	}
 
 	id, _ := w.lstb.Add(sym)
-	return id, nil
+	return id, true
+}
+
+// SIDInRange returns false if the writer has a fixed local symbol table and the given
+// symbol ID is beyond its max ID, so a reader would have no way of knowing what it
+// stands for.
+func (w *binaryWriter) sidInRange(id uint64) bool {
+	return w.lst == nil || id <= w.lst.MaxID()
+}
+
+// SIDRangeError returns an error for a symbol, described by desc, whose ID is beyond
+// the max ID of the writer's fixed local symbol table. The description is only built
+// by callers once they know they need it, to keep it off the writer's hot path.
+func (w *binaryWriter) sidRangeError(api, desc string, id uint64) error {
+	msg := fmt.Sprintf("%v has id %v, beyond the local symbol table's max id of %v", desc, id, w.lst.MaxID())
+	return &UsageError{api, msg}
+}
+
+// SymbolError returns an error for a symbol, described by desc, that's either not
+// defined in the writer's fixed local symbol table or out of its range.
+func (w *binaryWriter) symbolError(api, desc string, id uint64, defined bool) error {
+	if !defined {
+		return undefinedSymbolError(api, desc)
+	}
+	return w.sidRangeError(api, desc, id)
+}
+
+// UndefinedSymbolError returns an error for a symbol, described by desc, that isn't
+// defined in a writer's fixed local symbol table.
+func undefinedSymbolError(api, desc string) error {
+	msg := fmt.Sprintf("%v not defined in the local symbol table; add it to the table, "+
+		"or use NewBinaryWriter to build the table while writing", desc)
+	return &UsageError{api, msg}
 }
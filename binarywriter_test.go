@@ -2,10 +2,14 @@ package ion
 
 import (
 	"bytes"
+	"encoding/binary"
 	"encoding/hex"
 	"fmt"
+	"hash/crc32"
+	"io/ioutil"
 	"math"
 	"math/big"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
@@ -139,14 +143,32 @@ func TestWriteBinarySymbol(t *testing.T) {
 		0x71, 0x04, // name
 		0x71, 0x05, // version
 		0x71, 0x09, // $ion_shared_symbol_table
-		0x74, 0xFF, 0xFF, 0xFF, 0xFF, // $4294967295
+		0x71, 0x6F, // $111, the last in the table
 	}
 	testBinaryWriter(t, eval, func(w Writer) {
 		w.WriteSymbol("$ion")
 		w.WriteSymbol("name")
 		w.WriteSymbol("version")
 		w.WriteSymbol("$ion_shared_symbol_table")
-		w.WriteSymbol("$4294967295")
+		w.WriteSymbol("$111")
+	})
+}
+
+func TestWriteBinarySymbolZero(t *testing.T) {
+	eval := []byte{
+		0x71, 0x00, // $0
+		0xD6,                         // {
+		0x80,                         // $0:
+		0xE4, 0x81, 0x80, 0x71, 0x00, // $0::$0
+		// }
+	}
+	testBinaryWriter(t, eval, func(w Writer) {
+		w.WriteSymbolByID(0)
+		w.BeginStruct()
+		w.FieldName("$0")
+		w.Annotation("$0")
+		w.WriteSymbolByID(0)
+		w.EndStruct()
 	})
 }
 
@@ -173,6 +195,32 @@ func TestWriteBinaryTimestamp(t *testing.T) {
 	})
 }
 
+func TestWriteBinaryTimestampWithPrecision(t *testing.T) {
+	eval := []byte{
+		0x63, 0xC0, 0x0F, 0xE4, // 2020T
+		0x64, 0xC0, 0x0F, 0xE4, 0x86, // 2020-06T
+		0x65, 0xC0, 0x0F, 0xE4, 0x86, 0x8F, // 2020-06-15
+		0x68, 0x00, 0xF8, 0x0F, 0xE4, 0x86, 0x8F, 0x8A, 0x9E, // 2020-06-15T12:30+02:00
+	}
+
+	val := time.Date(2020, 6, 15, 12, 30, 45, 0, time.FixedZone("wtf", 7200))
+
+	testBinaryWriter(t, eval, func(w Writer) {
+		w.WriteTimestampWithPrecision(val, TimestampPrecisionYear)
+		w.WriteTimestampWithPrecision(val, TimestampPrecisionMonth)
+		w.WriteTimestampWithPrecision(val, TimestampPrecisionDay)
+		w.WriteTimestampWithPrecision(val, TimestampPrecisionMinute)
+	})
+
+	r := NewReaderBytes(writeBinary(t, func(w Writer) {
+		w.WriteTimestampWithPrecision(val, TimestampPrecisionYear)
+		w.WriteTimestampWithPrecision(val, TimestampPrecisionSecond)
+	}))
+	_timestampP(t, r, time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), TimestampPrecisionYear)
+	_timestampP(t, r, val, TimestampPrecisionSecond)
+	_eof(t, r)
+}
+
 func TestWriteBinaryDecimal(t *testing.T) {
 	eval := []byte{
 		0x50,       // 0.
@@ -181,6 +229,9 @@ func TestWriteBinaryDecimal(t *testing.T) {
 		0x53, 0xC3, 0x83, 0xE8, // -1.000, aka -1000 x 10^-3
 		0x53, 0x00, 0xE4, 0x01, // 1d100, aka 1 * 10^100
 		0x53, 0x00, 0xE4, 0x81, // -1d100, aka -1 * 10^100
+		0x52, 0x80, 0x0C, // 12.
+		0x52, 0x80, 0x80, // -0.
+		0x52, 0xC2, 0x80, // -0.00
 	}
 
 	testBinaryWriter(t, eval, func(w Writer) {
@@ -190,9 +241,64 @@ func TestWriteBinaryDecimal(t *testing.T) {
 		w.WriteDecimal(MustParseDecimal("-1.000"))
 		w.WriteDecimal(MustParseDecimal("1d100"))
 		w.WriteDecimal(MustParseDecimal("-1d100"))
+		w.WriteDecimal(MustParseDecimal("12."))
+		w.WriteDecimal(MustParseDecimal("-0."))
+		w.WriteDecimal(MustParseDecimal("-0.00"))
 	})
 }
 
+func TestWriteBinaryMoney(t *testing.T) {
+	eval := []byte{
+		0xE6, 0x81, 0xEE, // foo::
+		0x53, 0xC2, 0x04, 0xE2, // 12.50, aka 1250 x 10^-2
+	}
+	testBinaryWriter(t, eval, func(w Writer) {
+		w.WriteMoney("foo", "12.50")
+	})
+}
+
+func TestWriteBinaryNegativeZeroDecimal(t *testing.T) {
+	in := "0. -0. 0d-2 -0d-2"
+
+	vs, err := ReadValues(NewReaderStr(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buf := bytes.Buffer{}
+	w := NewBinaryWriter(&buf)
+	for i := range vs {
+		vs[i].WriteTo(w)
+	}
+	if err := w.Finish(); err != nil {
+		t.Fatal(err)
+	}
+
+	r := NewReaderBytes(buf.Bytes())
+	for _, eneg := range []bool{false, true, false, true} {
+		_next(t, r, DecimalType)
+		val, err := r.DecimalValue()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if val.IsNegativeZero() != eneg {
+			t.Errorf("expected IsNegativeZero=%v for %v", eneg, val)
+		}
+	}
+	_eof(t, r)
+
+	eq, err := Equal([]byte(in), buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !eq {
+		t.Errorf("expected %v to round-trip", in)
+	}
+	if eq, _ := Equal([]byte("0."), []byte("-0.")); eq {
+		t.Error("expected 0. and -0. to be distinct")
+	}
+}
+
 func TestWriteBinaryFloats(t *testing.T) {
 	eval := []byte{
 		0x40,                                                 // 0
@@ -212,6 +318,149 @@ func TestWriteBinaryFloats(t *testing.T) {
 	})
 }
 
+func TestWriteBinaryBlobString(t *testing.T) {
+	eval := []byte{
+		0xA2, 'h', 'i', // {{aGk=}}
+		0xA0, // {{}}
+	}
+	testBinaryWriter(t, eval, func(w Writer) {
+		w.WriteBlobString("aGk=")
+		w.WriteBlobString("")
+	})
+}
+
+func TestWriteBinaryLists(t *testing.T) {
+	ints := []int64{0, 1, -1, 255, -256, math.MaxInt64, math.MinInt64}
+	floats := []float64{0, 1.5, -2.25, math.Inf(1)}
+	strs := []string{"", "foo", strings.Repeat("x", 20), strings.Repeat("y", 200)}
+
+	test := func(name string, bulk, manual func(w Writer)) {
+		t.Run(name, func(t *testing.T) {
+			eval := writeBinary(t, func(w Writer) {
+				w.BeginStruct()
+				w.FieldName("foo")
+				w.Annotation("bar")
+				manual(w)
+				w.EndStruct()
+			})
+			val := writeBinary(t, func(w Writer) {
+				w.BeginStruct()
+				w.FieldName("foo")
+				w.Annotation("bar")
+				bulk(w)
+				w.EndStruct()
+			})
+			if !bytes.Equal(val, eval) {
+				t.Errorf("expected %v, got %v", fmtbytes(eval), fmtbytes(val))
+			}
+		})
+	}
+
+	test("ints", func(w Writer) {
+		w.WriteIntList(ints)
+	}, func(w Writer) {
+		w.BeginList()
+		for _, val := range ints {
+			w.WriteInt(val)
+		}
+		w.EndList()
+	})
+
+	test("floats", func(w Writer) {
+		w.WriteFloatList(floats)
+	}, func(w Writer) {
+		w.BeginList()
+		for _, val := range floats {
+			w.WriteFloat(val)
+		}
+		w.EndList()
+	})
+
+	test("strings", func(w Writer) {
+		w.WriteStringList(strs)
+	}, func(w Writer) {
+		w.BeginList()
+		for _, val := range strs {
+			w.WriteString(val)
+		}
+		w.EndList()
+	})
+
+	test("empty", func(w Writer) {
+		w.WriteIntList(nil)
+	}, func(w Writer) {
+		w.BeginList()
+		w.EndList()
+	})
+}
+
+func BenchmarkWriteIntList(b *testing.B) {
+	vals := make([]int64, 100000)
+	for i := range vals {
+		vals[i] = int64(i*7919) - 50000
+	}
+
+	b.Run("WriteIntList", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			w := NewBinaryWriter(ioutil.Discard)
+			w.WriteIntList(vals)
+			if err := w.Finish(); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("WriteInt", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			w := NewBinaryWriter(ioutil.Discard)
+			w.BeginList()
+			for _, val := range vals {
+				w.WriteInt(val)
+			}
+			w.EndList()
+			if err := w.Finish(); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func TestWriteBinaryRawText(t *testing.T) {
+	raw := bytes.Buffer{}
+	w := NewBinaryWriter(&raw)
+	w.BeginStruct()
+	w.FieldName("f")
+	w.Annotation("x")
+	w.WriteRawText("y::{a:1, b:[two, 3.5]}")
+	w.EndStruct()
+	if err := w.Finish(); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := bytes.Buffer{}
+	w = NewBinaryWriter(&expected)
+	w.BeginStruct()
+	w.FieldName("f")
+	w.Annotations("x", "y")
+	w.BeginStruct()
+	w.FieldName("a")
+	w.WriteInt(1)
+	w.FieldName("b")
+	w.BeginList()
+	w.WriteSymbol("two")
+	w.WriteDecimal(MustParseDecimal("3.5"))
+	w.EndList()
+	w.EndStruct()
+	w.EndStruct()
+	if err := w.Finish(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(raw.Bytes(), expected.Bytes()) {
+		t.Errorf("expected %v, got %v", fmtbytes(expected.Bytes()), fmtbytes(raw.Bytes()))
+	}
+}
+
 func TestWriteBinaryBigInts(t *testing.T) {
 	eval := []byte{
 		0x20,       // 0
@@ -241,6 +490,437 @@ func TestWriteBinaryReallyBigInts(t *testing.T) {
 	})
 }
 
+func TestBigIntRoundTrip(t *testing.T) {
+	huge := new(big.Int).Lsh(big.NewInt(1), 200)
+	ints := []*big.Int{
+		new(big.Int).SetUint64(math.MaxInt64 + 1), // intLongMaxValuePlusOne
+		new(big.Int).Neg(new(big.Int).SetUint64(math.MaxInt64 + 2)),
+		new(big.Int).SetUint64(math.MaxUint64),
+		new(big.Int).Add(new(big.Int).SetUint64(math.MaxUint64), big.NewInt(1)),
+		huge,
+		new(big.Int).Neg(huge),
+	}
+
+	for _, binary := range []bool{true, false} {
+		buf := bytes.Buffer{}
+		var w Writer
+		if binary {
+			w = NewBinaryWriter(&buf)
+		} else {
+			w = NewTextWriter(&buf)
+		}
+		for _, i := range ints {
+			w.WriteBigInt(i)
+		}
+		if err := w.Finish(); err != nil {
+			t.Fatal(err)
+		}
+
+		r := NewReaderBytes(buf.Bytes())
+		for _, i := range ints {
+			_next(t, r, IntType)
+
+			val, err := r.BigIntValue()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if val.Cmp(i) != 0 {
+				t.Errorf("expected %v, got %v", i, val)
+			}
+
+			// None of them fit, and Int64Value says so rather than truncating.
+			if _, err := r.Int64Value(); err == nil {
+				t.Errorf("expected an error reading %v as an int64", i)
+			}
+		}
+		_eof(t, r)
+	}
+}
+
+func TestWriteBinaryImportedSymbols(t *testing.T) {
+	buf := bytes.Buffer{}
+	w := NewBinaryWriter(&buf)
+	w.WriteSymbol("name")
+	w.WriteSymbol("version")
+	if err := w.Finish(); err != nil {
+		t.Fatal(err)
+	}
+
+	// System symbols use their system IDs, without a local symbol table.
+	eval := []byte{0xE0, 0x01, 0x00, 0xEA, 0x71, 0x04, 0x71, 0x05}
+	if !bytes.Equal(buf.Bytes(), eval) {
+		t.Errorf("expected %v, got %v", fmtbytes(eval), fmtbytes(buf.Bytes()))
+	}
+
+	buf.Reset()
+	w = NewBinaryWriter(&buf, NewSharedSymbolTable("shared", 1, []string{"foo"}))
+	w.WriteSymbol("name")
+	w.WriteSymbol("foo")
+	w.WriteSymbol("bar")
+	w.WriteSymbol("foo")
+	if err := w.Finish(); err != nil {
+		t.Fatal(err)
+	}
+
+	// As do symbols from other imports; only new symbols are added locally.
+	if syms := w.SymbolTable().Symbols(); len(syms) != 1 || syms[0] != "bar" {
+		t.Errorf("expected local symbols [bar], got %v", syms)
+	}
+	eval = []byte{0x71, 0x04, 0x71, 0x0A, 0x71, 0x0B, 0x71, 0x0A}
+	if val := buf.Bytes()[buf.Len()-len(eval):]; !bytes.Equal(val, eval) {
+		t.Errorf("expected %v, got %v", fmtbytes(eval), fmtbytes(val))
+	}
+}
+
+func TestWriteBinaryFinalizeHook(t *testing.T) {
+	buf := bytes.Buffer{}
+	w := NewBinaryWriterOpts(&buf, nil, WithFinalizeHook(func(doc []byte) []byte {
+		var crc [4]byte
+		binary.BigEndian.PutUint32(crc[:], crc32.ChecksumIEEE(doc))
+		return append(doc, crc[:]...)
+	}))
+	w.WriteInt(42)
+	if err := w.Finish(); err != nil {
+		t.Fatal(err)
+	}
+
+	doc := []byte{0xE0, 0x01, 0x00, 0xEA, 0x21, 0x2A}
+	eval := append(doc, 0, 0, 0, 0)
+	binary.BigEndian.PutUint32(eval[len(doc):], crc32.ChecksumIEEE(doc))
+	if !bytes.Equal(buf.Bytes(), eval) {
+		t.Errorf("expected %v, got %v", fmtbytes(eval), fmtbytes(buf.Bytes()))
+	}
+}
+
+func TestWriteBinaryStructWithoutLST(t *testing.T) {
+	buf := bytes.Buffer{}
+	w := NewBinaryWriter(&buf)
+	w.BeginStruct()
+	{
+		w.FieldName("a")
+		w.WriteInt(1)
+		w.FieldName("b")
+		w.BeginStruct()
+		{
+			w.FieldName("a")
+			w.WriteInt(2)
+		}
+		w.EndStruct()
+	}
+	w.EndStruct()
+	if err := w.Finish(); err != nil {
+		t.Fatal(err)
+	}
+
+	eval := []byte{
+		0xE0, 0x01, 0x00, 0xEA, // $ion_1_0
+		0xE9, 0x81, 0x83, 0xD6, // $ion_symbol_table::{
+		0x87, 0xB4, // symbols: [
+		0x81, 'a', 0x81, 'b', // "a", "b" ]}
+		0xD8,             // {
+		0x8A, 0x21, 0x01, // a: 1
+		0x8B, 0xD3, // b: {
+		0x8A, 0x21, 0x02, // a: 2 }}
+	}
+	if !bytes.Equal(buf.Bytes(), eval) {
+		t.Errorf("expected %v, got %v", fmtbytes(eval), fmtbytes(buf.Bytes()))
+	}
+
+	r := NewReaderBytes(buf.Bytes())
+	_struct(t, r, func(t *testing.T, r Reader) {
+		_intAF(t, r, "a", nil, 1)
+		_nextAF(t, r, StructType, "b", nil)
+		if err := r.StepIn(); err != nil {
+			t.Fatal(err)
+		}
+		_intAF(t, r, "a", nil, 2)
+		_eof(t, r)
+		if err := r.StepOut(); err != nil {
+			t.Fatal(err)
+		}
+		_eof(t, r)
+	})
+	_eof(t, r)
+}
+
+func TestWriteBinaryAnnotationsWithoutLST(t *testing.T) {
+	buf := bytes.Buffer{}
+	w := NewBinaryWriter(&buf)
+	w.Annotations("foo", "bar")
+	w.BeginStruct()
+	{
+		w.FieldName("baz")
+		w.Annotation("foo")
+		w.WriteInt(1)
+		w.FieldName("qux")
+		w.Annotation("name")
+		w.WriteString("quux")
+	}
+	w.EndStruct()
+	if err := w.Finish(); err != nil {
+		t.Fatal(err)
+	}
+
+	if syms := w.SymbolTable().Symbols(); !reflect.DeepEqual(syms, []string{"foo", "bar", "baz", "qux"}) {
+		t.Errorf("expected local symbols [foo bar baz qux], got %v", syms)
+	}
+
+	r := NewReaderBytes(buf.Bytes())
+	_nextAF(t, r, StructType, "", []string{"foo", "bar"})
+	if err := r.StepIn(); err != nil {
+		t.Fatal(err)
+	}
+	_intAF(t, r, "baz", []string{"foo"}, 1)
+	_stringAF(t, r, "qux", []string{"name"}, "quux")
+	_eof(t, r)
+	if err := r.StepOut(); err != nil {
+		t.Fatal(err)
+	}
+	_eof(t, r)
+}
+
+func TestWriteBinaryUndefinedSymbols(t *testing.T) {
+	lst := NewLocalSymbolTable(nil, []string{"foo"})
+	test := func(emsg string, f func(w Writer) error) {
+		t.Run(emsg, func(t *testing.T) {
+			err := f(NewBinaryWriterLST(&bytes.Buffer{}, lst))
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if !strings.Contains(err.Error(), emsg) {
+				t.Errorf("expected an error containing %q, got %q", emsg, err.Error())
+			}
+		})
+	}
+
+	test("Writer.WriteSymbol: symbol 'bar' not defined", func(w Writer) error {
+		return w.WriteSymbol("bar")
+	})
+	test("Writer.WriteInt: annotation 'bar' not defined", func(w Writer) error {
+		w.Annotations("foo", "bar")
+		return w.WriteInt(1)
+	})
+	test("Writer.BeginList: field name 'bar' not defined", func(w Writer) error {
+		w.BeginStruct()
+		w.FieldName("bar")
+		return w.BeginList()
+	})
+	test("Writer.WriteString: annotation 'bar' on field 'foo' not defined", func(w Writer) error {
+		w.BeginStruct()
+		w.FieldName("foo")
+		w.Annotation("bar")
+		return w.WriteString("baz")
+	})
+
+	// Symbol IDs beyond the table's max ID of 10 ($ion_1_0's nine, plus foo).
+	test("Writer.WriteSymbolByID: symbol $11 has id 11, beyond the local symbol table's max id of 10", func(w Writer) error {
+		return w.WriteSymbolByID(11)
+	})
+	test("Writer.WriteSymbol: symbol $12 has id 12", func(w Writer) error {
+		return w.WriteSymbol("$12")
+	})
+	test("Writer.WriteInt: field name '$11' has id 11", func(w Writer) error {
+		w.BeginStruct()
+		w.FieldName("$11")
+		return w.WriteInt(1)
+	})
+	test("Writer.BeginStruct: annotation '$100' has id 100", func(w Writer) error {
+		w.Annotations("foo", "$100")
+		return w.BeginStruct()
+	})
+}
+
+func TestWriteBinarySIDsInRange(t *testing.T) {
+	lst := NewLocalSymbolTable(nil, []string{"foo"})
+	buf := bytes.Buffer{}
+	w := NewBinaryWriterLST(&buf, lst)
+
+	w.Annotation("$10")
+	w.BeginStruct()
+	w.FieldName("$4")
+	w.WriteSymbolByID(10)
+	w.EndStruct()
+	if err := w.Finish(); err != nil {
+		t.Fatal(err)
+	}
+
+	r := NewReaderBytes(buf.Bytes())
+	_nextAF(t, r, StructType, "", []string{"foo"})
+	r.StepIn()
+	_symbolAF(t, r, "name", nil, "foo")
+	_eof(t, r)
+	r.StepOut()
+	_eof(t, r)
+
+	// A writer that builds its own table can refer to any symbol ID.
+	buf.Reset()
+	w = NewBinaryWriter(&buf)
+	w.WriteSymbol("$4294967295")
+	if err := w.Finish(); err != nil {
+		t.Fatal(err)
+	}
+	if eval := []byte{0x74, 0xFF, 0xFF, 0xFF, 0xFF}; !bytes.HasSuffix(buf.Bytes(), eval) {
+		t.Errorf("expected %v at the end, got %v", fmtbytes(eval), fmtbytes(buf.Bytes()))
+	}
+}
+
+func TestWriteBinaryAppend(t *testing.T) {
+	buf := bytes.Buffer{}
+
+	w := NewBinaryWriter(&buf)
+	w.WriteSymbol("foo")
+	w.WriteSymbol("bar")
+	if err := w.Finish(); err != nil {
+		t.Fatal(err)
+	}
+	first := buf.Len()
+
+	w = NewBinaryWriterAppend(&buf, w.SymbolTable())
+	w.WriteSymbol("bar")
+	w.WriteSymbol("baz")
+	if err := w.Finish(); err != nil {
+		t.Fatal(err)
+	}
+
+	eval := []byte{
+		0xEC, 0x81, 0x83, 0xD9, // $ion_symbol_table::{
+		0x86, 0x71, 0x03, // imports: $ion_symbol_table,
+		0x87, 0xB4, // symbols: [
+		0x83, 'b', 'a', 'z', // "baz" ]}
+		0x71, 0x0B, // bar
+		0x71, 0x0C, // baz
+	}
+	if val := buf.Bytes()[first:]; !bytes.Equal(val, eval) {
+		t.Errorf("expected %v, got %v", fmtbytes(eval), fmtbytes(val))
+	}
+
+	r := NewReaderBytes(buf.Bytes())
+	_symbol(t, r, "foo")
+	_symbol(t, r, "bar")
+	_symbol(t, r, "bar")
+	_symbol(t, r, "baz")
+	_eof(t, r)
+
+	if max := w.SymbolTable().MaxID(); max != 12 {
+		t.Errorf("expected max id 12, got %v", max)
+	}
+}
+
+func TestWriteBinaryAppendToExisting(t *testing.T) {
+	shared := NewSharedSymbolTable("shared", 1, []string{"foo"})
+
+	// An existing document, in two segments, whose writers are long gone.
+	buf := bytes.Buffer{}
+	w := NewBinaryWriter(&buf, shared)
+	w.WriteSymbol("foo")
+	w.WriteSymbol("bar")
+	if err := w.Finish(); err != nil {
+		t.Fatal(err)
+	}
+	w = NewBinaryWriterAppend(&buf, w.SymbolTable())
+	w.WriteSymbol("baz")
+	if err := w.Finish(); err != nil {
+		t.Fatal(err)
+	}
+	existing := buf.Len()
+
+	// Recover its symbol table by reading it to the end.
+	r := NewReaderCat(bytes.NewReader(buf.Bytes()), NewCatalog(shared))
+	for r.Next() {
+	}
+	if r.Err() != nil {
+		t.Fatal(r.Err())
+	}
+
+	w = NewBinaryWriterAppend(&buf, r.SymbolTable())
+	w.WriteSymbol("baz")
+	w.WriteSymbol("qux")
+	w.WriteSymbol("foo")
+	if err := w.Finish(); err != nil {
+		t.Fatal(err)
+	}
+
+	// No version marker, and only qux is new.
+	eval := []byte{
+		0xEC, 0x81, 0x83, 0xD9, // $ion_symbol_table::{
+		0x86, 0x71, 0x03, // imports: $ion_symbol_table,
+		0x87, 0xB4, // symbols: [
+		0x83, 'q', 'u', 'x', // "qux" ]}
+		0x71, 0x0C, // baz
+		0x71, 0x0D, // qux
+		0x71, 0x0A, // foo
+	}
+	if val := buf.Bytes()[existing:]; !bytes.Equal(val, eval) {
+		t.Errorf("expected %v, got %v", fmtbytes(eval), fmtbytes(val))
+	}
+
+	r = NewReaderCat(bytes.NewReader(buf.Bytes()), NewCatalog(shared))
+	for _, sym := range []string{"foo", "bar", "baz", "baz", "qux", "foo"} {
+		_symbol(t, r, sym)
+	}
+	_eof(t, r)
+}
+
+func TestWriteBinaryAppendNilLST(t *testing.T) {
+	// An existing document whose values use no symbols, so there's no local
+	// symbol table to append to.
+	buf := bytes.Buffer{}
+	w := NewBinaryWriter(&buf)
+	w.WriteInt(1)
+	if err := w.Finish(); err != nil {
+		t.Fatal(err)
+	}
+	first := buf.Len()
+
+	w = NewBinaryWriterAppend(&buf, nil)
+	w.WriteSymbol("foo")
+	w.WriteSymbol("name")
+	if err := w.Finish(); err != nil {
+		t.Fatal(err)
+	}
+
+	// No version marker, and name is a system symbol.
+	eval := []byte{
+		0xEC, 0x81, 0x83, 0xD9, // $ion_symbol_table::{
+		0x86, 0x71, 0x03, // imports: $ion_symbol_table,
+		0x87, 0xB4, // symbols: [
+		0x83, 'f', 'o', 'o', // "foo" ]}
+		0x71, 0x0A, // foo
+		0x71, 0x04, // name
+	}
+	if val := buf.Bytes()[first:]; !bytes.Equal(val, eval) {
+		t.Errorf("expected %v, got %v", fmtbytes(eval), fmtbytes(val))
+	}
+
+	r := NewReaderBytes(buf.Bytes())
+	_int(t, r, 1)
+	_symbol(t, r, "foo")
+	_symbol(t, r, "name")
+	_eof(t, r)
+}
+
+func TestWriteBinaryAppendNothingNew(t *testing.T) {
+	buf := bytes.Buffer{}
+
+	w := NewBinaryWriter(&buf)
+	w.WriteSymbol("foo")
+	if err := w.Finish(); err != nil {
+		t.Fatal(err)
+	}
+	first := buf.Len()
+
+	w = NewBinaryWriterAppend(&buf, w.SymbolTable())
+	w.WriteSymbol("foo")
+	if err := w.Finish(); err != nil {
+		t.Fatal(err)
+	}
+
+	eval := []byte{0x71, 0x0A} // foo
+	if val := buf.Bytes()[first:]; !bytes.Equal(val, eval) {
+		t.Errorf("expected %v, got %v", fmtbytes(eval), fmtbytes(val))
+	}
+}
+
 func TestWriteBinaryInts(t *testing.T) {
 	eval := []byte{
 		0x20,       // 0
@@ -276,6 +956,30 @@ func TestWriteBinaryBoolAnnotated(t *testing.T) {
 	})
 }
 
+func TestWriteBinaryAllowedAnnotations(t *testing.T) {
+	allowed := map[string]bool{"name": true}
+
+	buf := bytes.Buffer{}
+	w := NewBinaryWriterOpts(&buf, nil, WithAllowedAnnotations(allowed))
+
+	if err := w.Annotation("name"); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.WriteBool(false); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := w.Annotations("name", "version"); err == nil {
+		t.Error("expected an error from disallowed annotation")
+	}
+	if err := w.WriteBool(true); err == nil {
+		t.Error("expected an error writing value")
+	}
+	if err := w.Finish(); err == nil {
+		t.Error("expected an error from Finish")
+	}
+}
+
 func TestWriteBinaryBools(t *testing.T) {
 	eval := []byte{
 		0x10, // false
@@ -323,6 +1027,220 @@ func TestWriteBinaryNulls(t *testing.T) {
 	})
 }
 
+func TestWriteBinaryCanonical(t *testing.T) {
+	write := func(canonical bool, f func(w Writer)) []byte {
+		var opts []WriterOption
+		if canonical {
+			opts = append(opts, WithCanonical())
+		}
+
+		buf := bytes.Buffer{}
+		w := NewBinaryWriterOpts(&buf, nil, opts...)
+		f(w)
+		if err := w.Finish(); err != nil {
+			t.Fatal(err)
+		}
+		return buf.Bytes()
+	}
+
+	one := func(w Writer) {
+		w.BeginStruct()
+		w.FieldName("b")
+		w.WriteInt(1)
+		w.FieldName("a")
+		w.BeginStruct()
+		w.FieldName("d")
+		w.WriteSymbol("x")
+		w.FieldName("c")
+		w.WriteFloatList([]float64{2.5})
+		w.EndStruct()
+		w.FieldName("a")
+		w.Annotation("e")
+		w.WriteString("z")
+		w.EndStruct()
+	}
+	two := func(w Writer) {
+		w.BeginStruct()
+		w.FieldName("a")
+		w.Annotation("e")
+		w.WriteString("z")
+		w.FieldName("a")
+		w.BeginStruct()
+		w.FieldName("c")
+		w.BeginList()
+		w.WriteFloat(2.5)
+		w.EndList()
+		w.FieldName("d")
+		w.WriteSymbol("x")
+		w.EndStruct()
+		w.FieldName("b")
+		w.WriteInt(1)
+		w.EndStruct()
+	}
+
+	if bytes.Equal(write(false, one), write(false, two)) {
+		t.Fatal("expected different bytes without WithCanonical")
+	}
+	bs := write(true, one)
+	if !bytes.Equal(bs, write(true, two)) {
+		t.Fatalf("expected identical bytes, got %v and %v", fmtbytes(bs), fmtbytes(write(true, two)))
+	}
+
+	// The string comes before the struct, since their canonical text e::"z" sorts
+	// before {c:[2.5e+0],d:x}.
+	r := NewReaderBytes(bs)
+	_struct(t, r, func(t *testing.T, r Reader) {
+		_stringAF(t, r, "a", []string{"e"}, "z")
+		_nextAF(t, r, StructType, "a", nil)
+		_intAF(t, r, "b", nil, 1)
+		_eof(t, r)
+	})
+	_eof(t, r)
+
+	// Floats are shortened when that loses nothing.
+	bs = write(true, func(w Writer) {
+		w.WriteFloat(2.5)
+		w.WriteFloat(0.1)
+	})
+	eval := []byte{
+		0xE0, 0x01, 0x00, 0xEA, // $ion_1_0
+		0x44, 0x40, 0x20, 0x00, 0x00, // 2.5e0
+		0x48, 0x3F, 0xB9, 0x99, 0x99, 0x99, 0x99, 0x99, 0x9A, // 0.1e0
+	}
+	if !bytes.Equal(bs, eval) {
+		t.Errorf("expected %v, got %v", fmtbytes(eval), fmtbytes(bs))
+	}
+}
+
+func TestWriteBinaryCanonicalErrors(t *testing.T) {
+	buf := bytes.Buffer{}
+	w := NewBinaryWriterOpts(&buf, nil, WithCanonical(), WithErrorMode(CollectErrors))
+	if err := w.EndList(); err == nil {
+		t.Error("expected an error ending a list at the top level")
+	}
+	if err := w.FieldName("a"); err == nil {
+		t.Error("expected an error setting a field name at the top level")
+	}
+	w.WriteInt(1)
+
+	err := w.Finish()
+	if me, ok := err.(*MultiError); !ok || len(me.Errs) != 2 {
+		t.Fatalf("expected two errors, got %v", err)
+	}
+	r := NewReaderBytes(buf.Bytes())
+	_int(t, r, 1)
+	_eof(t, r)
+}
+
+func TestNullRoundTrip(t *testing.T) {
+	types := []Type{
+		NullType, BoolType, IntType, FloatType, DecimalType, TimestampType, SymbolType,
+		StringType, ClobType, BlobType, ListType, SexpType, StructType,
+	}
+
+	write := func(w Writer) {
+		for _, tpe := range types {
+			w.WriteNullType(tpe)
+		}
+		w.BeginStruct()
+		for _, tpe := range types {
+			w.FieldName("a")
+			w.Annotation("b")
+			w.WriteNullType(tpe)
+		}
+		w.EndStruct()
+		if err := w.Finish(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	check := func(t *testing.T, r Reader) {
+		for _, tpe := range types {
+			_null(t, r, tpe)
+		}
+		_struct(t, r, func(t *testing.T, r Reader) {
+			for _, tpe := range types {
+				_nullAF(t, r, tpe, "a", []string{"b"})
+			}
+			_eof(t, r)
+		})
+		_eof(t, r)
+	}
+
+	t.Run("text", func(t *testing.T) {
+		buf := strings.Builder{}
+		write(NewTextWriter(&buf))
+		check(t, NewReaderStr(buf.String()))
+	})
+
+	t.Run("binary", func(t *testing.T) {
+		buf := bytes.Buffer{}
+		write(NewBinaryWriter(&buf))
+		check(t, NewReaderBytes(buf.Bytes()))
+	})
+}
+
+func TestNullContainers(t *testing.T) {
+	types := []Type{ListType, SexpType, StructType}
+
+	write := func(w Writer) {
+		for _, tpe := range types {
+			w.WriteNullType(tpe)
+			switch tpe {
+			case ListType:
+				w.BeginList()
+				w.EndList()
+			case SexpType:
+				w.BeginSexp()
+				w.EndSexp()
+			case StructType:
+				w.BeginStruct()
+				w.EndStruct()
+			}
+		}
+	}
+
+	check := func(t *testing.T, r Reader) {
+		for _, tpe := range types {
+			_null(t, r, tpe)
+			if err := r.StepIn(); err == nil {
+				t.Errorf("expected an error stepping in to null.%v", tpe)
+			}
+
+			_next(t, r, tpe)
+			if r.IsNull() {
+				t.Errorf("expected an empty %v, got a null one", tpe)
+			}
+			if err := r.StepIn(); err != nil {
+				t.Fatal(err)
+			}
+			_eof(t, r)
+			if err := r.StepOut(); err != nil {
+				t.Fatal(err)
+			}
+		}
+		_eof(t, r)
+	}
+
+	t.Run("text", func(t *testing.T) {
+		buf := strings.Builder{}
+		w := NewTextWriter(&buf)
+		write(w)
+		if err := w.Finish(); err != nil {
+			t.Fatal(err)
+		}
+		if eval := "null.list\n[]\nnull.sexp\n()\nnull.struct\n{}\n"; buf.String() != eval {
+			t.Errorf("expected %q, got %q", eval, buf.String())
+		}
+		check(t, NewReaderStr(buf.String()))
+	})
+
+	t.Run("binary", func(t *testing.T) {
+		testBinaryWriter(t, []byte{0xBF, 0xB0, 0xCF, 0xC0, 0xDF, 0xD0}, write)
+		check(t, NewReaderBytes(writeBinary(t, write)))
+	})
+}
+
 func testBinaryWriter(t *testing.T, eval []byte, f func(w Writer)) {
 	val := writeBinary(t, f)
 
@@ -385,3 +1303,191 @@ func writeBinary(t *testing.T, f func(w Writer)) []byte {
 
 	return buf.Bytes()
 }
+
+func TestWriteBinarySharedLST(t *testing.T) {
+	lstb := NewSymbolTableBuilder()
+	lstb.Add("id")
+	lstb.Add("color")
+	lstb.Add("red")
+	lst := lstb.Build()
+
+	write := func(id int64, opts ...WriterOption) []byte {
+		buf := bytes.Buffer{}
+		w := NewBinaryWriterLST(&buf, lst, opts...)
+		w.BeginStruct()
+		w.FieldName("id")
+		w.WriteInt(id)
+		w.FieldName("color")
+		w.WriteSymbol("red")
+		w.EndStruct()
+		if err := w.Finish(); err != nil {
+			t.Fatal(err)
+		}
+		return buf.Bytes()
+	}
+
+	// The first document carries the table, and the rest assume it.
+	docs := [][]byte{write(1), write(2, WithoutLST()), write(3, WithoutLST())}
+
+	eval := []byte{
+		0xE0, 0x01, 0x00, 0xEA, // $ion_1_0
+		0xD6,             // {
+		0x8A, 0x21, 0x02, //   id:2
+		0x8B, 0x71, 0x0C, //   color:red }
+	}
+	if !bytes.Equal(docs[1], eval) {
+		t.Errorf("expected %v, got %v", fmtbytes(eval), fmtbytes(docs[1]))
+	}
+	if len(docs[0]) <= len(docs[1]) {
+		t.Errorf("expected the first document to carry the table, got %v", fmtbytes(docs[0]))
+	}
+
+	for i, doc := range docs {
+		r := NewReaderBytes(doc, WithSymbolTable(lst))
+		_struct(t, r, func(t *testing.T, r Reader) {
+			_intAF(t, r, "id", nil, i+1)
+			_symbolAF(t, r, "color", nil, "red")
+			_eof(t, r)
+		})
+		_eof(t, r)
+	}
+
+	// Without the table, there's no telling what the symbols were.
+	r := NewReaderBytes(docs[1])
+	_struct(t, r, func(t *testing.T, r Reader) {
+		_intAF(t, r, "$10", nil, 2)
+		_symbolAF(t, r, "$11", nil, "$12")
+	})
+}
+
+func TestWriteBinaryVersion(t *testing.T) {
+	eval := []byte{0xE0, 0x01, 0x00, 0xEA, 0x21, 0x01}
+
+	buf := bytes.Buffer{}
+	w := NewBinaryWriterOpts(&buf, nil, WithVersion(1, 0))
+	w.WriteInt(1)
+	if err := w.Finish(); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buf.Bytes(), eval) {
+		t.Errorf("expected %v, got %v", fmtbytes(eval), fmtbytes(buf.Bytes()))
+	}
+
+	for _, v := range [][2]int{{1, 1}, {2, 0}, {0, 0}} {
+		buf.Reset()
+		w := NewBinaryWriterOpts(&buf, nil, WithVersion(v[0], v[1]))
+		err := w.WriteInt(1)
+		if ue, ok := err.(*UsageError); !ok || ue.Msg != fmt.Sprintf("unsupported version %v.%v", v[0], v[1]) {
+			t.Errorf("expected a UsageError for version %v.%v, got %v", v[0], v[1], err)
+		}
+		if err := w.Finish(); err == nil {
+			t.Errorf("expected the error for version %v.%v to stick", v[0], v[1])
+		}
+		if buf.Len() != 0 {
+			t.Errorf("expected no output for version %v.%v, got %v", v[0], v[1], fmtbytes(buf.Bytes()))
+		}
+	}
+}
+
+func TestWriteBinaryPadding(t *testing.T) {
+	buf := bytes.Buffer{}
+	w := NewBinaryWriter(&buf)
+	w.WriteInt(1)
+	w.WritePadding(16 - 6) // Pad the version marker and 1 out to 16 bytes.
+	w.WriteInt(2)
+	if err := w.Finish(); err != nil {
+		t.Fatal(err)
+	}
+
+	bs := buf.Bytes()
+	if val := bs[16:]; !bytes.Equal(val, []byte{0x21, 0x02}) {
+		t.Errorf("expected 2 at offset 16, got %v", fmtbytes(bs))
+	}
+
+	r := NewReaderBytes(bs)
+	_int(t, r, 1)
+	_int(t, r, 2)
+	_eof(t, r)
+
+	// In a struct, padding leaves the field name and annotations for the next value.
+	buf.Reset()
+	w = NewBinaryWriter(&buf)
+	w.BeginStruct()
+	w.FieldName("a")
+	w.Annotation("x")
+	w.WritePadding(3)
+	w.WriteInt(3)
+	w.WritePadding(2)
+	w.EndStruct()
+	if err := w.Finish(); err != nil {
+		t.Fatal(err)
+	}
+
+	r = NewReaderBytes(buf.Bytes())
+	_struct(t, r, func(t *testing.T, r Reader) {
+		_intAF(t, r, "a", []string{"x"}, 3)
+		_eof(t, r)
+	})
+	_eof(t, r)
+
+	// Padding of any length comes out exactly that long.
+	for n := 1; n < 300; n++ {
+		buf := bytes.Buffer{}
+		w := NewBinaryWriter(&buf)
+		w.WritePadding(n)
+		w.WriteInt(1)
+		if err := w.Finish(); err != nil {
+			t.Fatal(err)
+		}
+		if buf.Len() != 4+n+2 {
+			t.Fatalf("expected %v bytes for %v bytes of padding, got %v", 4+n+2, n, fmtbytes(buf.Bytes()))
+		}
+		r := NewReaderBytes(buf.Bytes())
+		_int(t, r, 1)
+		_eof(t, r)
+	}
+}
+
+func TestWriteBinaryPaddingFixedLST(t *testing.T) {
+	lst := NewLocalSymbolTable(nil, []string{"x"})
+
+	buf := bytes.Buffer{}
+	w := NewBinaryWriterLST(&buf, lst)
+	w.Annotation("x")
+	w.WritePadding(2)
+	w.WriteInt(1)
+	if err := w.Finish(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The padding comes after the symbol table, and the annotation is the value's.
+	eval := []byte{0x01, 0x00, 0xE4, 0x81, 0x8A, 0x21, 0x01}
+	if val := buf.Bytes()[buf.Len()-len(eval):]; !bytes.Equal(val, eval) {
+		t.Errorf("expected %v, got %v", fmtbytes(eval), fmtbytes(buf.Bytes()))
+	}
+}
+
+func TestWritePaddingErrors(t *testing.T) {
+	test := func(name string, w Writer, f func(w Writer) error) {
+		t.Run(name, func(t *testing.T) {
+			if _, ok := f(w).(*UsageError); !ok {
+				t.Error("expected a UsageError")
+			}
+			if err := w.WriteNull(); err == nil {
+				t.Error("expected the error to stick")
+			}
+		})
+	}
+
+	buf := bytes.Buffer{}
+	test("negative", NewBinaryWriter(&buf), func(w Writer) error {
+		return w.WritePadding(-1)
+	})
+	test("struct", NewBinaryWriter(&buf), func(w Writer) error {
+		w.BeginStruct()
+		return w.WritePadding(1)
+	})
+	test("text", NewTextWriter(&buf), func(w Writer) error {
+		return w.WritePadding(4)
+	})
+}
@@ -244,14 +244,50 @@ func appendTag(b []byte, code byte, len uint64) []byte {
 	return appendVarUint(b, len)
 }
 
-// timeLen pre-calculates the length, in bytes, of the given time value.
-func timeLen(offset int, utc time.Time) uint64 {
+// appendPadding appends n bytes of NOP padding, using as few pads as possible.
+func appendPadding(b []byte, n uint64) []byte {
+	for n > 0 {
+		// Find the longest pad that fits, counting its tag. That may leave a byte
+		// over, since a pad's tag grows with its length: a pad with a one-byte tag
+		// is at most 14 bytes long, for example, and one with a two-byte tag at
+		// least 16, so 15 bytes take two pads.
+		len := n - 1
+		for tagLen(len)+len > n {
+			len--
+		}
+
+		b = appendTag(b, 0x00, len)
+		b = append(b, make([]byte, len)...)
+		n -= tagLen(len) + len
+	}
+	return b
+}
+
+// timeLen pre-calculates the length, in bytes, of the given time value
+// at the given precision.
+func timeLen(offset int, utc time.Time, precision TimestampPrecision) uint64 {
+	if precision <= TimestampPrecisionDay {
+		// Unknown offset, which is always one byte.
+		offset = 0
+	}
 	ret := varIntLen(int64(offset))
 
 	// Almost certainly two but let's be safe.
 	ret += varUintLen(uint64(utc.Year()))
 
 	// Month, day, hour, minute, and second are all guaranteed to be one byte.
+	switch precision {
+	case TimestampPrecisionYear:
+		return ret
+	case TimestampPrecisionMonth:
+		return ret + 1
+	case TimestampPrecisionDay:
+		return ret + 2
+	case TimestampPrecisionMinute:
+		return ret + 4
+	case TimestampPrecisionSecond:
+		return ret + 5
+	}
 	ret += 5
 
 	ns := utc.Nanosecond()
@@ -264,16 +300,36 @@ func timeLen(offset int, utc time.Time) uint64 {
 }
 
 // appendTime appends a timestamp value
-func appendTime(b []byte, offset int, utc time.Time) []byte {
-	b = appendVarInt(b, int64(offset))
+func appendTime(b []byte, offset int, utc time.Time, precision TimestampPrecision) []byte {
+	if precision <= TimestampPrecisionDay {
+		// Dates have an unknown offset, encoded as negative zero.
+		b = append(b, 0xC0)
+	} else {
+		b = appendVarInt(b, int64(offset))
+	}
 
 	b = appendVarUint(b, uint64(utc.Year()))
+	if precision == TimestampPrecisionYear {
+		return b
+	}
 	b = appendVarUint(b, uint64(utc.Month()))
+	if precision == TimestampPrecisionMonth {
+		return b
+	}
 	b = appendVarUint(b, uint64(utc.Day()))
+	if precision == TimestampPrecisionDay {
+		return b
+	}
 
 	b = appendVarUint(b, uint64(utc.Hour()))
 	b = appendVarUint(b, uint64(utc.Minute()))
+	if precision == TimestampPrecisionMinute {
+		return b
+	}
 	b = appendVarUint(b, uint64(utc.Second()))
+	if precision == TimestampPrecisionSecond {
+		return b
+	}
 
 	ns := utc.Nanosecond()
 	if ns > 0 {
@@ -170,18 +170,18 @@ func TestAppendTag(t *testing.T) {
 }
 
 func TestAppendTime(t *testing.T) {
-	test := func(val time.Time, elen uint64, ebits []byte) {
-		t.Run(fmt.Sprintf("%x", val), func(t *testing.T) {
+	test := func(val time.Time, precision TimestampPrecision, elen uint64, ebits []byte) {
+		t.Run(fmt.Sprintf("%x/%v", val, precision), func(t *testing.T) {
 			_, offset := val.Zone()
 			offset /= 60
 			utc := val.In(time.UTC)
 
-			len := timeLen(offset, utc)
+			len := timeLen(offset, utc, precision)
 			if len != elen {
 				t.Errorf("expected len=%v, got len=%v", elen, len)
 			}
 
-			bits := appendTime(nil, offset, utc)
+			bits := appendTime(nil, offset, utc, precision)
 			if !bytes.Equal(bits, ebits) {
 				t.Errorf("expected %v, got %v", fmtbytes(ebits), fmtbytes(bits))
 			}
@@ -190,8 +190,8 @@ func TestAppendTime(t *testing.T) {
 
 	nowish, _ := time.Parse(time.RFC3339Nano, "2019-08-04T18:15:43.863494+10:00")
 
-	test(time.Time{}, 7, []byte{0x80, 0x81, 0x81, 0x81, 0x80, 0x80, 0x80})
-	test(nowish, 14, []byte{
+	test(time.Time{}, TimestampPrecisionNanosecond, 7, []byte{0x80, 0x81, 0x81, 0x81, 0x80, 0x80, 0x80})
+	test(nowish, TimestampPrecisionNanosecond, 14, []byte{
 		0x04, 0xD8, // offset: +600 minutes (+10:00)
 		0x0F, 0xE3, // year:   2019
 		0x88,                   // month:  8
@@ -202,4 +202,27 @@ func TestAppendTime(t *testing.T) {
 		0xC9,                   // exp:    -9
 		0x33, 0x77, 0xDF, 0x70, // nsec:   863494000
 	})
+
+	test(nowish, TimestampPrecisionSecond, 9, []byte{
+		0x04, 0xD8, // offset: +600 minutes (+10:00)
+		0x0F, 0xE3, // year:   2019
+		0x88, // month:  8
+		0x84, // day:    4
+		0x88, // hour:   8 utc (18 local)
+		0x8F, // minute: 15
+		0xAB, // second: 43
+	})
+	test(nowish, TimestampPrecisionMinute, 8, []byte{
+		0x04, 0xD8, // offset: +600 minutes (+10:00)
+		0x0F, 0xE3, // year:   2019
+		0x88, // month:  8
+		0x84, // day:    4
+		0x88, // hour:   8 utc (18 local)
+		0x8F, // minute: 15
+	})
+
+	date := time.Date(2020, 6, 15, 0, 0, 0, 0, time.UTC)
+	test(date, TimestampPrecisionDay, 5, []byte{0xC0, 0x0F, 0xE4, 0x86, 0x8F})
+	test(date, TimestampPrecisionMonth, 4, []byte{0xC0, 0x0F, 0xE4, 0x86})
+	test(date, TimestampPrecisionYear, 3, []byte{0xC0, 0x0F, 0xE4})
 }
@@ -98,8 +98,48 @@ type bitstream struct {
 	stack bitstack
 
 	code bitcode
+	tag  byte
 	null bool
 	len  uint64
+
+	recording bool
+	rec       []byte
+
+	strict bool
+	maxLen uint64
+}
+
+// ReadChunkSize is the most readChunked allocates at a time.
+const readChunkSize = 64 * 1024
+
+// ReadChunked reads exactly n bytes from in, returning them along with the number
+// of bytes actually read and any error, as io.ReadFull does. It doesn't trust n
+// enough to allocate all of it up front if it's large: a value claiming to be
+// gigabytes long on input that ends long before that should fail for want of input,
+// not memory. So it reads a chunk at a time, growing the buffer as the bytes
+// actually turn up.
+func readChunked(in io.Reader, n uint64) ([]byte, uint64, error) {
+	size := n
+	if size > readChunkSize {
+		size = readChunkSize
+	}
+	bs := make([]byte, 0, size)
+
+	for uint64(len(bs)) < n {
+		m := n - uint64(len(bs))
+		if m > readChunkSize {
+			m = readChunkSize
+		}
+
+		start := len(bs)
+		bs = append(bs, make([]byte, m)...)
+		actual, err := io.ReadFull(in, bs[start:])
+		if err != nil {
+			return nil, uint64(start + actual), err
+		}
+	}
+
+	return bs, n, nil
 }
 
 // Init initializes this stream with the given bufio.Reader.
@@ -112,11 +152,29 @@ func (b *bitstream) InitBytes(in []byte) {
 	b.in = bufio.NewReader(bytes.NewReader(in))
 }
 
+// Record starts recording the bytes read from the underlying stream,
+// discarding anything previously recorded.
+func (b *bitstream) Record() {
+	b.recording = true
+	b.rec = nil
+}
+
+// Recorded stops recording and returns the bytes read since Record was called.
+func (b *bitstream) Recorded() []byte {
+	b.recording = false
+	return b.rec
+}
+
 // Code returns the typecode of the current value.
 func (b *bitstream) Code() bitcode {
 	return b.code
 }
 
+// Tag returns the type descriptor byte of the current value.
+func (b *bitstream) Tag() byte {
+	return b.tag
+}
+
 // IsNull returns true if the current value is null.
 func (b *bitstream) IsNull() bool {
 	return b.null
@@ -171,6 +229,7 @@ func (b *bitstream) Next() error {
 	}
 
 	// Parse the tag.
+	b.tag = byte(c)
 	code, len := parseTag(c)
 	if code == bitcodeNone {
 		return &InvalidTagByteError{byte(c), b.pos - 1}
@@ -219,6 +278,12 @@ func (b *bitstream) Next() error {
 	pos := b.pos
 	rem := b.remaining()
 
+	// A struct with a length of 1 is one whose fields are sorted by symbol ID. Its
+	// actual len follows, the same as for any other value with a length of 0x0E.
+	if code == bitcodeStruct && len == 1 {
+		len = 0x0E
+	}
+
 	// This value's actual len is encoded as a separate varUint.
 	if len == 0x0E {
 		var lenlen uint64
@@ -233,6 +298,9 @@ func (b *bitstream) Next() error {
 		msg := fmt.Sprintf("value overruns its container: %v vs %v", len, rem)
 		return &SyntaxError{msg, pos - 1}
 	}
+	if b.maxLen > 0 && len > b.maxLen {
+		return &SizeLimitError{b.maxLen, len, pos - 1}
+	}
 
 	b.code = code
 	b.len = len
@@ -406,13 +474,24 @@ func (b *bitstream) ReadInt() (interface{}, error) {
 		return "", err
 	}
 
+	// Some encoders pad the magnitude with leading zero bytes, which don't
+	// change its value but aren't canonical.
+	if len(bs) > 0 && bs[0] == 0 {
+		if b.strict {
+			return nil, &SyntaxError{"int magnitude has leading zero bytes", b.pos - b.len}
+		}
+		for len(bs) > 0 && bs[0] == 0 {
+			bs = bs[1:]
+		}
+	}
+
 	var ret interface{}
 	switch {
-	case b.len == 0:
+	case len(bs) == 0:
 		// Special case for zero.
 		ret = int64(0)
 
-	case b.len < 8, (b.len == 8 && bs[0]&0x80 == 0):
+	case len(bs) < 8, (len(bs) == 8 && bs[0]&0x80 == 0):
 		// It'll fit in an int64.
 		i := int64(0)
 		for _, b := range bs {
@@ -490,8 +569,20 @@ func (b *bitstream) ReadDecimal() (*Decimal, error) {
 	return d, nil
 }
 
-// ReadTimestamp reads a timestamp value.
-func (b *bitstream) ReadTimestamp() (time.Time, error) {
+// timestampPrecisions maps the number of components in a binary timestamp to
+// its precision. Hours can't appear without minutes.
+var timestampPrecisions = []TimestampPrecision{
+	TimestampNoPrecision,
+	TimestampPrecisionYear,
+	TimestampPrecisionMonth,
+	TimestampPrecisionDay,
+	TimestampNoPrecision,
+	TimestampPrecisionMinute,
+	TimestampPrecisionSecond,
+}
+
+// ReadTimestamp reads a timestamp value, returning it and its precision.
+func (b *bitstream) ReadTimestamp() (time.Time, TimestampPrecision, error) {
 	if b.code != bitcodeTimestamp {
 		panic("not a timestamp")
 	}
@@ -500,30 +591,40 @@ func (b *bitstream) ReadTimestamp() (time.Time, error) {
 
 	offset, olen, err := b.readVarIntLen(len)
 	if err != nil {
-		return time.Time{}, err
+		return time.Time{}, TimestampNoPrecision, err
 	}
 	len -= olen
 
 	ts := []int{1, 1, 1, 0, 0, 0}
-	for i := 0; len > 0 && i < 6; i++ {
+	n := 0
+	for ; len > 0 && n < 6; n++ {
 		val, vlen, err := b.readVarUintLen(len)
 		if err != nil {
-			return time.Time{}, err
+			return time.Time{}, TimestampNoPrecision, err
 		}
 		len -= vlen
-		ts[i] = int(val)
+		ts[n] = int(val)
+	}
+
+	precision := timestampPrecisions[n]
+	if precision == TimestampNoPrecision {
+		msg := fmt.Sprintf("invalid timestamp with %v components", n)
+		return time.Time{}, TimestampNoPrecision, &SyntaxError{msg, b.pos}
+	}
+	if len > 0 {
+		precision = TimestampPrecisionNanosecond
 	}
 
 	nsecs, err := b.readNsecs(len)
 	if err != nil {
-		return time.Time{}, err
+		return time.Time{}, TimestampNoPrecision, err
 	}
 
 	b.state = b.stateAfterValue()
 	b.clear()
 
 	utc := time.Date(ts[0], time.Month(ts[1]), ts[2], ts[3], ts[4], ts[5], int(nsecs), time.UTC)
-	return utc.In(time.FixedZone("fixed", int(offset)*60)), nil
+	return utc.In(time.FixedZone("fixed", int(offset)*60)), precision, nil
 }
 
 // ReadNsecs reads the fraction part of a timestamp and truncates it to nanoseconds.
@@ -547,6 +648,7 @@ func (b *bitstream) readNsecs(len uint64) (int, error) {
 func (b *bitstream) readDecimal(len uint64) (*Decimal, error) {
 	exp := int64(0)
 	coef := new(big.Int)
+	neg := false
 
 	if len > 0 {
 		val, vlen, err := b.readVarIntLen(len)
@@ -564,12 +666,15 @@ func (b *bitstream) readDecimal(len uint64) (*Decimal, error) {
 	}
 
 	if len > 0 {
-		if err := b.readBigInt(len, coef); err != nil {
+		var err error
+		if neg, err = b.readBigInt(len, coef); err != nil {
 			return nil, err
 		}
 	}
 
-	return NewDecimal(coef, int32(exp)), nil
+	d := NewDecimal(coef, int32(exp))
+	d.negZero = neg && coef.Sign() == 0
+	return d, nil
 }
 
 // ReadSymbolID reads a symbol value.
@@ -578,15 +683,24 @@ func (b *bitstream) ReadSymbolID() (uint64, error) {
 		panic("not a symbol")
 	}
 
-	if b.len > 8 {
-		return 0, &SyntaxError{"symbol id too large", b.pos}
-	}
-
 	bs, err := b.readN(b.len)
 	if err != nil {
 		return 0, err
 	}
 
+	// As with ints, leading zero bytes don't count against the size of the id.
+	if len(bs) > 0 && bs[0] == 0 {
+		if b.strict {
+			return 0, &SyntaxError{"symbol id has leading zero bytes", b.pos - b.len}
+		}
+		for len(bs) > 0 && bs[0] == 0 {
+			bs = bs[1:]
+		}
+	}
+	if len(bs) > 8 {
+		return 0, &SyntaxError{"symbol id too large", b.pos - b.len}
+	}
+
 	b.state = b.stateAfterValue()
 	b.clear()
 
@@ -640,11 +754,12 @@ func (b *bitstream) clear() {
 }
 
 // ReadBigInt reads a fixed-length integer of the given length and stores
-// the value in the given big.Int.
-func (b *bitstream) readBigInt(len uint64, ret *big.Int) error {
+// the value in the given big.Int. It returns whether the sign bit was set,
+// which distinguishes negative zero.
+func (b *bitstream) readBigInt(len uint64, ret *big.Int) (bool, error) {
 	bs, err := b.readN(len)
 	if err != nil {
-		return err
+		return false, err
 	}
 
 	neg := (bs[0]&0x80 != 0)
@@ -658,7 +773,7 @@ func (b *bitstream) readBigInt(len uint64, ret *big.Int) error {
 		ret.Neg(ret)
 	}
 
-	return nil
+	return neg, nil
 }
 
 // ReadVarUint reads a variable-length-encoded uint.
@@ -668,18 +783,16 @@ func (b *bitstream) readVarUint() (uint64, error) {
 }
 
 // ReadVarUintLen reads a variable-length-encoded uint of at most max bytes,
-// returning the value and its actual length in bytes.
+// returning the value and its actual length in bytes. Like ints, a varuint may
+// be padded with leading zero bytes, which are accepted unless the stream is
+// strict, but not to a value that won't fit in a uint64.
 func (b *bitstream) readVarUintLen(max uint64) (uint64, uint64, error) {
-	if max > 10 {
-		max = 10
-	}
-
 	val := uint64(0)
 	len := uint64(0)
 
 	for {
 		if len >= max {
-			return 0, 0, &SyntaxError{"varuint too large", b.pos}
+			return 0, 0, &SyntaxError{"varuint runs past the end of its container", b.pos}
 		}
 
 		c, err := b.read1()
@@ -687,6 +800,13 @@ func (b *bitstream) readVarUintLen(max uint64) (uint64, uint64, error) {
 			return 0, 0, err
 		}
 
+		if len == 0 && c == 0 && b.strict {
+			return 0, 0, &SyntaxError{"varuint has leading zero bytes", b.pos - 1}
+		}
+		if val > math.MaxUint64>>7 {
+			return 0, 0, &SyntaxError{"varuint too large", b.pos - len - 1}
+		}
+
 		val <<= 7
 		val ^= uint64(c & 0x7F)
 		len++
@@ -705,14 +825,10 @@ func (b *bitstream) skipVarUint() error {
 
 // SkipVarUintLen skips over a variable-length-encoded uint of at most max bytes.
 func (b *bitstream) skipVarUintLen(max uint64) (uint64, error) {
-	if max > 10 {
-		max = 10
-	}
-
 	len := uint64(0)
 	for {
 		if len >= max {
-			return 0, &SyntaxError{"varuint too large", b.pos - len}
+			return 0, &SyntaxError{"varuint runs past the end of its container", b.pos - len}
 		}
 
 		c, err := b.read1()
@@ -743,13 +859,12 @@ func (b *bitstream) remaining() uint64 {
 }
 
 // ReadVarIntLen reads a variable-length-encoded int of at most max bytes,
-// returning the value and its actual length in bytes
+// returning the value and its actual length in bytes. As with varuints, leading
+// zero bytes are accepted unless the stream is strict, but the magnitude must fit
+// in an int64.
 func (b *bitstream) readVarIntLen(max uint64) (int64, uint64, error) {
 	if max == 0 {
-		return 0, 0, &SyntaxError{"varint too large", b.pos}
-	}
-	if max > 10 {
-		max = 10
+		return 0, 0, &SyntaxError{"varint runs past the end of its container", b.pos}
 	}
 
 	// Read the first byte, which contains the sign bit.
@@ -757,6 +872,9 @@ func (b *bitstream) readVarIntLen(max uint64) (int64, uint64, error) {
 	if err != nil {
 		return 0, 0, err
 	}
+	if c&0xBF == 0 && b.strict {
+		return 0, 0, &SyntaxError{"varint has leading zero bytes", b.pos - 1}
+	}
 
 	sign := int64(1)
 	if c&0x40 != 0 {
@@ -773,7 +891,7 @@ func (b *bitstream) readVarIntLen(max uint64) (int64, uint64, error) {
 
 	for {
 		if len >= max {
-			return 0, 0, &SyntaxError{"varint too large", b.pos - len}
+			return 0, 0, &SyntaxError{"varint runs past the end of its container", b.pos - len}
 		}
 
 		c, err := b.read1()
@@ -781,6 +899,10 @@ func (b *bitstream) readVarIntLen(max uint64) (int64, uint64, error) {
 			return 0, 0, err
 		}
 
+		if val > math.MaxInt64>>7 {
+			return 0, 0, &SyntaxError{"varint too large", b.pos - len - 1}
+		}
+
 		val <<= 7
 		val ^= int64(c & 0x7F)
 		len++
@@ -836,17 +958,24 @@ func (b *bitstream) readN(n uint64) ([]byte, error) {
 		return nil, nil
 	}
 
-	bs := make([]byte, n)
-	actual, err := b.in.Read(bs)
-	b.pos += uint64(actual)
+	bs, actual, err := readChunked(b.in, n)
+	b.pos += actual
 
-	if err == io.EOF {
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
 		return nil, &UnexpectedEOFError{b.pos}
 	}
 	if err != nil {
 		return nil, &IOError{err}
 	}
 
+	if b.recording {
+		if b.rec == nil {
+			b.rec = bs
+		} else {
+			b.rec = append(b.rec, bs...)
+		}
+	}
+
 	return bs, nil
 }
 
@@ -877,6 +1006,10 @@ func (b *bitstream) read() (int, error) {
 		return 0, &IOError{err}
 	}
 
+	if b.recording {
+		b.rec = append(b.rec, c)
+	}
+
 	return int(c), nil
 }
 
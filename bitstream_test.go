@@ -109,3 +109,125 @@ func TestBitcodeString(t *testing.T) {
 		}
 	}
 }
+
+func TestReadVarUint(t *testing.T) {
+	test := func(bs []byte, eval uint64) {
+		t.Run(fmtbytes(bs), func(t *testing.T) {
+			b := bitstream{}
+			b.InitBytes(bs)
+			val, len, err := b.readVarUintLen(uint64(cap(bs)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if val != eval {
+				t.Errorf("expected %v, got %v", eval, val)
+			}
+			if len != uint64(cap(bs)) {
+				t.Errorf("expected len=%v, got %v", cap(bs), len)
+			}
+		})
+	}
+
+	test([]byte{0x80}, 0)
+	test([]byte{0xFF}, 0x7F)
+	test([]byte{0x7F, 0xFF}, 1<<14-1)
+	test([]byte{0x01, 0x00, 0x80}, 1<<14)
+	test([]byte{0x01, 0x7F, 0xFF}, 1<<15-1)
+	test([]byte{0x02, 0x00, 0x80}, 1<<15)
+	test([]byte{0x03, 0x7F, 0xFF}, 1<<16-1)
+	test([]byte{0x04, 0x00, 0x80}, 1<<16)
+	test([]byte{0x0F, 0x7F, 0x7F, 0x7F, 0xFF}, 1<<32-1)
+	test([]byte{0x10, 0x00, 0x00, 0x00, 0x80}, 1<<32)
+	test([]byte{0x01, 0x7F, 0x7F, 0x7F, 0x7F, 0x7F, 0x7F, 0x7F, 0x7F, 0xFF}, 1<<64-1)
+
+	// Padded with leading zeros, even past ten bytes.
+	test([]byte{0x00, 0x00, 0x81}, 1)
+	test([]byte{0x00, 0x00, 0x01, 0x7F, 0x7F, 0x7F, 0x7F, 0x7F, 0x7F, 0x7F, 0x7F, 0xFF}, 1<<64-1)
+}
+
+func TestReadVarUintErrors(t *testing.T) {
+	test := func(name string, bs []byte, max uint64, strict bool) {
+		t.Run(name, func(t *testing.T) {
+			b := bitstream{strict: strict}
+			b.InitBytes(bs)
+			_, _, err := b.readVarUintLen(max)
+			if _, ok := err.(*SyntaxError); !ok {
+				t.Errorf("expected a SyntaxError, got %v", err)
+			}
+		})
+	}
+
+	test("overflow", []byte{0x02, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x80}, 10, false)
+	test("past max", []byte{0x01, 0x80}, 1, false)
+	test("strict padding", []byte{0x00, 0x81}, 2, true)
+}
+
+func TestReadVarInt(t *testing.T) {
+	test := func(bs []byte, eval int64, strict bool) {
+		t.Run(fmtbytes(bs), func(t *testing.T) {
+			b := bitstream{strict: strict}
+			b.InitBytes(bs)
+			val, _, err := b.readVarIntLen(uint64(len(bs)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if val != eval {
+				t.Errorf("expected %v, got %v", eval, val)
+			}
+		})
+	}
+
+	test([]byte{0x80}, 0, true)
+	test([]byte{0xC1}, -1, true)
+	test([]byte{0x3F, 0xFF}, 1<<13-1, true)
+	test([]byte{0x7F, 0xFF}, -(1<<13 - 1), true)
+	test([]byte{0x01, 0x7F, 0xFF}, 1<<15-1, true)
+	test([]byte{0x01, 0x7F, 0x7F, 0xFF}, 1<<22-1, true)
+	test([]byte{0x3F, 0x7F, 0x7F, 0x7F, 0x7F, 0x7F, 0x7F, 0x7F, 0xFF}, 1<<62-1, true)
+	test([]byte{0x00, 0x7F, 0x7F, 0x7F, 0x7F, 0x7F, 0x7F, 0x7F, 0x7F, 0xFF}, 1<<63-1, false)
+	test([]byte{0x40, 0x01, 0x80}, -128, false)
+
+	for _, bs := range [][]byte{
+		{0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x80}, // 2^63
+		{0x40, 0x81}, // -1, padded
+	} {
+		b := bitstream{strict: true}
+		b.InitBytes(bs)
+		if _, _, err := b.readVarIntLen(uint64(len(bs))); err == nil {
+			t.Errorf("expected an error reading %v", fmtbytes(bs))
+		}
+	}
+}
+
+func TestReadSymbolIDPadded(t *testing.T) {
+	// A symbol value whose nine-byte id is padded with a leading zero.
+	bs := []byte{0xE0, 0x01, 0x00, 0xEA, 0x79, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x0A}
+
+	b := bitstream{}
+	b.InitBytes(bs)
+	if err := b.Next(); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := b.ReadBVM(); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Next(); err != nil {
+		t.Fatal(err)
+	}
+	id, err := b.ReadSymbolID()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != 10 {
+		t.Errorf("expected 10, got %v", id)
+	}
+
+	b = bitstream{strict: true}
+	b.InitBytes(bs)
+	b.Next()
+	b.ReadBVM()
+	b.Next()
+	if _, err := b.ReadSymbolID(); err == nil {
+		t.Error("expected an error reading a padded symbol id strictly")
+	}
+}
@@ -53,24 +53,60 @@ func (c *basicCatalog) FindLatest(name string) SharedSymbolTable {
 	return c.latest[name]
 }
 
+// A compositeCatalog searches a list of catalogs in order.
+type compositeCatalog struct {
+	cats []Catalog
+}
+
+// NewCompositeCatalog creates a catalog that searches each of the given catalogs in
+// turn, returning the first match, for example to layer an in-memory catalog over a
+// file-backed one. Earlier catalogs take precedence over later ones, so FindLatest
+// returns the latest version of a table from the first catalog that has any version
+// of it, even if a later catalog has a more recent one.
+func NewCompositeCatalog(cats ...Catalog) Catalog {
+	return &compositeCatalog{cats}
+}
+
+// FindExact finds the shared symbol table with the given name and version in the
+// first catalog that has it.
+func (c *compositeCatalog) FindExact(name string, version int) SharedSymbolTable {
+	for _, cat := range c.cats {
+		if sst := cat.FindExact(name, version); sst != nil {
+			return sst
+		}
+	}
+	return nil
+}
+
+// FindLatest finds the shared symbol table with the given name and largest version
+// in the first catalog that has one with that name.
+func (c *compositeCatalog) FindLatest(name string) SharedSymbolTable {
+	for _, cat := range c.cats {
+		if sst := cat.FindLatest(name); sst != nil {
+			return sst
+		}
+	}
+	return nil
+}
+
 // A System is a reader factory wrapping a catalog.
 type System struct {
 	Catalog Catalog
 }
 
 // NewReader creates a new reader using this system's catalog.
-func (s System) NewReader(in io.Reader) Reader {
-	return NewReaderCat(in, s.Catalog)
+func (s System) NewReader(in io.Reader, opts ...ReaderOption) Reader {
+	return NewReaderCat(in, s.Catalog, opts...)
 }
 
 // NewReaderStr creates a new reader using this system's catalog.
-func (s System) NewReaderStr(in string) Reader {
-	return NewReaderCat(strings.NewReader(in), s.Catalog)
+func (s System) NewReaderStr(in string, opts ...ReaderOption) Reader {
+	return NewReaderCat(strings.NewReader(in), s.Catalog, opts...)
 }
 
 // NewReaderBytes creates a new reader using this system's catalog.
-func (s System) NewReaderBytes(in []byte) Reader {
-	return NewReaderCat(bytes.NewReader(in), s.Catalog)
+func (s System) NewReaderBytes(in []byte, opts ...ReaderOption) Reader {
+	return NewReaderCat(bytes.NewReader(in), s.Catalog, opts...)
 }
 
 // Unmarshal unmarshals Ion data using this system's catalog.
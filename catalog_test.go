@@ -60,3 +60,43 @@ func TestCatalog(t *testing.T) {
 		t.Errorf("expected i=10, got %v", i)
 	}
 }
+
+func TestCompositeCatalog(t *testing.T) {
+	item1 := NewSharedSymbolTable("item", 1, []string{"id"})
+	item2 := NewSharedSymbolTable("item", 2, []string{"id", "name"})
+	item3 := NewSharedSymbolTable("item", 3, []string{"id", "name", "description"})
+	order := NewSharedSymbolTable("order", 1, []string{"total"})
+
+	cat := NewCompositeCatalog(NewCatalog(item2), NewCatalog(item1, item3, order))
+
+	test := func(name string, sst, esst SharedSymbolTable) {
+		t.Run(name, func(t *testing.T) {
+			if sst != esst {
+				t.Errorf("expected %v, got %v", esst, sst)
+			}
+		})
+	}
+
+	// The first catalog wins where both have a table, but tables only in the
+	// second are still found.
+	test("exact item 2", cat.FindExact("item", 2), item2)
+	test("exact item 1", cat.FindExact("item", 1), item1)
+	test("exact item 3", cat.FindExact("item", 3), item3)
+	test("exact order 1", cat.FindExact("order", 1), order)
+	test("exact order 2", cat.FindExact("order", 2), nil)
+	test("latest item", cat.FindLatest("item"), item2)
+	test("latest order", cat.FindLatest("order"), order)
+	test("latest missing", cat.FindLatest("missing"), nil)
+
+	// Imports are resolved through it.
+	buf := bytes.Buffer{}
+	w := NewBinaryWriter(&buf, order)
+	w.WriteSymbol("total")
+	if err := w.Finish(); err != nil {
+		t.Fatal(err)
+	}
+
+	r := System{Catalog: cat}.NewReaderBytes(buf.Bytes())
+	_symbol(t, r, "total")
+	_eof(t, r)
+}
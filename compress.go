@@ -0,0 +1,151 @@
+package ion
+
+import (
+	"compress/gzip"
+	"io"
+)
+
+// A Codec compresses and decompresses streams of bytes, for reading and writing
+// compressed Ion with NewReaderCompressed and NewWriterCompressed. Gzip is built
+// in; other formats, such as zstd or lz4, can be plugged in by implementing Codec
+// on top of a library for them.
+type Codec interface {
+	// NewReader returns a ReadCloser that decompresses what it reads from in.
+	NewReader(in io.Reader) (io.ReadCloser, error)
+	// NewWriter returns a WriteCloser that compresses what is written to it to
+	// out, writing the last of it when closed.
+	NewWriter(out io.Writer) (io.WriteCloser, error)
+}
+
+// Gzip is a Codec for the gzip format, as implemented by compress/gzip.
+var Gzip Codec = gzipCodec{}
+
+type gzipCodec struct{}
+
+func (gzipCodec) NewReader(in io.Reader) (io.ReadCloser, error) {
+	return gzip.NewReader(in)
+}
+
+func (gzipCodec) NewWriter(out io.Writer) (io.WriteCloser, error) {
+	return gzip.NewWriter(out), nil
+}
+
+// NewReaderCompressed creates a new reader for text or binary Ion that has been
+// compressed with the given codec. The decompressor is closed when it reaches the
+// end of its input; any error it returns, including for input that isn't in the
+// codec's format at all, is returned by the reader's Err.
+func NewReaderCompressed(in io.Reader, codec Codec, opts ...ReaderOption) Reader {
+	return NewReaderCompressedCat(in, codec, nil, opts...)
+}
+
+// NewReaderCompressedCat creates a new reader for compressed Ion with the given
+// catalog.
+func NewReaderCompressedCat(in io.Reader, codec Codec, cat Catalog, opts ...ReaderOption) Reader {
+	return NewReaderCat(&decompressor{in: in, codec: codec}, cat, opts...)
+}
+
+// A decompressor reads from in through a decompressor created by codec, which it
+// creates on the first call to Read so that NewReaderCompressed needn't return an
+// error of its own.
+type decompressor struct {
+	in    io.Reader
+	codec Codec
+	r     io.ReadCloser
+	err   error
+}
+
+// Read implements io.Reader.
+func (d *decompressor) Read(p []byte) (int, error) {
+	if d.err != nil {
+		return 0, d.err
+	}
+	if d.r == nil {
+		if d.r, d.err = d.codec.NewReader(d.in); d.err != nil {
+			return 0, d.err
+		}
+	}
+
+	n, err := d.r.Read(p)
+	if err == io.EOF {
+		err = d.r.Close()
+		if err == nil {
+			err = io.EOF
+		}
+	}
+	d.err = err
+	return n, err
+}
+
+// A compressedWriter is a Writer whose output is compressed.
+type compressedWriter struct {
+	Writer
+
+	z         *compressor
+	newWriter func(io.Writer) Writer
+}
+
+// NewWriterCompressed creates a new Writer whose output is compressed with the given
+// codec before being written to out. The Writer itself, text or binary, is created by
+// calling newWriter with the compressor to write to, for example:
+//
+//	w := ion.NewWriterCompressed(f, ion.Gzip, func(out io.Writer) ion.Writer {
+//		return ion.NewBinaryWriter(out)
+//	})
+//
+// Each call to Finish closes the compressor, writing out the document written since the
+// previous call as a complete compressed stream. Values written after that go to a new
+// Writer created by calling newWriter again, through a new compressor. Gzip, like most
+// formats, reads a series of streams back as though they were one, so the result can
+// be read by NewReaderCompressed all the same.
+func NewWriterCompressed(out io.Writer, codec Codec, newWriter func(io.Writer) Writer) Writer {
+	z := &compressor{out: out, codec: codec}
+	return &compressedWriter{
+		Writer:    newWriter(z),
+		z:         z,
+		newWriter: newWriter,
+	}
+}
+
+// Finish finishes the current document and closes the compressor.
+func (w *compressedWriter) Finish() error {
+	if err := w.Writer.Finish(); err != nil {
+		return err
+	}
+	if err := w.z.close(); err != nil {
+		return err
+	}
+
+	// Start afresh for the next document.
+	w.Writer = w.newWriter(w.z)
+	return nil
+}
+
+// A compressor writes to out through a compressor created by codec, which it creates
+// on the first call to Write after being created or closed.
+type compressor struct {
+	out   io.Writer
+	codec Codec
+	w     io.WriteCloser
+}
+
+// Write implements io.Writer.
+func (z *compressor) Write(p []byte) (int, error) {
+	if z.w == nil {
+		w, err := z.codec.NewWriter(z.out)
+		if err != nil {
+			return 0, err
+		}
+		z.w = w
+	}
+	return z.w.Write(p)
+}
+
+// Close closes the current compressor, if anything has been written to it.
+func (z *compressor) close() error {
+	if z.w == nil {
+		return nil
+	}
+	w := z.w
+	z.w = nil
+	return w.Close()
+}
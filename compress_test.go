@@ -0,0 +1,104 @@
+package ion
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"testing"
+)
+
+func TestCompressedRoundTrip(t *testing.T) {
+	test := func(name string, newWriter func(io.Writer) Writer) {
+		t.Run(name, func(t *testing.T) {
+			buf := bytes.Buffer{}
+			w := NewWriterCompressed(&buf, Gzip, newWriter)
+
+			w.WriteSymbol("foo")
+			if err := w.Finish(); err != nil {
+				t.Fatal(err)
+			}
+
+			w.BeginStruct()
+			w.FieldName("bar")
+			w.WriteString("baz")
+			w.EndStruct()
+			if err := w.Finish(); err != nil {
+				t.Fatal(err)
+			}
+
+			// It's really gzipped.
+			if _, err := gzip.NewReader(bytes.NewReader(buf.Bytes())); err != nil {
+				t.Fatal(err)
+			}
+
+			r := NewReaderCompressed(&buf, Gzip)
+			_symbol(t, r, "foo")
+			_struct(t, r, func(t *testing.T, r Reader) {
+				_stringAF(t, r, "bar", nil, "baz")
+				_eof(t, r)
+			})
+			_eof(t, r)
+		})
+	}
+
+	test("text", func(out io.Writer) Writer { return NewTextWriter(out) })
+	test("binary", func(out io.Writer) Writer { return NewBinaryWriter(out) })
+}
+
+func TestCompressedReaderErrors(t *testing.T) {
+	test := func(name string, data []byte) {
+		t.Run(name, func(t *testing.T) {
+			r := NewReaderCompressed(bytes.NewReader(data), Gzip)
+			for r.Next() {
+			}
+			if r.Err() == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+
+	test("not gzip", []byte("{foo:bar}"))
+
+	buf := bytes.Buffer{}
+	zw := gzip.NewWriter(&buf)
+	zw.Write([]byte("{foo:bar} baz"))
+	zw.Close()
+	test("truncated", buf.Bytes()[:buf.Len()-4])
+}
+
+// A flateCodec is a Codec for raw DEFLATE data, standing in for one from another
+// library.
+type flateCodec struct{}
+
+func (flateCodec) NewReader(in io.Reader) (io.ReadCloser, error) {
+	return flate.NewReader(in), nil
+}
+
+func (flateCodec) NewWriter(out io.Writer) (io.WriteCloser, error) {
+	return flate.NewWriter(out, flate.BestCompression)
+}
+
+func TestCompressedCustomCodec(t *testing.T) {
+	buf := bytes.Buffer{}
+	w := NewWriterCompressed(&buf, flateCodec{}, func(out io.Writer) Writer {
+		return NewTextWriter(out)
+	})
+	w.WriteInt(42)
+	if err := w.Finish(); err != nil {
+		t.Fatal(err)
+	}
+
+	text, err := ioutil.ReadAll(flate.NewReader(bytes.NewReader(buf.Bytes())))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(text) != "42\n" {
+		t.Errorf("expected '42\\n', got '%v'", string(text))
+	}
+
+	r := NewReaderCompressed(&buf, flateCodec{})
+	_int(t, r, 42)
+	_eof(t, r)
+}
@@ -1,10 +1,32 @@
 package ion
 
 import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"math/big"
 	"reflect"
 	"time"
 )
 
+// The version of Ion that writers write by default, and the only one readers read.
+const (
+	versionMajor = 1
+	versionMinor = 0
+)
+
+// SupportedVersion returns true if the given version of Ion can be read and written.
+func supportedVersion(major, minor int) bool {
+	return major == versionMajor && minor == versionMinor
+}
+
+// BinaryVersionMarker returns the binary version marker for the given version of Ion.
+func binaryVersionMarker(major, minor byte) []byte {
+	return []byte{0xE0, major, minor, 0xEA}
+}
+
 var binaryNulls = func() []byte {
 	ret := make([]byte, StructType+1)
 	ret[NoType] = 0x0F
@@ -49,4 +71,39 @@ var hexChars = []byte{
 }
 
 var timeType = reflect.TypeOf(time.Time{})
+var durationType = reflect.TypeOf(time.Duration(0))
 var decimalType = reflect.TypeOf(Decimal{})
+var bigIntType = reflect.TypeOf(big.Int{})
+var jsonNumberType = reflect.TypeOf(json.Number(""))
+var orderedMapType = reflect.TypeOf(OrderedMap{})
+
+var stringerType = reflect.TypeOf((*fmt.Stringer)(nil)).Elem()
+var textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+var binaryUnmarshalerType = reflect.TypeOf((*encoding.BinaryUnmarshaler)(nil)).Elem()
+var valuerType = reflect.TypeOf((*driver.Valuer)(nil)).Elem()
+var scannerType = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+
+// IsIonNative returns true if the given type (or the type it points to) maps
+// directly to an Ion type, in which case it shouldn't be treated as an
+// encoding.TextMarshaler or encoding.TextUnmarshaler even if it is one.
+func isIonNative(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t == timeType || t == decimalType || t == bigIntType
+}
+
+// IsEnum returns true if t is a declared integer type, as Go enums are, rather than
+// one of the built-in ones, and so might be encoded as a string by its String method.
+func isEnum(t reflect.Type) bool {
+	if t.PkgPath() == "" {
+		return false
+	}
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return true
+	}
+	return false
+}
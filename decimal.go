@@ -1,6 +1,7 @@
 package ion
 
 import (
+	"errors"
 	"fmt"
 	"math"
 	"math/big"
@@ -25,6 +26,10 @@ func (e *ParseError) Error() string {
 type Decimal struct {
 	n     *big.Int
 	scale int32
+
+	// Ion distinguishes negative zero from zero, but big.Int doesn't,
+	// so keep track of it separately.
+	negZero bool
 }
 
 // NewDecimal creates a new decimal whose value is equal to n * 10^exp.
@@ -40,6 +45,45 @@ func NewDecimalInt(n int64) *Decimal {
 	return NewDecimal(big.NewInt(n), 0)
 }
 
+// DecimalFromFloat creates a new decimal whose value is equal to f, rounded
+// to the given number of decimal places. Rounding is performed on the exact
+// binary value of f, rounding to the nearest representable decimal and breaking
+// ties to even, so DecimalFromFloat(0.1, 2) is 0.10. A negative f that rounds
+// to zero becomes positive zero, so DecimalFromFloat(-0.001, 2) is 0.00; only a
+// negative zero f becomes a negative zero decimal. It panics if places is
+// negative or f is NaN or infinite, none of which can be represented as a Decimal.
+func DecimalFromFloat(f float64, places int) *Decimal {
+	if places < 0 {
+		panic("places must be non-negative")
+	}
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		panic("cannot represent NaN or infinity as a decimal")
+	}
+
+	str := strconv.FormatFloat(f, 'f', places, 64)
+	d := MustParseDecimal(str)
+	if f != 0 && d.IsNegativeZero() {
+		d = MustParseDecimal(str[1:])
+	}
+	return d
+}
+
+// NewDecimalFromBigFloat creates a new decimal from the given big.Float. Since
+// the big.Float is only accurate to its precision, the result is the decimal
+// with the fewest digits that rounds back to f at that precision, rather than
+// the exact (and potentially much longer) decimal expansion of f's binary
+// value. So a big.Float holding 0.1 at 53 bits of precision becomes 0.1, and
+// one holding 1/3 at 10 bits of precision becomes 0.3335. It returns an error
+// if f is infinite, which cannot be represented as a Decimal.
+func NewDecimalFromBigFloat(f *big.Float) (*Decimal, error) {
+	if f.IsInf() {
+		return nil, fmt.Errorf("ion: cannot represent %v as a decimal", f)
+	}
+
+	str := strings.Replace(f.Text('e', -1), "e", "d", 1)
+	return ParseDecimal(str)
+}
+
 // MustParseDecimal parses the given string into a decimal object,
 // panicing on error.
 func MustParseDecimal(in string) *Decimal {
@@ -92,7 +136,9 @@ func ParseDecimal(in string) (*Decimal, error) {
 		return nil, &ParseError{in, "cannot parse coefficient"}
 	}
 
-	return NewDecimal(n, exponent), nil
+	dec := NewDecimal(n, exponent)
+	dec.negZero = n.Sign() == 0 && in[0] == '-'
+	return dec, nil
 }
 
 // CoEx returns this decimal's coefficient and exponent.
@@ -100,6 +146,41 @@ func (d *Decimal) CoEx() (*big.Int, int32) {
 	return d.n, -d.scale
 }
 
+// BigFloat converts the decimal to a big.Float. The result's precision is the
+// number of bits in the decimal's coefficient, or 64 if that is larger. Most
+// decimals with digits after the decimal point, such as 0.1, have no exact
+// binary representation, so they are rounded to the nearest value at that
+// precision, with ties to even. Negative zero becomes a negative zero, as do
+// negative decimals too small in magnitude to be represented as a big.Float.
+// It returns an error if the decimal is too large in magnitude to represent.
+func (d *Decimal) BigFloat() (*big.Float, error) {
+	prec := uint(d.n.BitLen())
+	if prec < 64 {
+		prec = 64
+	}
+
+	str := d.n.String() + "e" + strconv.FormatInt(-int64(d.scale), 10)
+	if d.negZero {
+		str = "-" + str
+	}
+
+	f, _, err := big.ParseFloat(str, 10, prec, big.ToNearestEven)
+	if err == nil && f.IsInf() {
+		err = errors.New("exponent overflow")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ion: cannot convert %v to a big.Float: %v", d, err)
+	}
+	return f, nil
+}
+
+// IsNegativeZero returns true if this decimal is negative zero, like -0. or
+// -0.00. Negative zero is equal to zero, but it is a distinct Ion value and is
+// preserved when reading and writing.
+func (d *Decimal) IsNegativeZero() bool {
+	return d.negZero
+}
+
 // Abs returns the absolute value of this Decimal.
 func (d *Decimal) Abs() *Decimal {
 	return &Decimal{
@@ -127,11 +208,13 @@ func (d *Decimal) Sub(o *Decimal) *Decimal {
 	}
 }
 
-// Neg returns the negative of this Decimal.
+// Neg returns the negative of this Decimal. The negative of zero is negative
+// zero, and vice versa.
 func (d *Decimal) Neg() *Decimal {
 	return &Decimal{
-		n:     new(big.Int).Neg(d.n),
-		scale: d.scale,
+		n:       new(big.Int).Neg(d.n),
+		scale:   d.scale,
+		negZero: d.n.Sign() == 0 && !d.negZero,
 	}
 }
 
@@ -159,8 +242,9 @@ func (d *Decimal) ShiftL(shift int) *Decimal {
 	}
 
 	return &Decimal{
-		n:     d.n,
-		scale: int32(scale),
+		n:       d.n,
+		scale:   int32(scale),
+		negZero: d.negZero,
 	}
 }
 
@@ -174,8 +258,9 @@ func (d *Decimal) ShiftR(shift int) *Decimal {
 	}
 
 	return &Decimal{
-		n:     d.n,
-		scale: int32(scale),
+		n:       d.n,
+		scale:   int32(scale),
+		negZero: d.negZero,
 	}
 }
 
@@ -194,12 +279,50 @@ func (d *Decimal) Cmp(o *Decimal) int {
 	return dd.n.Cmp(oo.n)
 }
 
-// Equal determines if two decimals are equal (discounting precision,
-// at least for now).
+// Equal determines if two decimals have the same numeric value, regardless of
+// precision, so 1.0, 1.00, and 1. are all equal, as are 0. and -0.0. This is not
+// the equivalence of the Ion data model, under which decimals must also have the
+// same exponent and sign to be equivalent, as checked by the package-level Equal;
+// to compare that way, compare the results of CoEx and IsNegativeZero.
 func (d *Decimal) Equal(o *Decimal) bool {
 	return d.Cmp(o) == 0
 }
 
+// IsZero returns true if this decimal is zero, of any precision, or negative zero.
+func (d *Decimal) IsZero() bool {
+	return d.n.Sign() == 0
+}
+
+// Normalize returns an equal decimal with any trailing zeros stripped from its
+// coefficient, so 1.00 becomes 1., 1.50 becomes 1.5, and 100. becomes 1d2. Zeros of
+// any precision become 0., or -0. for negative zero. Decimals that are equal
+// according to Equal, apart from negative zero, normalize to identical ones.
+func (d *Decimal) Normalize() *Decimal {
+	if d.n.Sign() == 0 {
+		return &Decimal{
+			n:       new(big.Int),
+			negZero: d.negZero,
+		}
+	}
+
+	n := new(big.Int).Set(d.n)
+	scale := int64(d.scale)
+	q, r := new(big.Int), new(big.Int)
+	for scale > math.MinInt32 {
+		q.QuoRem(n, ten, r)
+		if r.Sign() != 0 {
+			break
+		}
+		n, q = q, n
+		scale--
+	}
+
+	return &Decimal{
+		n:     n,
+		scale: int32(scale),
+	}
+}
+
 func rescale(a, b *Decimal) (*Decimal, *Decimal) {
 	if a.scale < b.scale {
 		return a.upscale(b.scale), b
@@ -226,8 +349,9 @@ func (d *Decimal) upscale(scale int32) *Decimal {
 	n := new(big.Int).Mul(d.n, pow)
 
 	return &Decimal{
-		n:     n,
-		scale: scale,
+		n:       n,
+		scale:   scale,
+		negZero: d.negZero,
 	}
 }
 
@@ -292,29 +416,34 @@ func (d *Decimal) Truncate(precision int) *Decimal {
 	}
 
 	return &Decimal{
-		n:     n,
-		scale: int32(scale),
+		n:       n,
+		scale:   int32(scale),
+		negZero: d.negZero,
 	}
 }
 
 // String formats the decimal as a string in Ion text format.
 func (d *Decimal) String() string {
+	str := d.n.String()
+	if d.negZero {
+		str = "-" + str
+	}
+
 	switch {
 	case d.scale == 0:
 		// Value is an unscaled integer. Just mark it as a decimal.
-		return d.n.String() + "."
+		return str + "."
 
 	case d.scale < 0:
 		// Value is a upscaled integer, nn'd'ss
-		return d.n.String() + "d" + fmt.Sprintf("%d", -d.scale)
+		return str + "d" + fmt.Sprintf("%d", -d.scale)
 
 	default:
 		// Value is a downscaled integer nn.nn('d'-ss)?
-		str := d.n.String()
 		idx := len(str) - int(d.scale)
 
 		prefix := 1
-		if d.n.Sign() < 0 {
+		if str[0] == '-' {
 			// Account for leading '-'.
 			prefix++
 		}
@@ -2,6 +2,7 @@ package ion
 
 import (
 	"fmt"
+	"math"
 	"math/big"
 	"testing"
 )
@@ -83,6 +84,155 @@ func TestParseDecimal(t *testing.T) {
 	test("-0.12d4", big.NewInt(-12), -2)
 }
 
+func TestDecimalNegativeZero(t *testing.T) {
+	test := func(in string, eneg bool, estr string) {
+		t.Run(in, func(t *testing.T) {
+			d := MustParseDecimal(in)
+			if d.IsNegativeZero() != eneg {
+				t.Errorf("expected IsNegativeZero=%v", eneg)
+			}
+			if d.String() != estr {
+				t.Errorf("expected %v, got %v", estr, d.String())
+			}
+		})
+	}
+
+	test("0", false, "0.")
+	test("-0", true, "-0.")
+	test("-0.", true, "-0.")
+	test("-0.00", true, "-0d-2")
+	test("-0d3", true, "-0d3")
+	test("-1", false, "-1.")
+
+	zero := MustParseDecimal("0")
+	negZero := MustParseDecimal("-0")
+
+	if !zero.Equal(negZero) {
+		t.Error("expected -0 to equal 0")
+	}
+	if !zero.Neg().IsNegativeZero() {
+		t.Error("expected -(0) to be negative zero")
+	}
+	if negZero.Neg().IsNegativeZero() || negZero.Abs().IsNegativeZero() {
+		t.Error("expected -(-0) and |-0| to be zero")
+	}
+	if !negZero.ShiftR(2).IsNegativeZero() {
+		t.Error("expected shifting to preserve negative zero")
+	}
+}
+
+func TestDecimalFromFloat(t *testing.T) {
+	test := func(f float64, places int, expected string) {
+		t.Run(fmt.Sprintf("%v,%v", f, places), func(t *testing.T) {
+			actual := DecimalFromFloat(f, places).String()
+			if actual != expected {
+				t.Errorf("expected %v, got %v", expected, actual)
+			}
+		})
+	}
+
+	test(0.1, 2, "1.0d-1") // 0.10
+	test(0.1, 0, "0.")
+	test(1.005, 2, "1.00") // 1.005 is actually 1.00499999...
+	test(2.5, 0, "2.")
+	test(3.14159, 3, "3.142")
+	test(-3.14159, 3, "-3.142")
+	test(-0.1, 1, "-1d-1")
+	test(0, 0, "0.")
+	test(0, 3, "0d-3")
+	test(123456789, 1, "123456789.0")
+
+	// Rounding a negative number to zero loses its sign, but negative zero keeps it.
+	test(-0.001, 2, "0d-2")
+	test(math.Copysign(0, -1), 2, "-0d-2")
+	test(math.Copysign(0, -1), 0, "-0.")
+}
+
+func TestDecimalBigFloat(t *testing.T) {
+	test := func(in string, eprec uint, eval string) {
+		t.Run(in, func(t *testing.T) {
+			f, err := MustParseDecimal(in).BigFloat()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if f.Prec() != eprec {
+				t.Errorf("expected precision %v, got %v", eprec, f.Prec())
+			}
+			if actual := f.Text('g', -1); actual != eval {
+				t.Errorf("expected %v, got %v", eval, actual)
+			}
+		})
+	}
+
+	test("0", 64, "0")
+	test("-0", 64, "-0")
+	test("1.5", 64, "1.5")
+	test("-42d3", 64, "-42000")
+	test("0.1", 64, "0.1")
+	test("123456789012345678901234567890", 97, "1.2345678901234567890123456789e+29")
+
+	// 0.1 can't be represented exactly; it's rounded to 64 bits.
+	f, _ := MustParseDecimal("0.1").BigFloat()
+	if acc := new(big.Float).SetPrec(200).Mul(f, big.NewFloat(10)).Cmp(big.NewFloat(1)); acc == 0 {
+		t.Error("expected 0.1 to be inexact")
+	}
+
+	if _, err := MustParseDecimal("1d2000000000").BigFloat(); err == nil {
+		t.Error("expected an error for a huge exponent")
+	}
+
+	f, err := MustParseDecimal("-1d-2000000000").BigFloat()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Sign() != 0 || !f.Signbit() {
+		t.Errorf("expected -0, got %v", f)
+	}
+}
+
+func TestNewDecimalFromBigFloat(t *testing.T) {
+	test := func(f *big.Float, eval string) {
+		t.Run(eval, func(t *testing.T) {
+			d, err := NewDecimalFromBigFloat(f)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if actual := d.String(); actual != eval {
+				t.Errorf("expected %v, got %v", eval, actual)
+			}
+
+			// Converting back at the original precision gives the original value.
+			rt, err := d.BigFloat()
+			if err != nil {
+				t.Fatal(err)
+			}
+			rt.SetPrec(f.Prec())
+			if rt.Cmp(f) != 0 || rt.Signbit() != f.Signbit() {
+				t.Errorf("expected %v to round-trip, got %v", f, rt)
+			}
+		})
+	}
+
+	third := func(prec uint) *big.Float {
+		f := new(big.Float).SetPrec(prec)
+		return f.Quo(big.NewFloat(1), big.NewFloat(3))
+	}
+
+	test(new(big.Float), "0.")
+	test(new(big.Float).Neg(new(big.Float)), "-0.")
+	test(big.NewFloat(1.5), "1.5")
+	test(big.NewFloat(-100), "-1d2")
+	test(big.NewFloat(0.1), "1d-1")
+	test(big.NewFloat(1e300), "1d300")
+	test(third(10), "3.335d-1")
+	test(third(53), "3.333333333333333d-1")
+	test(third(100), "3.333333333333333333333333333335d-1")
+
+	if _, err := NewDecimalFromBigFloat(new(big.Float).SetInf(false)); err == nil {
+		t.Error("expected an error for +inf")
+	}
+}
+
 func absF(d *Decimal) *Decimal { return d.Abs() }
 func negF(d *Decimal) *Decimal { return d.Neg() }
 
@@ -310,3 +460,72 @@ func TestUpscale(t *testing.T) {
 		t.Errorf("expected 10.0000, got %v", actual)
 	}
 }
+
+func TestEqualNormalize(t *testing.T) {
+	test := func(a, b string, eeq bool) {
+		t.Run(a+"="+b, func(t *testing.T) {
+			ad, bd := MustParseDecimal(a), MustParseDecimal(b)
+			if eq := ad.Equal(bd); eq != eeq {
+				t.Errorf("expected Equal to be %v", eeq)
+			}
+			if same := ad.Normalize().String() == bd.Normalize().String(); same != eeq && !ad.IsZero() {
+				t.Errorf("expected the normalized forms %v and %v to match: %v", ad.Normalize(), bd.Normalize(), eeq)
+			}
+		})
+	}
+
+	test("1.0", "1.00", true)
+	test("1.0", "1.", true)
+	test("1.00", "1.", true)
+	test("1d2", "100.0", true)
+	test("0.", "-0.00", true)
+	test("1.0", "1.01", false)
+	test("1.0", "-1.0", false)
+
+	// Equal is about value, not Ion equivalence, which also requires the same exponent.
+	eq, err := Equal([]byte("1.0"), []byte("1.00"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if eq {
+		t.Error("expected 1.0 and 1.00 not to be equivalent Ion values")
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	test := func(in, expected string) {
+		t.Run(in, func(t *testing.T) {
+			d := MustParseDecimal(in)
+			before := d.String()
+			actual := d.Normalize().String()
+			if actual != expected {
+				t.Errorf("expected %v, got %v", expected, actual)
+			}
+			if d.String() != before {
+				t.Errorf("normalize modified the original: %v", d)
+			}
+		})
+	}
+
+	test("1.00", "1.")
+	test("1.50", "1.5")
+	test("100.", "1d2")
+	test("-1200d-1", "-12d1")
+	test("12.34", "12.34")
+	test("0.000", "0.")
+	test("-0.00", "-0.")
+	test("0d10", "0.")
+}
+
+func TestIsZero(t *testing.T) {
+	for _, in := range []string{"0.", "0.000", "-0.", "0d5"} {
+		if !MustParseDecimal(in).IsZero() {
+			t.Errorf("expected %v to be zero", in)
+		}
+	}
+	for _, in := range []string{"1.", "-0.001", "1d-100"} {
+		if MustParseDecimal(in).IsZero() {
+			t.Errorf("expected %v not to be zero", in)
+		}
+	}
+}
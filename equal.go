@@ -0,0 +1,155 @@
+package ion
+
+import (
+	"bytes"
+	"math"
+	"math/big"
+	"time"
+)
+
+// Equal determines whether two Ion documents, in either text or binary form,
+// are equivalent according to the Ion data model. Two documents are equivalent
+// if they contain the same sequence of equivalent top-level values. Two values
+// are equivalent if they have the same type and annotations and:
+//
+//   - nulls are of the same type;
+//   - ints have the same value;
+//   - floats have the same value, treating nan as equal to nan and -0e0 as
+//     distinct from 0e0;
+//   - decimals have the same coefficient and exponent, so 1.0 is not equal
+//     to 1.00, and -0. is not equal to 0.;
+//   - timestamps represent the same instant with the same offset and precision;
+//   - symbols, strings, clobs, and blobs have the same content, regardless of
+//     whether a symbol was encoded by text or by symbol ID;
+//   - lists and sexps contain equivalent values in the same order;
+//   - structs contain the same multiset of fields, in any order.
+//
+// An error is returned if either document cannot be read.
+func Equal(a, b []byte) (bool, error) {
+	avs, err := ReadValues(NewReaderBytes(a))
+	if err != nil {
+		return false, err
+	}
+	bvs, err := ReadValues(NewReaderBytes(b))
+	if err != nil {
+		return false, err
+	}
+	return equivalentSeqs(avs, bvs), nil
+}
+
+// EquivalentSeqs determines whether two ordered sequences of values are equivalent.
+func equivalentSeqs(a, b []Value) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !equivalent(&a[i], &b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// Equivalent determines whether two values are equivalent. Field names are
+// not compared here; equivalentFields takes care of that for structs.
+func equivalent(a, b *Value) bool {
+	if a.Type != b.Type || a.Null != b.Null {
+		return false
+	}
+	if !equivalentAnnotations(a.Annotations, b.Annotations) {
+		return false
+	}
+	if a.Null {
+		return true
+	}
+
+	switch a.Type {
+	case BoolType:
+		return a.Scalar.(bool) == b.Scalar.(bool)
+
+	case IntType:
+		return toBigInt(a.Scalar).Cmp(toBigInt(b.Scalar)) == 0
+
+	case FloatType:
+		af, bf := a.Scalar.(float64), b.Scalar.(float64)
+		if math.IsNaN(af) || math.IsNaN(bf) {
+			return math.IsNaN(af) && math.IsNaN(bf)
+		}
+		return math.Float64bits(af) == math.Float64bits(bf)
+
+	case DecimalType:
+		ad, bd := a.Scalar.(*Decimal), b.Scalar.(*Decimal)
+		an, ae := ad.CoEx()
+		bn, be := bd.CoEx()
+		return ae == be && an.Cmp(bn) == 0 && ad.IsNegativeZero() == bd.IsNegativeZero()
+
+	case TimestampType:
+		at, bt := a.Scalar.(time.Time), b.Scalar.(time.Time)
+		_, ao := at.Zone()
+		_, bo := bt.Zone()
+		return at.Equal(bt) && ao == bo && a.Precision == b.Precision
+
+	case SymbolType, StringType:
+		return a.Scalar.(string) == b.Scalar.(string)
+
+	case ClobType, BlobType:
+		return bytes.Equal(a.Scalar.([]byte), b.Scalar.([]byte))
+
+	case ListType, SexpType:
+		return equivalentSeqs(a.Children, b.Children)
+
+	case StructType:
+		return equivalentFields(a.Children, b.Children)
+	}
+
+	return false
+}
+
+// EquivalentAnnotations determines whether two lists of annotations are the same.
+func equivalentAnnotations(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// EquivalentFields determines whether two sets of struct fields are equivalent.
+// Order doesn't matter, but repeated fields must be matched the same number of
+// times in each.
+func equivalentFields(a, b []Value) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	matched := make([]bool, len(b))
+	for i := range a {
+		found := false
+		for j := range b {
+			if matched[j] || a[i].FieldName != b[j].FieldName {
+				continue
+			}
+			if equivalent(&a[i], &b[j]) {
+				matched[j] = true
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// ToBigInt converts an int64 or *big.Int scalar to a *big.Int.
+func toBigInt(i interface{}) *big.Int {
+	if n, ok := i.(int64); ok {
+		return big.NewInt(n)
+	}
+	return i.(*big.Int)
+}
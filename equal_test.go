@@ -0,0 +1,137 @@
+package ion
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestEqual(t *testing.T) {
+	test := func(a, b string, eval bool) {
+		t.Run(a+" == "+b, func(t *testing.T) {
+			val, err := Equal([]byte(a), []byte(b))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if val != eval {
+				t.Errorf("expected %v, got %v", eval, val)
+			}
+
+			// Equivalence should be symmetric.
+			val, err = Equal([]byte(b), []byte(a))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if val != eval {
+				t.Errorf("expected %v reversed, got %v", eval, val)
+			}
+		})
+	}
+
+	test("", "", true)
+	test("1", "", false)
+	test("1 2", "1", false)
+
+	test("null", "null.null", true)
+	test("null", "null.int", false)
+	test("null.int", "null.int", true)
+	test("null.list", "[]", false)
+
+	test("true", "true", true)
+	test("true", "false", false)
+
+	test("16", "0x10", true)
+	test("16", "0b10000", true)
+	test("0", "-0", true)
+	test("1", "1.", false)
+	test("18446744073709551616", "0x10000000000000000", true)
+	test("18446744073709551616", "18446744073709551617", false)
+
+	test("1e0", "1.0e0", true)
+	test("0e0", "-0e0", false)
+	test("nan", "nan", true)
+	test("+inf", "+inf", true)
+	test("+inf", "-inf", false)
+	test("1e0", "1.", false)
+
+	test("1.", "1d0", true)
+	test("1.0", "10d-1", true)
+	test("1.0", "1.00", false)
+	test("100.", "1d2", false)
+	test("0.", "0d1", false)
+	test("0.", "-0.", false)
+	test("-0.", "-0d0", true)
+	test("-0.0", "-0d-1", true)
+	test("-0.0", "-0.00", false)
+
+	test("2000-01-01T00:00:00Z", "2000-01-01T00:00:00+00:00", true)
+	test("2000-01-01T00:00:00Z", "1999-12-31T23:00:00-01:00", false)
+	test("2000-01-01T00:00:00Z", "2000-01-01T00:00:01Z", false)
+	test("2000T", "2000-01T", false)
+	test("2000-01T", "2000-01-01", false)
+	test("2000-01-01", "2000-01-01T", true)
+	test("2000-01-01", "2000-01-01T00:00Z", false)
+	test("2000-01-01T00:00Z", "2000-01-01T00:00:00Z", false)
+	test("2000-01-01T00:00:00Z", "2000-01-01T00:00:00.0Z", false)
+
+	test("abc", "'abc'", true)
+	test("abc", "\"abc\"", false)
+	test("'''ab''' '''c'''", "\"abc\"", true)
+
+	test("{{\"abc\"}}", "{{ YWJj }}", false)
+	test("{{ YWJj }}", "{{YWJj}}", true)
+	test("{{\"abc\"}}", "{{'''a''' '''bc'''}}", true)
+
+	test("a::1", "1", false)
+	test("a::b::1", "b::a::1", false)
+	test("a::b::1", "'a'::'b'::1", true)
+
+	test("[1, 2]", "[1, 2]", true)
+	test("[1, 2]", "[2, 1]", false)
+	test("[1, 2]", "(1 2)", false)
+	test("(a+b)", "(a '+' b)", true)
+
+	test("{a:1, b:2}", "{b:2, a:1}", true)
+	test("{a:1, b:2}", "{a:2, b:1}", false)
+	test("{a:1, a:2}", "{a:2, a:1}", true)
+	test("{a:1, a:1}", "{a:1}", false)
+	test("{a:1, a:1, b:2}", "{a:1, b:2, b:2}", false)
+	test("{a:{b:[1.0]}}", "{'a':{\"b\":[10d-1]}}", true)
+	test("{a:{b:[1.0]}}", "{a:{b:[1.00]}}", false)
+}
+
+func TestEqualTextAndBinary(t *testing.T) {
+	text := `a::1 2.50 3e0 {b:foo, c:[bar::"baz", null.sexp]} (x y) 2000-01-01T00:00Z {{ YWJj }}`
+
+	vs, err := ReadValues(NewReaderStr(text))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buf := bytes.Buffer{}
+	w := NewBinaryWriter(&buf)
+	for i := range vs {
+		if err := vs[i].WriteTo(w); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Finish(); err != nil {
+		t.Fatal(err)
+	}
+
+	eq, err := Equal([]byte(text), buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !eq {
+		t.Errorf("expected text and binary to be equal")
+	}
+}
+
+func TestEqualError(t *testing.T) {
+	if _, err := Equal([]byte("{a:"), []byte("1")); err == nil {
+		t.Error("expected an error")
+	}
+	if _, err := Equal([]byte("1"), []byte("[1")); err == nil {
+		t.Error("expected an error")
+	}
+}
@@ -1,6 +1,9 @@
 package ion
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
 
 // A UsageError is returned when you use a Reader or Writer in an inappropriate way.
 type UsageError struct {
@@ -86,3 +89,69 @@ type UnexpectedTokenError struct {
 func (e *UnexpectedTokenError) Error() string {
 	return fmt.Sprintf("ion: unexpected token '%v' (offset %v)", e.Token, e.Offset)
 }
+
+// A TooManyValuesError is returned when a Decoder, or ReadValueMax, encounters more
+// values than it has been configured to decode at once.
+type TooManyValuesError struct {
+	Max int
+}
+
+func (e *TooManyValuesError) Error() string {
+	return fmt.Sprintf("ion: too many values to decode (max %v)", e.Max)
+}
+
+// A DepthLimitError is returned when a Reader is asked to step in to a container
+// nested more deeply than its maximum depth.
+type DepthLimitError struct {
+	Max    int
+	Offset uint64
+}
+
+func (e *DepthLimitError) Error() string {
+	return fmt.Sprintf("ion: containers nested deeper than %v (offset %v)", e.Max, e.Offset)
+}
+
+// A SizeLimitError is returned when a binary Reader encounters a value whose length
+// exceeds its maximum value size, or a FramedReader a message whose length exceeds
+// its maximum frame size.
+type SizeLimitError struct {
+	Max    uint64
+	Size   uint64
+	Offset uint64
+}
+
+func (e *SizeLimitError) Error() string {
+	return fmt.Sprintf("ion: length of %v bytes is larger than the max of %v (offset %v)", e.Size, e.Max, e.Offset)
+}
+
+// A SchemaError is returned when a value does not satisfy a Schema. Path identifies
+// the offending value within the top-level value, in the form described by Transform.
+type SchemaError struct {
+	Path string
+	Msg  string
+}
+
+func (e *SchemaError) Error() string {
+	if e.Path == "" {
+		return fmt.Sprintf("ion: schema violation: %v", e.Msg)
+	}
+	return fmt.Sprintf("ion: schema violation at %v: %v", e.Path, e.Msg)
+}
+
+// A MultiError is returned by Finish on a Writer with the CollectErrors error mode
+// if any of the calls made to it since the previous Finish failed. Errs holds each
+// of their errors, in the order they happened.
+type MultiError struct {
+	Errs []error
+}
+
+func (e *MultiError) Error() string {
+	if len(e.Errs) == 1 {
+		return e.Errs[0].Error()
+	}
+	msgs := make([]string, len(e.Errs))
+	for i, err := range e.Errs {
+		msgs[i] = err.Error()
+	}
+	return fmt.Sprintf("ion: %v errors: %v", len(e.Errs), strings.Join(msgs, "; "))
+}
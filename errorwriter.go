@@ -0,0 +1,219 @@
+package ion
+
+import (
+	"math/big"
+	"time"
+)
+
+// An errorWriter wraps a text or binary writer to handle its errors according
+// to an ErrorMode other than the default.
+type errorWriter struct {
+	Writer
+	w    *writer
+	errs []error
+
+	// The errors that stick until cleared: w's, and those of any layers between it
+	// and us.
+	sticky []*error
+}
+
+// WrapErrors returns self, the Writer of which w is a part, wrapped to handle its
+// errors according to w's error mode, or unwrapped if that is the default. Any
+// layers between w and self must pass pointers to their own sticky errors, so
+// they can be cleared too.
+func (w *writer) wrapErrors(self Writer, sticky ...*error) Writer {
+	if w.errMode == StopOnError {
+		return self
+	}
+	e := &errorWriter{Writer: self, w: w, sticky: append([]*error{&w.err}, sticky...)}
+	e.check(w.err)
+	return e
+}
+
+// FieldName sets the field name for the next value written.
+func (e *errorWriter) FieldName(val string) error {
+	return e.check(e.Writer.FieldName(val))
+}
+
+// Annotation adds an annotation to the next value written.
+func (e *errorWriter) Annotation(val string) error {
+	return e.check(e.Writer.Annotation(val))
+}
+
+// Annotations adds annotations to the next value written.
+func (e *errorWriter) Annotations(vals ...string) error {
+	return e.check(e.Writer.Annotations(vals...))
+}
+
+// WriteNull writes an untyped null.
+func (e *errorWriter) WriteNull() error {
+	return e.check(e.Writer.WriteNull())
+}
+
+// WriteNullType writes a typed null.
+func (e *errorWriter) WriteNullType(t Type) error {
+	return e.check(e.Writer.WriteNullType(t))
+}
+
+// WriteBool writes a bool.
+func (e *errorWriter) WriteBool(val bool) error {
+	return e.check(e.Writer.WriteBool(val))
+}
+
+// WriteInt writes an int.
+func (e *errorWriter) WriteInt(val int64) error {
+	return e.check(e.Writer.WriteInt(val))
+}
+
+// WriteUint writes a uint.
+func (e *errorWriter) WriteUint(val uint64) error {
+	return e.check(e.Writer.WriteUint(val))
+}
+
+// WriteBigInt writes a big int.
+func (e *errorWriter) WriteBigInt(val *big.Int) error {
+	return e.check(e.Writer.WriteBigInt(val))
+}
+
+// WriteFloat writes a float.
+func (e *errorWriter) WriteFloat(val float64) error {
+	return e.check(e.Writer.WriteFloat(val))
+}
+
+// WriteDecimal writes a decimal.
+func (e *errorWriter) WriteDecimal(val *Decimal) error {
+	return e.check(e.Writer.WriteDecimal(val))
+}
+
+// WriteMoney writes a decimal amount annotated with a currency code.
+func (e *errorWriter) WriteMoney(annotation string, amount string) error {
+	return e.check(e.Writer.WriteMoney(annotation, amount))
+}
+
+// WriteTimestamp writes a timestamp.
+func (e *errorWriter) WriteTimestamp(val time.Time) error {
+	return e.check(e.Writer.WriteTimestamp(val))
+}
+
+// WriteTimestampWithPrecision writes a timestamp with the given precision.
+func (e *errorWriter) WriteTimestampWithPrecision(val time.Time, precision TimestampPrecision) error {
+	return e.check(e.Writer.WriteTimestampWithPrecision(val, precision))
+}
+
+// WriteSymbol writes a symbol.
+func (e *errorWriter) WriteSymbol(val string) error {
+	return e.check(e.Writer.WriteSymbol(val))
+}
+
+// WriteSymbolByID writes a symbol given its symbol ID.
+func (e *errorWriter) WriteSymbolByID(id uint64) error {
+	return e.check(e.Writer.WriteSymbolByID(id))
+}
+
+// WriteString writes a string.
+func (e *errorWriter) WriteString(val string) error {
+	return e.check(e.Writer.WriteString(val))
+}
+
+// WriteClob writes a clob.
+func (e *errorWriter) WriteClob(val []byte) error {
+	return e.check(e.Writer.WriteClob(val))
+}
+
+// WriteBlob writes a blob.
+func (e *errorWriter) WriteBlob(val []byte) error {
+	return e.check(e.Writer.WriteBlob(val))
+}
+
+// WriteBlobString writes a blob given its base64 encoding.
+func (e *errorWriter) WriteBlobString(base64Str string) error {
+	return e.check(e.Writer.WriteBlobString(base64Str))
+}
+
+// WriteIntList writes a list of ints.
+func (e *errorWriter) WriteIntList(vals []int64) error {
+	return e.check(e.Writer.WriteIntList(vals))
+}
+
+// WriteFloatList writes a list of floats.
+func (e *errorWriter) WriteFloatList(vals []float64) error {
+	return e.check(e.Writer.WriteFloatList(vals))
+}
+
+// WriteStringList writes a list of strings.
+func (e *errorWriter) WriteStringList(vals []string) error {
+	return e.check(e.Writer.WriteStringList(vals))
+}
+
+// WriteRawText writes a value given in Ion text form.
+func (e *errorWriter) WriteRawText(s string) error {
+	return e.check(e.Writer.WriteRawText(s))
+}
+
+// WritePadding writes n bytes of padding.
+func (e *errorWriter) WritePadding(n int) error {
+	return e.check(e.Writer.WritePadding(n))
+}
+
+// BeginList begins writing a list.
+func (e *errorWriter) BeginList() error {
+	return e.check(e.Writer.BeginList())
+}
+
+// EndList finishes writing a list.
+func (e *errorWriter) EndList() error {
+	return e.check(e.Writer.EndList())
+}
+
+// BeginSexp begins writing an s-expression.
+func (e *errorWriter) BeginSexp() error {
+	return e.check(e.Writer.BeginSexp())
+}
+
+// EndSexp finishes writing an s-expression.
+func (e *errorWriter) EndSexp() error {
+	return e.check(e.Writer.EndSexp())
+}
+
+// BeginStruct begins writing a struct.
+func (e *errorWriter) BeginStruct() error {
+	return e.check(e.Writer.BeginStruct())
+}
+
+// EndStruct finishes writing a struct.
+func (e *errorWriter) EndStruct() error {
+	return e.check(e.Writer.EndStruct())
+}
+
+// Finish finishes writing values and flushes any buffered output. With the
+// CollectErrors error mode, it returns every error collected since the previous
+// call to Finish.
+func (e *errorWriter) Finish() error {
+	if err := e.check(e.Writer.Finish()); e.w.errMode != CollectErrors {
+		return err
+	}
+	if len(e.errs) > 0 {
+		errs := e.errs
+		e.errs = nil
+		return &MultiError{errs}
+	}
+	return nil
+}
+
+// Check handles an error from the wrapped writer according to the error mode,
+// panicking with it or collecting it and clearing it so the writer carries on.
+func (e *errorWriter) check(err error) error {
+	if err == nil {
+		return nil
+	}
+	switch e.w.errMode {
+	case PanicOnError:
+		panic(err)
+	case CollectErrors:
+		e.errs = append(e.errs, err)
+		for _, p := range e.sticky {
+			*p = nil
+		}
+	}
+	return err
+}
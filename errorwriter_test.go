@@ -0,0 +1,160 @@
+package ion
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+// WriteErrors makes three bad calls to w, with a good one in between, returning
+// the errors the bad calls returned.
+func writeErrors(w Writer) []error {
+	var errs []error
+	errs = append(errs, w.FieldName("a"))
+	errs = append(errs, w.WriteTimestampWithPrecision(time.Time{}, TimestampPrecision(99)))
+	w.WriteInt(1)
+	errs = append(errs, w.EndList())
+	return errs
+}
+
+func TestWriterStopOnError(t *testing.T) {
+	buf := strings.Builder{}
+	w := NewTextWriter(&buf)
+	errs := writeErrors(w)
+
+	// The first error sticks.
+	for i, err := range errs {
+		if err == nil || err != errs[0] {
+			t.Errorf("expected error %v to be the first error, got %v", i, err)
+		}
+	}
+	if err := w.Finish(); err != errs[0] {
+		t.Errorf("expected Finish to return the first error, got %v", err)
+	}
+	if buf.String() != "" {
+		t.Errorf("expected no output, got %q", buf.String())
+	}
+}
+
+func TestWriterCollectErrors(t *testing.T) {
+	test := func(t *testing.T, w Writer, read func() Reader) {
+		errs := writeErrors(w)
+		for i, err := range errs {
+			if err == nil {
+				t.Fatalf("expected error %v", i)
+			}
+			for _, prev := range errs[:i] {
+				if err == prev {
+					t.Errorf("expected error %v to be new, got %v", i, err)
+				}
+			}
+		}
+		if _, ok := errs[1].(*UsageError); !ok {
+			t.Errorf("expected a UsageError, got %v", errs[1])
+		}
+
+		err := w.Finish()
+		me, ok := err.(*MultiError)
+		if !ok {
+			t.Fatalf("expected a MultiError, got %v", err)
+		}
+		if len(me.Errs) != len(errs) {
+			t.Fatalf("expected %v errors, got %v", len(errs), me.Errs)
+		}
+		for i := range errs {
+			if me.Errs[i] != errs[i] {
+				t.Errorf("expected error %v to be %v, got %v", i, errs[i], me.Errs[i])
+			}
+		}
+		if !strings.HasPrefix(me.Error(), "ion: 3 errors: ") {
+			t.Errorf("unexpected message %q", me.Error())
+		}
+
+		// The good call in between still made it out.
+		r := read()
+		_int(t, r, 1)
+		_eof(t, r)
+	}
+
+	t.Run("text", func(t *testing.T) {
+		buf := strings.Builder{}
+		w := NewTextWriter(&buf, WithErrorMode(CollectErrors))
+		test(t, w, func() Reader { return NewReaderStr(buf.String()) })
+	})
+
+	t.Run("binary", func(t *testing.T) {
+		buf := bytes.Buffer{}
+		w := NewBinaryWriterOpts(&buf, nil, WithErrorMode(CollectErrors))
+		test(t, w, func() Reader { return NewReaderBytes(buf.Bytes()) })
+	})
+
+	t.Run("none", func(t *testing.T) {
+		buf := strings.Builder{}
+		w := NewTextWriter(&buf, WithErrorMode(CollectErrors))
+		w.WriteInt(1)
+		if err := w.Finish(); err != nil {
+			t.Errorf("expected no error, got %v", err)
+		}
+	})
+
+	t.Run("option", func(t *testing.T) {
+		buf := strings.Builder{}
+		w := NewTextWriter(&buf, WithErrorMode(CollectErrors), WithIndent("x"))
+		w.WriteInt(1)
+		err := w.Finish()
+		if me, ok := err.(*MultiError); !ok || len(me.Errs) != 1 {
+			t.Errorf("expected the option's error, got %v", err)
+		} else if me.Error() != me.Errs[0].Error() {
+			t.Errorf("expected the one error's message, got %q", me.Error())
+		}
+		if buf.String() != "1\n" {
+			t.Errorf("expected %q, got %q", "1\n", buf.String())
+		}
+	})
+
+	t.Run("reuse", func(t *testing.T) {
+		buf := strings.Builder{}
+		w := NewTextWriter(&buf, WithErrorMode(CollectErrors))
+		w.EndList()
+		if me, ok := w.Finish().(*MultiError); !ok || len(me.Errs) != 1 {
+			t.Fatalf("expected the EndList error, got %v", me)
+		}
+
+		// Errors already returned by Finish aren't returned again.
+		w.WriteInt(1)
+		if err := w.Finish(); err != nil {
+			t.Errorf("expected no error, got %v", err)
+		}
+	})
+}
+
+func TestWriterPanicOnError(t *testing.T) {
+	expectPanic := func(t *testing.T, api string, f func()) {
+		defer func() {
+			r := recover()
+			if err, ok := r.(error); !ok || !strings.Contains(err.Error(), api) {
+				t.Errorf("expected a panic with an error from %v, got %v", api, r)
+			}
+		}()
+		f()
+	}
+
+	t.Run("call", func(t *testing.T) {
+		buf := strings.Builder{}
+		w := NewBinaryWriterOpts(&buf, nil, WithErrorMode(PanicOnError))
+		if err := w.WriteInt(1); err != nil {
+			t.Fatal(err)
+		}
+		expectPanic(t, "Writer.FieldName", func() {
+			writeErrors(w)
+		})
+	})
+
+	t.Run("option", func(t *testing.T) {
+		buf := strings.Builder{}
+		expectPanic(t, "WithIndent", func() {
+			NewTextWriter(&buf, WithErrorMode(PanicOnError), WithIndent("x"))
+		})
+	})
+}
@@ -1,9 +1,11 @@
 package ion
 
 import (
-	"fmt"
 	"reflect"
+	"strconv"
 	"strings"
+	"sync"
+	"unicode"
 )
 
 // A field is a reflectively-accessed field of a struct type.
@@ -12,22 +14,105 @@ type field struct {
 	typ       reflect.Type
 	path      []int
 	omitEmpty bool
+	tagged    bool
+
+	// Sid is the symbol ID given by an `ion:",sid=N"` tag, or SymbolIDUnknown.
+	// When decoding, a field name with this symbol ID matches the field ahead of
+	// any field matched by the name's text.
+	sid int64
 }
 
 // A fielder maps out the fields of a type.
 type fielder struct {
 	fields []field
-	index  map[string]bool
 }
 
-// FieldsFor returns the fields of the given struct type.
-// TODO: cache me.
+// FieldCache maps struct types to their fields, as returned by fieldsFor.
+var fieldCache sync.Map
+
+// FieldsFor returns the fields of the given struct type, including fields
+// promoted from embedded structs. The result is cached and shared, so it
+// must not be modified.
 func fieldsFor(t reflect.Type) []field {
-	fldr := fielder{index: map[string]bool{}}
+	if fields, ok := fieldCache.Load(t); ok {
+		return fields.([]field)
+	}
+
+	fields := dominantFields(inspectFields(t))
+
+	fieldCache.Store(t, fields)
+	return fields
+}
+
+// InspectFields returns all the fields of the given struct type, including fields
+// promoted from embedded structs, before any with the same name are resolved.
+func inspectFields(t reflect.Type) []field {
+	fldr := fielder{}
 	fldr.inspect(t, nil)
 	return fldr.fields
 }
 
+// A fieldNamer renames the untagged fields of struct types with a function set by
+// SetFieldNameMapper, caching the results.
+type fieldNamer struct {
+	mapper func(string) string
+	cache  map[reflect.Type][]field
+}
+
+// FieldsFor returns the fields of the given struct type, renamed by the mapper if
+// there is one. Fields are renamed before those with the same name are resolved, so
+// a tagged field still wins over an untagged one mapped to the same name.
+func (n *fieldNamer) fieldsFor(t reflect.Type) []field {
+	if n.mapper == nil {
+		return fieldsFor(t)
+	}
+	if mapped, ok := n.cache[t]; ok {
+		return mapped
+	}
+
+	fields := inspectFields(t)
+	for i := range fields {
+		if !fields[i].tagged {
+			fields[i].name = n.mapper(fields[i].name)
+		}
+	}
+	mapped := dominantFields(fields)
+
+	if n.cache == nil {
+		n.cache = map[reflect.Type][]field{}
+	}
+	n.cache[t] = mapped
+	return mapped
+}
+
+// SetMapper sets the mapper, discarding any fields mapped by the previous one.
+func (n *fieldNamer) setMapper(mapper func(string) string) {
+	n.mapper = mapper
+	n.cache = nil
+}
+
+// SnakeCase converts a Go field name to snake_case, for use with an Encoder's and a
+// Decoder's SetFieldNameMapper: UserName becomes user_name, and a run of capitals is
+// taken as an initialism, so UserID becomes user_id and HTTPServer http_server.
+func SnakeCase(name string) string {
+	rs := []rune(name)
+	buf := strings.Builder{}
+	for i, r := range rs {
+		if unicode.IsUpper(r) {
+			if i > 0 {
+				prev := rs[i-1]
+				nextLower := i+1 < len(rs) && unicode.IsLower(rs[i+1])
+				if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+					buf.WriteByte('_')
+				}
+			}
+			r = unicode.ToLower(r)
+		}
+		buf.WriteRune(r)
+	}
+	return buf.String()
+}
+
 // Inspect recursively inspects a type to determine all of its fields.
 func (f *fielder) inspect(t reflect.Type, path []int) {
 	for i := 0; i < t.NumField(); i++ {
@@ -58,25 +143,79 @@ func (f *fielder) inspect(t reflect.Type, path []int) {
 			f.inspect(ft, newpath)
 		} else {
 			// Add this named field.
+			tagged := name != ""
 			if name == "" {
 				name = sf.Name
 			}
 
-			if f.index[name] {
-				panic(fmt.Sprintf("too many fields named %v", name))
-			}
-			f.index[name] = true
+			_, ionOpts := parseJSONTag(sf.Tag.Get("ion"))
 
 			f.fields = append(f.fields, field{
 				name:      name,
 				typ:       ft,
 				path:      newpath,
 				omitEmpty: omitEmpty(opts),
+				tagged:    tagged,
+				sid:       sid(ionOpts),
 			})
 		}
 	}
 }
 
+// DominantFields resolves conflicts between fields with the same name using
+// Go's rules for promoted fields, as encoding/json does: a shallower field
+// shadows deeper ones, and between fields at the same depth a tagged field
+// wins. If there's still more than one candidate, the name is ambiguous and
+// all of the fields with that name are dropped.
+func dominantFields(fields []field) []field {
+	byName := map[string][]int{}
+	for i := range fields {
+		byName[fields[i].name] = append(byName[fields[i].name], i)
+	}
+
+	ret := []field{}
+	for i := range fields {
+		if dominantField(fields, byName[fields[i].name]) == i {
+			ret = append(ret, fields[i])
+		}
+	}
+	return ret
+}
+
+// DominantField returns the index of the dominant field out of the given
+// fields with the same name, or -1 if there isn't one.
+func dominantField(fields []field, idxs []int) int {
+	depth := len(fields[idxs[0]].path)
+	for _, i := range idxs[1:] {
+		if len(fields[i].path) < depth {
+			depth = len(fields[i].path)
+		}
+	}
+
+	shallowest, tagged := -1, -1
+	nshallowest, ntagged := 0, 0
+	for _, i := range idxs {
+		if len(fields[i].path) != depth {
+			continue
+		}
+		shallowest = i
+		nshallowest++
+		if fields[i].tagged {
+			tagged = i
+			ntagged++
+		}
+	}
+
+	switch {
+	case nshallowest == 1:
+		return shallowest
+	case ntagged == 1:
+		return tagged
+	default:
+		return -1
+	}
+}
+
 // Visible returns true if the given StructField should show up in the output.
 func visible(sf *reflect.StructField) bool {
 	exported := sf.PkgPath == ""
@@ -102,21 +241,34 @@ func parseJSONTag(tag string) (string, string) {
 	return tag, ""
 }
 
-// OmitEmpty returns true if opts includes "omitempty".
-func omitEmpty(opts string) bool {
-	for opts != "" {
-		var o string
-
-		i := strings.Index(opts, ",")
-		if i >= 0 {
-			o, opts = opts[:i], opts[i+1:]
-		} else {
-			o, opts = opts, ""
+// Sid returns the symbol ID given by a "sid=N" option in opts, or SymbolIDUnknown
+// if there isn't a valid one.
+func sid(opts string) int64 {
+	for _, o := range tagOptions(opts) {
+		if strings.HasPrefix(o, "sid=") {
+			id, err := strconv.ParseInt(o[len("sid="):], 10, 64)
+			if err == nil && id >= 0 {
+				return id
+			}
 		}
+	}
+	return SymbolIDUnknown
+}
 
+// OmitEmpty returns true if opts includes "omitempty".
+func omitEmpty(opts string) bool {
+	for _, o := range tagOptions(opts) {
 		if o == "omitempty" {
 			return true
 		}
 	}
 	return false
 }
+
+// TagOptions splits the comma-separated options of a field tag.
+func tagOptions(opts string) []string {
+	if opts == "" {
+		return nil
+	}
+	return strings.Split(opts, ",")
+}
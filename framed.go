@@ -0,0 +1,134 @@
+package ion
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+)
+
+// A framedWriter writes each finished document as a separate binary Ion message,
+// prefixed with its length.
+type framedWriter struct {
+	Writer
+
+	out  io.Writer
+	buf  bytes.Buffer
+	opts []WriterOption
+}
+
+// NewFramedWriter creates a new Writer for message-oriented transports. Values are
+// written as binary Ion, and each call to Finish ends a message: the document written
+// since the previous call to Finish is written to out prefixed by its length as a
+// 4-byte big-endian integer. Each message is self-contained, starting with its own
+// binary version marker and local symbol table. Messages can be read back one at a
+// time with a FramedReader.
+func NewFramedWriter(out io.Writer, opts ...WriterOption) Writer {
+	w := &framedWriter{
+		out:  out,
+		opts: opts,
+	}
+	w.Writer = NewBinaryWriterOpts(&w.buf, nil, opts...)
+	return w
+}
+
+// Finish finishes the current message and writes it out.
+func (w *framedWriter) Finish() error {
+	if err := w.Writer.Finish(); err != nil {
+		return err
+	}
+
+	if uint64(w.buf.Len()) > math.MaxUint32 {
+		return &UsageError{"Writer.Finish", "message too long to frame"}
+	}
+
+	var size [4]byte
+	binary.BigEndian.PutUint32(size[:], uint32(w.buf.Len()))
+	if _, err := w.out.Write(size[:]); err != nil {
+		return err
+	}
+	if _, err := w.buf.WriteTo(w.out); err != nil {
+		return err
+	}
+
+	// Start afresh for the next message.
+	w.buf.Reset()
+	w.Writer = NewBinaryWriterOpts(&w.buf, nil, w.opts...)
+	return nil
+}
+
+// DefaultMaxFrameSize is the default maximum length of a message that a FramedReader
+// will read.
+const DefaultMaxFrameSize = 64 << 20
+
+// A FramedReader reads length-prefixed messages written by a Writer created with
+// NewFramedWriter.
+type FramedReader struct {
+	in      io.Reader
+	cat     Catalog
+	opts    []ReaderOption
+	pos     uint64
+	maxSize int
+}
+
+// NewFramedReader creates a new FramedReader.
+func NewFramedReader(in io.Reader, opts ...ReaderOption) *FramedReader {
+	return NewFramedReaderCat(in, nil, opts...)
+}
+
+// NewFramedReaderCat creates a new FramedReader with the given catalog.
+func NewFramedReaderCat(in io.Reader, cat Catalog, opts ...ReaderOption) *FramedReader {
+	return &FramedReader{
+		in:      in,
+		cat:     cat,
+		opts:    opts,
+		maxSize: DefaultMaxFrameSize,
+	}
+}
+
+// SetMaxFrameSize sets the maximum length in bytes of a message that Next will read.
+// For a message whose length prefix says it's any longer, Next returns a
+// SizeLimitError instead of trying to read it. It defaults to DefaultMaxFrameSize.
+// A value of zero or less means no limit.
+func (f *FramedReader) SetMaxFrameSize(n int) {
+	f.maxSize = n
+}
+
+// Next reads the next message in its entirety and returns a Reader over its values.
+// It returns io.EOF if there are no more messages.
+func (f *FramedReader) Next() (Reader, error) {
+	var size [4]byte
+	n, err := io.ReadFull(f.in, size[:])
+	if err == io.EOF {
+		return nil, io.EOF
+	}
+	if err := f.check(n, err); err != nil {
+		return nil, err
+	}
+
+	msgLen := uint64(binary.BigEndian.Uint32(size[:]))
+	if f.maxSize > 0 && msgLen > uint64(f.maxSize) {
+		return nil, &SizeLimitError{uint64(f.maxSize), msgLen, f.pos - 4}
+	}
+
+	msg, actual, err := readChunked(f.in, msgLen)
+	f.pos += actual
+	if err := f.check(0, err); err != nil {
+		return nil, err
+	}
+
+	return NewReaderCat(bytes.NewReader(msg), f.cat, f.opts...), nil
+}
+
+// Check accounts for n bytes having been read, translating any error.
+func (f *FramedReader) check(n int, err error) error {
+	f.pos += uint64(n)
+	switch err {
+	case nil:
+		return nil
+	case io.EOF, io.ErrUnexpectedEOF:
+		return &UnexpectedEOFError{f.pos}
+	default:
+		return &IOError{err}
+	}
+}
@@ -0,0 +1,127 @@
+package ion
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+	"testing"
+)
+
+func TestFramedMessages(t *testing.T) {
+	buf := bytes.Buffer{}
+	w := NewFramedWriter(&buf)
+
+	w.WriteSymbol("foo")
+	if err := w.Finish(); err != nil {
+		t.Fatal(err)
+	}
+
+	w.BeginStruct()
+	w.FieldName("bar")
+	w.WriteInt(42)
+	w.EndStruct()
+	if err := w.Finish(); err != nil {
+		t.Fatal(err)
+	}
+
+	w.WriteString("baz")
+	w.WriteSymbol("foo")
+	if err := w.Finish(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Each message is self-contained, so the first can be read on its own.
+	size := binary.BigEndian.Uint32(buf.Bytes())
+	_symbol(t, NewReaderBytes(buf.Bytes()[4:4+size]), "foo")
+
+	f := NewFramedReader(bytes.NewReader(buf.Bytes()))
+
+	r := _nextMessage(t, f)
+	_symbol(t, r, "foo")
+	_eof(t, r)
+
+	r = _nextMessage(t, f)
+	_struct(t, r, func(t *testing.T, r Reader) {
+		_intAF(t, r, "bar", nil, 42)
+		_eof(t, r)
+	})
+	_eof(t, r)
+
+	r = _nextMessage(t, f)
+	_string(t, r, "baz")
+	_symbol(t, r, "foo")
+	_eof(t, r)
+
+	if _, err := f.Next(); err != io.EOF {
+		t.Errorf("expected io.EOF, got %v", err)
+	}
+}
+
+func TestFramedReaderTruncated(t *testing.T) {
+	test := func(name string, data []byte) {
+		t.Run(name, func(t *testing.T) {
+			f := NewFramedReader(bytes.NewReader(data))
+			_, err := f.Next()
+			if _, ok := err.(*UnexpectedEOFError); !ok {
+				t.Errorf("expected UnexpectedEOFError, got %v", err)
+			}
+		})
+	}
+
+	test("size", []byte{0x00, 0x00})
+	test("message", []byte{0x00, 0x00, 0x00, 0x05, 0xE0, 0x01})
+}
+
+func TestFramedReaderMaxFrameSize(t *testing.T) {
+	// A message claiming to be 4GB long, followed by not nearly that much.
+	huge := []byte{0xFF, 0xFF, 0xFF, 0xFF, 0xE0, 0x01, 0x00, 0xEA}
+
+	f := NewFramedReader(bytes.NewReader(huge))
+	_, err := f.Next()
+	if e, ok := err.(*SizeLimitError); !ok {
+		t.Errorf("expected a SizeLimitError, got %v", err)
+	} else if e.Max != DefaultMaxFrameSize || e.Size != math.MaxUint32 || e.Offset != 0 {
+		t.Errorf("expected max %v, size %v and offset 0, got %+v", DefaultMaxFrameSize, uint32(math.MaxUint32), e)
+	}
+
+	// Without a limit it runs out of input, rather than memory, trying to read it.
+	f = NewFramedReader(bytes.NewReader(huge))
+	f.SetMaxFrameSize(0)
+	if _, err := f.Next(); err == nil {
+		t.Error("expected an error")
+	} else if _, ok := err.(*UnexpectedEOFError); !ok {
+		t.Errorf("expected an UnexpectedEOFError, got %v", err)
+	}
+
+	// Messages exactly at the limit are fine.
+	buf := bytes.Buffer{}
+	w := NewFramedWriter(&buf)
+	w.WriteInt(42)
+	if err := w.Finish(); err != nil {
+		t.Fatal(err)
+	}
+	size := buf.Len() - 4
+
+	f = NewFramedReader(bytes.NewReader(buf.Bytes()))
+	f.SetMaxFrameSize(size)
+	r := _nextMessage(t, f)
+	_int(t, r, 42)
+	_eof(t, r)
+
+	f = NewFramedReader(bytes.NewReader(buf.Bytes()))
+	f.SetMaxFrameSize(size - 1)
+	if _, err := f.Next(); err == nil {
+		t.Error("expected an error")
+	} else if _, ok := err.(*SizeLimitError); !ok {
+		t.Errorf("expected a SizeLimitError, got %v", err)
+	}
+}
+
+func _nextMessage(t *testing.T, f *FramedReader) Reader {
+	r, err := f.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
@@ -0,0 +1,37 @@
+//go:build go1.23
+// +build go1.23
+
+package ion
+
+import (
+	"io"
+	"iter"
+)
+
+// Values returns an iterator over the top-level values read from in, each read in
+// its entirety into a Value as it's reached:
+//
+//	for v, err := range ion.Values(in) {
+//		if err != nil {
+//			return err
+//		}
+//		// ...
+//	}
+//
+// Iteration stops after the last value. If reading fails, the error is yielded
+// along with a zero Value, and iteration stops after that. Values is only
+// available when building with Go 1.23 or later.
+func Values(in io.Reader, opts ...ReaderOption) iter.Seq2[Value, error] {
+	return func(yield func(Value, error) bool) {
+		r := NewReader(in, opts...)
+		for r.Next() {
+			v, err := ReadValue(r)
+			if !yield(v, err) || err != nil {
+				return
+			}
+		}
+		if err := r.Err(); err != nil {
+			yield(Value{}, err)
+		}
+	}
+}
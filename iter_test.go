@@ -0,0 +1,61 @@
+//go:build go1.23
+// +build go1.23
+
+package ion
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValues(t *testing.T) {
+	var vs []Value
+	for v, err := range Values(strings.NewReader(`a::1 "b" {c:[d]}`)) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		vs = append(vs, v)
+	}
+
+	if len(vs) != 3 {
+		t.Fatalf("expected 3 values, got %v", len(vs))
+	}
+	if vs[0].Type != IntType || vs[0].Scalar.(int64) != 1 || vs[0].Annotations[0] != "a" {
+		t.Errorf("unexpected value %+v", vs[0])
+	}
+	if vs[1].Type != StringType || vs[1].Scalar.(string) != "b" {
+		t.Errorf("unexpected value %+v", vs[1])
+	}
+	if vs[2].Type != StructType || len(vs[2].Children) != 1 || vs[2].Children[0].Children[0].Scalar != "d" {
+		t.Errorf("unexpected value %+v", vs[2])
+	}
+}
+
+func TestValuesError(t *testing.T) {
+	n := 0
+	var last error
+	for _, err := range Values(strings.NewReader(`1 2 [3`)) {
+		n++
+		last = err
+	}
+
+	if n != 3 {
+		t.Errorf("expected 3 iterations, got %v", n)
+	}
+	if last == nil {
+		t.Error("expected an error")
+	}
+}
+
+func TestValuesBreak(t *testing.T) {
+	n := 0
+	for range Values(strings.NewReader(`1 2 3`)) {
+		n++
+		if n == 2 {
+			break
+		}
+	}
+	if n != 2 {
+		t.Errorf("expected 2 iterations, got %v", n)
+	}
+}
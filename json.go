@@ -0,0 +1,295 @@
+package ion
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// FromJSON converts the stream of JSON values read from in to Ion, writing them to w
+// as it goes. Objects become structs, arrays become lists, and strings, booleans, and
+// null become their Ion equivalents.
+//
+// Numbers are mapped so that no precision is lost: integers, however large, become
+// ints, and numbers with a fraction or an exponent become decimals, which, unlike
+// floats, represent them exactly as written. So 42 becomes 42, and 1.10 and 1e400
+// become the decimals 1.10 and 1d400.
+//
+// FromJSON does not call Finish on w.
+func FromJSON(in io.Reader, w Writer) error {
+	dec := json.NewDecoder(in)
+	dec.UseNumber()
+
+	// Whether each of the containers we're in is an object, outermost first.
+	var objs []bool
+	key := false
+
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF && len(objs) == 0 {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if key {
+			// Either a key, which the decoder makes sure is a string, or the end
+			// of the object.
+			if name, ok := tok.(string); ok {
+				if err := w.FieldName(name); err != nil {
+					return err
+				}
+				key = false
+				continue
+			}
+		}
+
+		switch t := tok.(type) {
+		case json.Delim:
+			switch t {
+			case '{':
+				err = w.BeginStruct()
+				objs = append(objs, true)
+			case '[':
+				err = w.BeginList()
+				objs = append(objs, false)
+			case '}':
+				err = w.EndStruct()
+				objs = objs[:len(objs)-1]
+			case ']':
+				err = w.EndList()
+				objs = objs[:len(objs)-1]
+			}
+
+		case json.Number:
+			err = writeJSONNumber(w, t.String())
+
+		case string:
+			err = w.WriteString(t)
+
+		case bool:
+			err = w.WriteBool(t)
+
+		case nil:
+			err = w.WriteNull()
+		}
+		if err != nil {
+			return err
+		}
+
+		// A key comes next if we've just started an object, or finished one of its values.
+		key = len(objs) > 0 && objs[len(objs)-1]
+	}
+}
+
+// WriteJSONNumber writes a JSON number as an int if it's an integer, or a decimal
+// if not.
+func writeJSONNumber(w Writer, num string) error {
+	if !strings.ContainsAny(num, ".eE") {
+		i, err := parseInt(num, 10)
+		if err != nil {
+			return err
+		}
+		if bi, ok := i.(*big.Int); ok {
+			return w.WriteBigInt(bi)
+		}
+		return w.WriteInt(i.(int64))
+	}
+
+	// Ion writes exponents of decimals with a d.
+	d, err := ParseDecimal(strings.NewReplacer("e", "d", "E", "d").Replace(num))
+	if err != nil {
+		return err
+	}
+	return w.WriteDecimal(d)
+}
+
+// ToJSON down-converts the remaining values read from r to JSON, following the
+// usual rules for doing so, and writes them to out, one per line. Annotations are
+// dropped, as JSON has no equivalent.
+//
+//   - Nulls of any type become null, and bools become booleans.
+//   - Ints become numbers, as do floats, except for nan and the infinities,
+//     which become null.
+//   - Decimals become numbers if a float64 can represent them exactly, which is
+//     all most JSON parsers can manage, and strings of their JSON number form if
+//     not, so 1.5 becomes 1.5 and 1.0000000000000000000001 becomes
+//     "1.0000000000000000000001".
+//   - Timestamps become strings in Ion text form, such as "2019-08-04T18:15:43Z".
+//   - Symbols and strings become strings.
+//   - Blobs become strings of their base64 encoding, and clobs become strings with
+//     one character per byte, from U+0000 to U+00FF.
+//   - Structs become objects, and lists and sexps become arrays.
+func ToJSON(r Reader, out io.Writer) error {
+	for r.Next() {
+		if err := writeJSONValue(r, out); err != nil {
+			return err
+		}
+		if err := writeRawChar('\n', out); err != nil {
+			return err
+		}
+	}
+	return r.Err()
+}
+
+// WriteJSONValue writes the value the reader is positioned on as JSON.
+func writeJSONValue(r Reader, out io.Writer) error {
+	if r.IsNull() {
+		return writeRawString("null", out)
+	}
+
+	switch r.Type() {
+	case BoolType:
+		val, err := r.BoolValue()
+		if err != nil {
+			return err
+		}
+		return writeRawString(strconv.FormatBool(val), out)
+
+	case IntType:
+		val, err := r.BigIntValue()
+		if err != nil {
+			return err
+		}
+		return writeRawString(val.String(), out)
+
+	case FloatType:
+		val, err := r.FloatValue()
+		if err != nil {
+			return err
+		}
+		if math.IsNaN(val) || math.IsInf(val, 0) {
+			return writeRawString("null", out)
+		}
+		return writeRawString(strconv.FormatFloat(val, 'g', -1, 64), out)
+
+	case DecimalType:
+		val, err := r.DecimalValue()
+		if err != nil {
+			return err
+		}
+		return writeJSONDecimal(val, out)
+
+	case TimestampType:
+		val, err := r.TimeValue()
+		if err != nil {
+			return err
+		}
+		precision, err := r.TimestampPrecision()
+		if err != nil {
+			return err
+		}
+		return writeJSONString(formatTimestamp(val, precision), out)
+
+	case SymbolType, StringType:
+		val, err := r.StringValue()
+		if err != nil {
+			return err
+		}
+		return writeJSONString(val, out)
+
+	case BlobType:
+		val, err := r.ByteValue()
+		if err != nil {
+			return err
+		}
+		return writeJSONString(base64.StdEncoding.EncodeToString(val), out)
+
+	case ClobType:
+		val, err := r.ByteValue()
+		if err != nil {
+			return err
+		}
+		runes := make([]rune, len(val))
+		for i, b := range val {
+			runes[i] = rune(b)
+		}
+		return writeJSONString(string(runes), out)
+
+	case StructType:
+		return writeJSONContainer(r, out, '{', '}', true)
+
+	case ListType, SexpType:
+		return writeJSONContainer(r, out, '[', ']', false)
+	}
+
+	return &UsageError{"ToJSON", "reader is not positioned on a value"}
+}
+
+// WriteJSONContainer writes the contents of the container the reader is positioned
+// on as a JSON object or array.
+func writeJSONContainer(r Reader, out io.Writer, open, close byte, fields bool) error {
+	if err := r.StepIn(); err != nil {
+		return err
+	}
+	if err := writeRawChar(open, out); err != nil {
+		return err
+	}
+
+	for i := 0; r.Next(); i++ {
+		if i > 0 {
+			if err := writeRawChar(',', out); err != nil {
+				return err
+			}
+		}
+		if fields {
+			if err := writeJSONString(r.FieldName(), out); err != nil {
+				return err
+			}
+			if err := writeRawChar(':', out); err != nil {
+				return err
+			}
+		}
+		if err := writeJSONValue(r, out); err != nil {
+			return err
+		}
+	}
+	if err := r.Err(); err != nil {
+		return err
+	}
+
+	if err := writeRawChar(close, out); err != nil {
+		return err
+	}
+	return r.StepOut()
+}
+
+// WriteJSONDecimal writes a decimal as a JSON number if a float64 can represent it
+// exactly, or as a string containing that number if not.
+func writeJSONDecimal(val *Decimal, out io.Writer) error {
+	num := decimalNumber(val)
+
+	f, err := strconv.ParseFloat(num, 64)
+	if err == nil {
+		exp := strings.Replace(strconv.FormatFloat(f, 'e', -1, 64), "e", "d", 1)
+		if d, err := ParseDecimal(exp); err == nil && d.Equal(val) {
+			return writeRawString(num, out)
+		}
+	}
+	return writeJSONString(num, out)
+}
+
+// DecimalNumber formats a decimal as a number in the syntax shared by JSON and Go,
+// with any exponent marked by an e rather than a d.
+func decimalNumber(val *Decimal) string {
+	return strings.Replace(strings.TrimSuffix(val.String(), "."), "d", "e", 1)
+}
+
+// WriteJSONString writes a JSON string.
+func writeJSONString(val string, out io.Writer) error {
+	buf := bytes.Buffer{}
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(val); err != nil {
+		return err
+	}
+
+	// Encode adds a newline we don't want.
+	return writeRawChars(bytes.TrimSuffix(buf.Bytes(), []byte{'\n'}), out)
+}
@@ -0,0 +1,179 @@
+package ion
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestFromJSON(t *testing.T) {
+	test := func(in, expected string) {
+		t.Run(in, func(t *testing.T) {
+			buf := strings.Builder{}
+			w := NewTextWriter(&buf)
+			if err := FromJSON(strings.NewReader(in), w); err != nil {
+				t.Fatal(err)
+			}
+			if err := w.Finish(); err != nil {
+				t.Fatal(err)
+			}
+			if buf.String() != expected {
+				t.Errorf("expected %q, got %q", expected, buf.String())
+			}
+		})
+	}
+
+	test(``, "\n")
+	test(`null true "hi\u00e9"`, "null\ntrue\n\"hié\"\n")
+	test(`{"a": {"b": [1, {"c": null}, []], "d": {}}, "e": "f"}`, "{a:{b:[1,{c:null},[]],d:{}},e:\"f\"}\n")
+	test(`[{"a": 1}, {"b": 2}]`, "[{a:1},{b:2}]\n")
+	test(`{"null": 1, "a b": 2}`, "{'null':1,'a b':2}\n")
+
+	test(`[0, -12, 1.10, -0.5, 1e3, 2.5E-3]`, "[0,-12,1.10,-5d-1,1d3,2.5d-3]\n")
+	test(`123456789012345678901234567890`, "123456789012345678901234567890\n")
+	test(`1e400 -1.5e-400`, "1d400\n-1.5d-400\n")
+}
+
+func TestFromJSONRoundTrip(t *testing.T) {
+	in := `{"id": 18446744073709551616, "price": 12.50, "tags": ["a", "b"], "huge": 1e400}`
+
+	buf := strings.Builder{}
+	w := NewBinaryWriter(&buf)
+	if err := FromJSON(strings.NewReader(in), w); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Finish(); err != nil {
+		t.Fatal(err)
+	}
+
+	r := NewReaderStr(buf.String())
+	_struct(t, r, func(t *testing.T, r Reader) {
+		_nextAF(t, r, IntType, "id", nil)
+		if i, err := r.BigIntValue(); err != nil || i.String() != "18446744073709551616" {
+			t.Errorf("expected 18446744073709551616, got %v (%v)", i, err)
+		}
+		_decimalAF(t, r, "price", nil, MustParseDecimal("12.50"))
+		_nextAF(t, r, ListType, "tags", nil)
+		_nextAF(t, r, DecimalType, "huge", nil)
+		if d, err := r.DecimalValue(); err != nil || d.String() != "1d400" {
+			t.Errorf("expected 1d400, got %v (%v)", d, err)
+		}
+		_eof(t, r)
+	})
+	_eof(t, r)
+}
+
+func TestFromJSONErrors(t *testing.T) {
+	test := func(in string) {
+		t.Run(in, func(t *testing.T) {
+			buf := strings.Builder{}
+			if err := FromJSON(strings.NewReader(in), NewTextWriter(&buf)); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+
+	test(`{"a": 1`)
+	test(`[1, 2`)
+	test(`{"a" 1}`)
+	test(`{1: 2}`)
+	test(`[1,]`)
+	test(`nope`)
+}
+
+func TestToJSON(t *testing.T) {
+	test := func(in, expected string) {
+		t.Run(in, func(t *testing.T) {
+			buf := strings.Builder{}
+			if err := ToJSON(NewReaderStr(in), &buf); err != nil {
+				t.Fatal(err)
+			}
+			if buf.String() != expected+"\n" {
+				t.Errorf("expected %q, got %q", expected+"\n", buf.String())
+			}
+		})
+	}
+
+	test("null", "null")
+	test("null.int", "null")
+	test("null.struct", "null")
+	test("true", "true")
+	test("a::false", "false")
+
+	test("42", "42")
+	test("-0x10", "-16")
+	test("123456789012345678901234567890", "123456789012345678901234567890")
+
+	test("1.5e0", "1.5")
+	test("-2.5e-10", "-2.5e-10")
+	test("1e21", "1e+21")
+	test("nan", "null")
+	test("+inf", "null")
+	test("-inf", "null")
+
+	test("1.5", "1.5")
+	test("12.50", "12.50")
+	test("1000.", "1000")
+	test("-5d-2", "-5e-2")
+	test("1d400", `"1e400"`)
+	test("1.0000000000000000000001", `"1.0000000000000000000001"`)
+
+	test("2019-08-04T18:15:43.863494+10:00", `"2019-08-04T18:15:43.863494+10:00"`)
+	test("2019-08T", `"2019-08T"`)
+
+	test("sym", `"sym"`)
+	test(`'<a & b>'`, `"<a & b>"`)
+	test(`"tab\t\"quote\"\u00e9"`, `"tab\t\"quote\"é"`)
+	test("{{aGk=}}", `"aGk="`)
+	test(`{{"hi\x01"}}`, `"hi\u0001"`)
+
+	test("{}", "{}")
+	test("[]", "[]")
+	test("()", "[]")
+	test(`{a:1, 'b c':[x::2, (+ 3 "four"), {d:null.list}], a:5}`, `{"a":1,"b c":[2,["+",3,"four"],{"d":null}],"a":5}`)
+}
+
+func TestToJSONClob(t *testing.T) {
+	bin := bytes.Buffer{}
+	w := NewBinaryWriter(&bin)
+	w.WriteClob([]byte{'h', 'i', 0x7F, 0x80, 0xFF})
+	if err := w.Finish(); err != nil {
+		t.Fatal(err)
+	}
+
+	buf := strings.Builder{}
+	if err := ToJSON(NewReaderBytes(bin.Bytes()), &buf); err != nil {
+		t.Fatal(err)
+	}
+
+	// One character per byte, not UTF-8.
+	expected := "\"hi\u007f\u0080\u00ff\"\n"
+	if buf.String() != expected {
+		t.Errorf("expected %q, got %q", expected, buf.String())
+	}
+}
+
+func TestToJSONMultipleValues(t *testing.T) {
+	buf := strings.Builder{}
+	if err := ToJSON(NewReaderStr(`1 {a:b} [c]`), &buf); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "1\n{\"a\":\"b\"}\n[\"c\"]\n"
+	if buf.String() != expected {
+		t.Errorf("expected %q, got %q", expected, buf.String())
+	}
+
+	// And it's JSON that FromJSON can read back.
+	out := strings.Builder{}
+	w := NewTextWriter(&out)
+	if err := FromJSON(strings.NewReader(buf.String()), w); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Finish(); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "1\n{a:\"b\"}\n[\"c\"]\n" {
+		t.Errorf("unexpected round trip: %q", out.String())
+	}
+}
@@ -2,11 +2,16 @@ package ion
 
 import (
 	"bytes"
+	"database/sql/driver"
+	"encoding"
 	"fmt"
 	"io"
+	"math"
 	"math/big"
 	"reflect"
 	"sort"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -16,12 +21,64 @@ type EncoderOpts uint
 const (
 	// EncodeSortMaps instructs the encoder to write map keys in sorted order.
 	EncodeSortMaps EncoderOpts = 1
+
+	// EncodeFloatAsDecimal instructs the encoder to write float32 and float64 values
+	// as decimals instead of floats. Each is written as the decimal with the fewest
+	// digits that converts back to exactly the same float, so 0.1 is written as the
+	// decimal 0.1 rather than the exact value of its binary approximation,
+	// 0.1000000000000000055511151231257827... That is to say, the decimal carries as
+	// much precision as the float does, and no more. NaN and infinities have no
+	// decimal representation, and are still written as floats.
+	EncodeFloatAsDecimal EncoderOpts = 2
+
+	// EncodeDurationAsString instructs the encoder to write time.Duration values as
+	// strings in the form returned by their String method, such as "1h2m3.5s",
+	// instead of as plain int counts of nanoseconds, which is the default. Any of
+	// the forms decodes back to a time.Duration.
+	EncodeDurationAsString EncoderOpts = 4
+
+	// EncodeEnumsAsStrings instructs the encoder to write values of named integer
+	// types that implement fmt.Stringer, as enums declared like
+	//
+	//	type Color int
+	//
+	//	const (
+	//		Red Color = iota
+	//		Green
+	//	)
+	//
+	// usually do, as strings in the form returned by their String method, such as
+	// "Green", instead of as their underlying ints, which is the default. The Decoder
+	// can decode them back given a parse function registered with RegisterEnum.
+	EncodeEnumsAsStrings EncoderOpts = 8
+
+	// EncodeDurationAnnotated instructs the encoder to annotate the ints it writes
+	// for time.Duration values with DurationAnnotation, marking them as durations for
+	// readers that don't know the Go type. EncodeDurationAsString takes precedence.
+	EncodeDurationAnnotated EncoderOpts = 16
 )
 
+// DurationAnnotation is the annotation an Encoder given EncodeDurationAnnotated
+// writes on a time.Duration, which is otherwise written as a plain int count of
+// nanoseconds: so a one-second duration is written as duration::1000000000.
+const DurationAnnotation = "duration"
+
 // MarshalText marshals values to text ion.
 func MarshalText(v interface{}) ([]byte, error) {
+	return marshalText(v)
+}
+
+// MarshalTextIndent is like MarshalText, but pretty-prints its output: each value
+// inside a container goes on its own line, indented by one copy of indent per
+// level of nesting. As with WithIndent, the indent must be Ion whitespace.
+func MarshalTextIndent(v interface{}, indent string) ([]byte, error) {
+	return marshalText(v, WithIndent(indent))
+}
+
+// marshalText marshals values to text ion with the given writer options.
+func marshalText(v interface{}, wopts ...WriterOption) ([]byte, error) {
 	buf := bytes.Buffer{}
-	w := NewTextWriterOpts(&buf, TextWriterQuietFinish)
+	w := NewTextWriterOpts(&buf, TextWriterQuietFinish, wopts...)
 	e := Encoder{
 		w:    w,
 		opts: EncodeSortMaps,
@@ -69,6 +126,26 @@ func MarshalBinaryLST(v interface{}, lst SymbolTable) ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
+// MarshalSmallest marshals values to both text and binary ion, returning whichever
+// is smaller along with its format. Binary is generally more compact, particularly
+// for documents that repeat the same symbols, but its version marker and symbol
+// table make it the larger of the two for small documents. Ties go to text.
+func MarshalSmallest(v interface{}, ssts ...SharedSymbolTable) ([]byte, Format, error) {
+	bin, err := MarshalBinary(v, ssts...)
+	if err != nil {
+		return nil, FormatBinary, err
+	}
+	text, err := MarshalText(v)
+	if err != nil {
+		return nil, FormatText, err
+	}
+
+	if len(bin) < len(text) {
+		return bin, FormatBinary, nil
+	}
+	return text, FormatText, nil
+}
+
 // MarshalTo marshals the given value to the given writer. It does
 // not call Finish, so is suitable for encoding values inside of
 // a partially-constructed Ion value.
@@ -81,8 +158,9 @@ func MarshalTo(w Writer, v interface{}) error {
 
 // An Encoder writes Ion values to an output stream.
 type Encoder struct {
-	w    Writer
-	opts EncoderOpts
+	w     Writer
+	opts  EncoderOpts
+	names fieldNamer
 }
 
 // NewEncoder creates a new encoder.
@@ -113,6 +191,14 @@ func NewBinaryEncoderLST(w io.Writer, lst SymbolTable) *Encoder {
 	return NewEncoder(NewBinaryWriterLST(w, lst))
 }
 
+// SetFieldNameMapper sets a function that the names of struct fields without a name
+// given by a tag are passed through before being written, such as SnakeCase, for
+// APIs whose conventions differ from Go's. Decode such values with a Decoder given
+// the same mapper. Map keys are written as they are.
+func (m *Encoder) SetFieldNameMapper(mapper func(string) string) {
+	m.names.setMapper(mapper)
+}
+
 // Encode marshals the given value to Ion, writing it to the underlying writer.
 func (m *Encoder) Encode(v interface{}) error {
 	return m.encodeValue(reflect.ValueOf(v))
@@ -131,6 +217,39 @@ func (m *Encoder) encodeValue(v reflect.Value) error {
 	}
 
 	t := v.Type()
+	if !isIonNative(t) {
+		if t.Implements(valuerType) {
+			return m.encodeValuer(v)
+		}
+		if t.Kind() != reflect.Ptr && v.CanAddr() && reflect.PtrTo(t).Implements(valuerType) {
+			return m.encodeValuer(v.Addr())
+		}
+		if t.Implements(textMarshalerType) {
+			return m.encodeTextMarshaler(v)
+		}
+		if t.Kind() != reflect.Ptr && v.CanAddr() && reflect.PtrTo(t).Implements(textMarshalerType) {
+			return m.encodeTextMarshaler(v.Addr())
+		}
+	}
+
+	if t == jsonNumberType {
+		return m.encodeJSONNumber(v)
+	}
+	if t == durationType {
+		return m.encodeDuration(v)
+	}
+	if m.opts&EncodeEnumsAsStrings != 0 && isEnum(t) {
+		if t.Implements(stringerType) {
+			return m.encodeStringer(v)
+		}
+		if v.CanAddr() && reflect.PtrTo(t).Implements(stringerType) {
+			return m.encodeStringer(v.Addr())
+		}
+	}
+	if t == orderedMapType {
+		return m.encodeOrderedMap(v)
+	}
+
 	switch t.Kind() {
 	case reflect.Bool:
 		return m.w.WriteBool(v.Bool())
@@ -147,7 +266,7 @@ func (m *Encoder) encodeValue(v reflect.Value) error {
 		return m.w.WriteBigInt(&i)
 
 	case reflect.Float32, reflect.Float64:
-		return m.w.WriteFloat(v.Float())
+		return m.encodeFloat(v)
 
 	case reflect.String:
 		return m.w.WriteString(v.String())
@@ -205,6 +324,24 @@ func (m *Encoder) encodeMap(v reflect.Value) error {
 	return m.w.EndStruct()
 }
 
+// EncodeOrderedMap encodes an OrderedMap as an Ion struct, with its fields in order.
+func (m *Encoder) encodeOrderedMap(v reflect.Value) error {
+	if v.IsNil() {
+		return m.w.WriteNull()
+	}
+
+	m.w.BeginStruct()
+
+	for _, kv := range v.Interface().(OrderedMap) {
+		m.w.FieldName(kv.Key)
+		if err := m.encodeValue(reflect.ValueOf(kv.Value)); err != nil {
+			return err
+		}
+	}
+
+	return m.w.EndStruct()
+}
+
 // A mapkey holds the reflective map key value as well as its stringified form.
 type mapkey struct {
 	v reflect.Value
@@ -251,8 +388,17 @@ func (m *Encoder) encodeBlob(v reflect.Value) error {
 	return m.w.WriteBlob(v.Bytes())
 }
 
-// EncodeArray encodes an array to the output writer as an Ion list.
+// EncodeArray encodes an array to the output writer as an Ion list, or
+// as an Ion blob if it's an array of bytes, such as a hash or UUID.
 func (m *Encoder) encodeArray(v reflect.Value) error {
+	if v.Kind() == reflect.Array && v.Type().Elem().Kind() == reflect.Uint8 {
+		b := make([]byte, v.Len())
+		for i := range b {
+			b[i] = byte(v.Index(i).Uint())
+		}
+		return m.w.WriteBlob(b)
+	}
+
 	m.w.BeginList()
 
 	for i := 0; i < v.Len(); i++ {
@@ -273,8 +419,12 @@ func (m *Encoder) encodeStruct(v reflect.Value) error {
 	if t == decimalType {
 		return m.encodeDecimal(v)
 	}
+	if t == bigIntType {
+		i := v.Interface().(big.Int)
+		return m.w.WriteBigInt(&i)
+	}
 
-	fields := fieldsFor(v.Type())
+	fields := m.names.fieldsFor(v.Type())
 
 	m.w.BeginStruct()
 
@@ -306,6 +456,118 @@ FieldLoop:
 	return m.w.EndStruct()
 }
 
+// EncodeTextMarshaler encodes a value implementing encoding.TextMarshaler to the
+// output writer as an Ion string.
+func (m *Encoder) encodeTextMarshaler(v reflect.Value) error {
+	if (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) && v.IsNil() {
+		return m.w.WriteNull()
+	}
+
+	text, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+	if err != nil {
+		return err
+	}
+	return m.w.WriteString(string(text))
+}
+
+// EncodeJSONNumber encodes a json.Number as an int if it's an integer, or as a
+// decimal if not, so it loses no precision either way.
+func (m *Encoder) encodeJSONNumber(v reflect.Value) error {
+	num := v.String()
+	if num == "" {
+		// As encoding/json does.
+		num = "0"
+	}
+	return writeJSONNumber(m.w, num)
+}
+
+// EncodeDuration encodes a time.Duration as an int, annotated or not, or as a string,
+// as the encoder is configured.
+func (m *Encoder) encodeDuration(v reflect.Value) error {
+	d := time.Duration(v.Int())
+	if m.opts&EncodeDurationAsString != 0 {
+		return m.w.WriteString(d.String())
+	}
+	if m.opts&EncodeDurationAnnotated != 0 {
+		m.w.Annotation(DurationAnnotation)
+	}
+	return m.w.WriteInt(int64(d))
+}
+
+// EncodeStringer encodes an enum value implementing fmt.Stringer as a string.
+func (m *Encoder) encodeStringer(v reflect.Value) error {
+	return m.w.WriteString(v.Interface().(fmt.Stringer).String())
+}
+
+// EncodeFloat encodes a float, as a decimal if the encoder is so configured.
+func (m *Encoder) encodeFloat(v reflect.Value) error {
+	f := v.Float()
+	if m.opts&EncodeFloatAsDecimal == 0 || math.IsNaN(f) || math.IsInf(f, 0) {
+		return m.w.WriteFloat(f)
+	}
+
+	str := strconv.FormatFloat(f, 'e', -1, v.Type().Bits())
+	d, err := ParseDecimal(strings.Replace(str, "e", "d", 1))
+	if err != nil {
+		return err
+	}
+	return m.w.WriteDecimal(d)
+}
+
+// EncodeValuer encodes a driver.Valuer, such as an sql.NullString, as the value
+// it returns. A nil value is encoded as a typed null where possible.
+func (m *Encoder) encodeValuer(v reflect.Value) error {
+	if (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) && v.IsNil() {
+		return m.encodeValuerNull(v.Type())
+	}
+
+	val, err := v.Interface().(driver.Valuer).Value()
+	if err != nil {
+		return err
+	}
+	if val == nil {
+		return m.encodeValuerNull(v.Type())
+	}
+	return m.encodeValue(reflect.ValueOf(val))
+}
+
+// EncodeValuerNull encodes a null for a driver.Valuer of the given type. The
+// sql.Null* types are structs holding a value followed by a Valid flag, so the
+// type of null is based on the type of that value.
+func (m *Encoder) encodeValuerNull(t reflect.Type) error {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() == reflect.Struct && t.NumField() == 2 && t.Field(1).Name == "Valid" {
+		t = t.Field(0).Type
+	}
+
+	if t == timeType {
+		return m.w.WriteNullType(TimestampType)
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return m.w.WriteNullType(BoolType)
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return m.w.WriteNullType(IntType)
+
+	case reflect.Float32, reflect.Float64:
+		return m.w.WriteNullType(FloatType)
+
+	case reflect.String:
+		return m.w.WriteNullType(StringType)
+
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return m.w.WriteNullType(BlobType)
+		}
+	}
+	return m.w.WriteNull()
+}
+
 // EncodeTime encodes a time.Time to the output writer as an Ion timestamp.
 func (m *Encoder) encodeTime(v reflect.Value) error {
 	t := v.Interface().(time.Time)
@@ -2,7 +2,14 @@ package ion
 
 import (
 	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
 	"math"
+	"math/big"
+	"net"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
 )
@@ -63,7 +70,376 @@ func TestMarshalText(t *testing.T) {
 	test(struct{ V []byte }{}, "{V:null}")
 	test(struct{ V []byte }{[]byte{4, 2}}, "{V:{{BAI=}}}")
 
-	test(struct{ V [2]byte }{[2]byte{4, 2}}, "{V:[4,2]}")
+	test(struct{ V [2]byte }{[2]byte{4, 2}}, "{V:{{BAI=}}}")
+	test(struct{ V [2]int8 }{[2]int8{4, 2}}, "{V:[4,2]}")
+
+	test(big.NewInt(42), "42")
+	test(struct{ V big.Int }{*big.NewInt(-42)}, "{V:-42}")
+}
+
+func TestMarshalTextIndent(t *testing.T) {
+	type inner struct {
+		Name string   `json:"name"`
+		Tags []string `json:"tags"`
+	}
+	v := struct {
+		ID    int               `json:"id"`
+		Inner inner             `json:"inner"`
+		Empty []int             `json:"empty"`
+		Attrs map[string]string `json:"attrs"`
+	}{
+		ID:    1,
+		Inner: inner{"it's \"quoted\"", []string{"a", "b"}},
+		Empty: []int{},
+		Attrs: map[string]string{"a b": "c", "null": "d"},
+	}
+
+	val, err := MarshalTextIndent(v, "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	eval := `{
+  id:1,
+  inner:{
+    name:"it's \"quoted\"",
+    tags:[
+      "a",
+      "b"
+    ]
+  },
+  empty:[],
+  attrs:{
+    'a b':"c",
+    'null':"d"
+  }
+}`
+	if string(val) != eval {
+		t.Errorf("expected:\n%v\ngot:\n%v", eval, string(val))
+	}
+
+	// It's the same value MarshalText produces, just prettier.
+	flat, err := MarshalText(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	eq, err := Equal(val, flat)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !eq {
+		t.Errorf("expected %v to be equivalent to %v", string(val), string(flat))
+	}
+
+	if _, err := MarshalTextIndent(v, "--"); err == nil {
+		t.Error("expected an error for a non-whitespace indent")
+	}
+}
+
+type textPoint struct {
+	X, Y int
+}
+
+func (p textPoint) MarshalText() ([]byte, error) {
+	return []byte(fmt.Sprintf("%v,%v", p.X, p.Y)), nil
+}
+
+func (p *textPoint) UnmarshalText(text []byte) error {
+	_, err := fmt.Sscanf(string(text), "%d,%d", &p.X, &p.Y)
+	return err
+}
+
+func TestMarshalTextMarshaler(t *testing.T) {
+	test := func(v interface{}, eval string) {
+		t.Run(eval, func(t *testing.T) {
+			val, err := MarshalText(v)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(val) != eval {
+				t.Errorf("expected '%v', got '%v'", eval, string(val))
+			}
+		})
+	}
+
+	test(net.ParseIP("192.168.0.1"), "\"192.168.0.1\"")
+	test(textPoint{1, 2}, "\"1,2\"")
+	test(&textPoint{3, 4}, "\"3,4\"")
+	test(struct{ P *textPoint }{}, "{P:null}")
+	test(struct {
+		IP net.IP
+		P  textPoint
+	}{net.IPv4(10, 0, 0, 1), textPoint{5, 6}}, "{IP:\"10.0.0.1\",P:\"5,6\"}")
+
+	// Ion-native types take precedence over their MarshalText methods.
+	test(time.Date(2010, 1, 1, 0, 0, 0, 0, time.UTC), "2010-01-01T00:00:00Z")
+}
+
+func TestMarshalSQLNulls(t *testing.T) {
+	test := func(v interface{}, eval string) {
+		t.Run(eval, func(t *testing.T) {
+			val, err := MarshalText(v)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(val) != eval {
+				t.Errorf("expected '%v', got '%v'", eval, string(val))
+			}
+		})
+	}
+
+	test(sql.NullInt64{}, "null.int")
+	test(sql.NullInt64{Int64: 5, Valid: true}, "5")
+	test(sql.NullString{}, "null.string")
+	test(sql.NullString{String: "hi", Valid: true}, "\"hi\"")
+	test(sql.NullFloat64{}, "null.float")
+	test(sql.NullFloat64{Float64: 1.5, Valid: true}, "1.5e+0")
+	test(sql.NullBool{}, "null.bool")
+	test(sql.NullBool{Bool: true, Valid: true}, "true")
+
+	test(struct {
+		A sql.NullInt64
+		B *sql.NullString
+		C *sql.NullString
+	}{C: &sql.NullString{String: "c", Valid: true}}, "{A:null.int,B:null.string,C:\"c\"}")
+}
+
+func TestMarshalFloatAsDecimal(t *testing.T) {
+	test := func(v interface{}, eval string) {
+		t.Run(eval, func(t *testing.T) {
+			buf := strings.Builder{}
+			e := NewEncoderOpts(NewTextWriterOpts(&buf, TextWriterQuietFinish), EncodeFloatAsDecimal)
+			if err := e.Encode(v); err != nil {
+				t.Fatal(err)
+			}
+			if err := e.Finish(); err != nil {
+				t.Fatal(err)
+			}
+			if buf.String() != eval {
+				t.Errorf("expected '%v', got '%v'", eval, buf.String())
+			}
+		})
+	}
+
+	test(0.1, "1d-1") // Which is to say, 0.1.
+	test(float32(0.1), "1d-1")
+	test(-2.5e-10, "-2.5d-10")
+	test(1e21, "1d21")
+	test(0.0, "0.")
+	test(math.Copysign(0, -1), "-0.")
+	test(math.Inf(1), "+inf")
+	test(math.NaN(), "nan")
+	test(struct{ A float64 }{1.25}, "{A:1.25}")
+
+	// Without the option, they're floats as usual.
+	val, err := MarshalText(0.1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(val) != "1e-1" {
+		t.Errorf("expected '1e-1', got '%v'", string(val))
+	}
+}
+
+type color int
+
+const (
+	red color = iota
+	green
+	blue
+)
+
+var colorNames = []string{"red", "green", "blue"}
+
+func (c color) String() string {
+	if c < 0 || int(c) >= len(colorNames) {
+		return fmt.Sprintf("color(%d)", int(c))
+	}
+	return colorNames[c]
+}
+
+func parseColor(s string) (interface{}, error) {
+	for i, name := range colorNames {
+		if s == name {
+			return color(i), nil
+		}
+	}
+	return nil, fmt.Errorf("no color %q", s)
+}
+
+func TestMarshalEnum(t *testing.T) {
+	type paint struct {
+		Color  color
+		Colors []color
+		Count  int
+	}
+	in := paint{green, []color{blue, red}, 2}
+
+	test := func(opts EncoderOpts, eval string) {
+		t.Run(eval, func(t *testing.T) {
+			buf := strings.Builder{}
+			e := NewEncoderOpts(NewTextWriterOpts(&buf, TextWriterQuietFinish), opts)
+			if err := e.Encode(in); err != nil {
+				t.Fatal(err)
+			}
+			if err := e.Finish(); err != nil {
+				t.Fatal(err)
+			}
+			if buf.String() != eval {
+				t.Errorf("expected '%v', got '%v'", eval, buf.String())
+			}
+
+			var out paint
+			d := NewDecoder(NewReaderStr(buf.String()))
+			d.RegisterEnum(red, parseColor)
+			if err := d.DecodeTo(&out); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(out, in) {
+				t.Errorf("expected %v, got %v", in, out)
+			}
+		})
+	}
+
+	test(0, "{Color:1,Colors:[2,0],Count:2}")
+	test(EncodeEnumsAsStrings, `{Color:"green",Colors:["blue","red"],Count:2}`)
+}
+
+func TestDecodeEnumErrors(t *testing.T) {
+	test := func(data string, parse func(string) (interface{}, error)) {
+		t.Run(data, func(t *testing.T) {
+			var v color
+			d := NewDecoder(NewReaderStr(data))
+			if parse != nil {
+				d.RegisterEnum(red, parse)
+			}
+			if err := d.DecodeTo(&v); err == nil {
+				t.Errorf("expected an error, got %v", v)
+			}
+		})
+	}
+
+	test(`"green"`, nil)
+	test(`"purple"`, parseColor)
+	test(`"green"`, func(string) (interface{}, error) { return 1, nil })
+	test(`"green"`, func(string) (interface{}, error) { return nil, nil })
+}
+
+func TestMarshalDuration(t *testing.T) {
+	type timeout struct {
+		After time.Duration
+	}
+	in := timeout{90*time.Minute + 500*time.Millisecond}
+
+	test := func(opts EncoderOpts, eval string) {
+		t.Run(eval, func(t *testing.T) {
+			buf := strings.Builder{}
+			e := NewEncoderOpts(NewTextWriterOpts(&buf, TextWriterQuietFinish), opts)
+			if err := e.Encode(in); err != nil {
+				t.Fatal(err)
+			}
+			if err := e.Finish(); err != nil {
+				t.Fatal(err)
+			}
+			if buf.String() != eval {
+				t.Errorf("expected '%v', got '%v'", eval, buf.String())
+			}
+
+			var out timeout
+			if err := UnmarshalStr(buf.String(), &out); err != nil {
+				t.Fatal(err)
+			}
+			if out != in {
+				t.Errorf("expected %v, got %v", in, out)
+			}
+		})
+	}
+
+	test(0, "{After:5400500000000}")
+	test(EncodeDurationAnnotated, "{After:duration::5400500000000}")
+	test(EncodeDurationAsString, `{After:"1h30m0.5s"}`)
+	test(EncodeDurationAsString|EncodeDurationAnnotated, `{After:"1h30m0.5s"}`)
+
+	// And through binary.
+	bin, err := MarshalBinary(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out timeout
+	if err := Unmarshal(bin, &out); err != nil {
+		t.Fatal(err)
+	}
+	if out != in {
+		t.Errorf("expected %v, got %v", in, out)
+	}
+}
+
+func TestMarshalJSONNumber(t *testing.T) {
+	test := func(v interface{}, eval string) {
+		t.Run(eval, func(t *testing.T) {
+			val, err := MarshalText(v)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(val) != eval {
+				t.Errorf("expected '%v', got '%v'", eval, string(val))
+			}
+		})
+	}
+
+	test(json.Number("123"), "123")
+	test(json.Number("-123456789012345678901234567890"), "-123456789012345678901234567890")
+	test(json.Number("1.23"), "1.23")
+	test(json.Number("1.10e-3"), "1.10d-3")
+	test(json.Number(""), "0")
+	test(struct {
+		A json.Number
+		B *json.Number
+	}{A: "0.1"}, "{A:1d-1,B:null}")
+
+	// It really is an int and a decimal, not strings or floats.
+	val, err := MarshalText([]json.Number{"123", "1.23"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := NewReaderBytes(val)
+	_list(t, r, func(t *testing.T, r Reader) {
+		_int(t, r, 123)
+		_decimal(t, r, MustParseDecimal("1.23"))
+	})
+
+	if _, err := MarshalText(json.Number("12abc")); err == nil {
+		t.Error("expected an error for an invalid json.Number")
+	}
+}
+
+func TestMarshalSmallest(t *testing.T) {
+	test := func(v interface{}, eformat Format) {
+		t.Run(eformat.String(), func(t *testing.T) {
+			val, format, err := MarshalSmallest(v)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if format != eformat {
+				t.Errorf("expected %v, got %v", eformat, format)
+			}
+			if enc := NewReaderBytes(val).Encoding(); enc != format {
+				t.Errorf("expected %v to be %v, got %v", val, format, enc)
+			}
+		})
+	}
+
+	type record struct {
+		Name      string
+		Timestamp int64
+		Kind      string
+	}
+	records := make([]record, 20)
+	for i := range records {
+		records[i] = record{"record", int64(i) * 1000000, "kind"}
+	}
+	test(records, FormatBinary)
+	test(1, FormatText)
 }
 
 func TestMarshalBinary(t *testing.T) {
@@ -160,3 +536,178 @@ func TestMarshalNestedStructs(t *testing.T) {
 		t.Errorf("expected %v, got %v", eval, string(val))
 	}
 }
+
+func TestMarshalShadowedStructs(t *testing.T) {
+	type root struct {
+		PromotedMid
+		PromotedUntagged
+		PromotedOther
+		Shadow string `json:"s"`
+	}
+
+	v := root{
+		PromotedMid: PromotedMid{
+			PromotedInner: PromotedInner{X: 1, Shadow: 2},
+			Y:             3,
+			Shadow:        4,
+		},
+		PromotedUntagged: PromotedUntagged{T: 5, U: 6},
+		PromotedOther:    PromotedOther{U: 7},
+		Shadow:           "hi",
+	}
+
+	val, err := MarshalText(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	eval := "{x:1,y:3,s2:4,T:5,s:\"hi\"}"
+	if string(val) != eval {
+		t.Errorf("expected %v, got %v", eval, string(val))
+	}
+}
+
+func TestMarshalStructFieldOrder(t *testing.T) {
+	// Struct fields come out in declaration order, not sorted like map keys, even
+	// with EncodeSortMaps on, as it is for MarshalText.
+	type record struct {
+		Zulu    int                `json:"zulu"`
+		Alpha   string             `json:"alpha"`
+		Mike    bool               `json:"mike"`
+		Charlie map[string]int     `json:"charlie"`
+		Bravo   struct{ Y, X int } `json:"bravo"`
+	}
+	v := record{
+		Zulu:    1,
+		Alpha:   "a",
+		Mike:    true,
+		Charlie: map[string]int{"z": 1, "a": 2},
+	}
+	v.Bravo.Y, v.Bravo.X = 3, 4
+
+	text, err := MarshalText(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	eval := `{zulu:1,alpha:"a",mike:true,charlie:{a:2,z:1},bravo:{Y:3,X:4}}`
+	if string(text) != eval {
+		t.Errorf("expected %v, got %v", eval, string(text))
+	}
+
+	bin, err := MarshalBinary(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := NewReaderBytes(bin)
+	_next(t, r, StructType)
+	r.StepIn()
+	var names []string
+	for r.Next() {
+		names = append(names, r.FieldName())
+	}
+	if enames := []string{"zulu", "alpha", "mike", "charlie", "bravo"}; !reflect.DeepEqual(names, enames) {
+		t.Errorf("expected %v, got %v", enames, names)
+	}
+}
+
+func TestMarshalFieldNameMapper(t *testing.T) {
+	type account struct {
+		UserID     int
+		FirstName  string
+		HTTPServer string
+		Nickname   string `json:"NickName"`
+		Tags       map[string]int
+	}
+	in := account{
+		UserID:     7,
+		FirstName:  "Ada",
+		HTTPServer: "example.com",
+		Nickname:   "ada",
+		Tags:       map[string]int{"MixedCase": 1},
+	}
+
+	buf := strings.Builder{}
+	e := NewEncoderOpts(NewTextWriterOpts(&buf, TextWriterQuietFinish), EncodeSortMaps)
+	e.SetFieldNameMapper(SnakeCase)
+	if err := e.Encode(in); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.Finish(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Tagged fields and map keys are left alone.
+	eval := `{user_id:7,first_name:"Ada",http_server:"example.com",NickName:"ada",tags:{MixedCase:1}}`
+	if buf.String() != eval {
+		t.Fatalf("expected %v, got %v", eval, buf.String())
+	}
+
+	var out account
+	d := NewDecoder(NewReaderStr(buf.String()))
+	d.SetFieldNameMapper(SnakeCase)
+	if err := d.DecodeTo(&out); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(out, in) {
+		t.Errorf("expected %+v, got %+v", in, out)
+	}
+
+	// Without the mapper, the snake_case fields don't match.
+	out = account{}
+	if err := UnmarshalStr(buf.String(), &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.UserID != 0 || out.Nickname != "ada" {
+		t.Errorf("expected only the tagged field, got %+v", out)
+	}
+}
+
+func TestMarshalFieldNameMapperCollision(t *testing.T) {
+	// Mapped to the same name, the tagged field wins, as if the untagged one had
+	// been named user_name to begin with.
+	type user struct {
+		UserName string
+		Other    string `json:"user_name"`
+	}
+
+	buf := strings.Builder{}
+	e := NewEncoder(NewTextWriterOpts(&buf, TextWriterQuietFinish))
+	e.SetFieldNameMapper(SnakeCase)
+	if err := e.Encode(user{"a", "b"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.Finish(); err != nil {
+		t.Fatal(err)
+	}
+	if eval := `{user_name:"b"}`; buf.String() != eval {
+		t.Errorf("expected %v, got %v", eval, buf.String())
+	}
+
+	var out user
+	d := NewDecoder(NewReaderStr(`{user_name:"c"}`))
+	d.SetFieldNameMapper(SnakeCase)
+	if err := d.DecodeTo(&out); err != nil {
+		t.Fatal(err)
+	}
+	if eval := (user{Other: "c"}); out != eval {
+		t.Errorf("expected %+v, got %+v", eval, out)
+	}
+}
+
+func TestSnakeCase(t *testing.T) {
+	test := func(name, eval string) {
+		if val := SnakeCase(name); val != eval {
+			t.Errorf("expected %v, got %v", eval, val)
+		}
+	}
+
+	test("", "")
+	test("A", "a")
+	test("Name", "name")
+	test("UserName", "user_name")
+	test("UserID", "user_id")
+	test("HTTPServer", "http_server")
+	test("Version2Name", "version2_name")
+	test("already_snake", "already_snake")
+	test("ÜberName", "über_name")
+}
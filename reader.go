@@ -3,6 +3,8 @@ package ion
 import (
 	"bufio"
 	"bytes"
+	"encoding/base64"
+	"fmt"
 	"io"
 	"math"
 	"math/big"
@@ -62,9 +64,26 @@ type Reader interface {
 
 	// SymbolTable returns the current symbol table, or nil if there isn't one.
 	// Text Readers do not, generally speaking, have an associated symbol table.
-	// Binary Readers do.
+	// Binary Readers do: it's replaced as Next passes over version markers and local
+	// symbol tables, so once Next returns true it's the table in effect for the
+	// current value, including any imports, and can be inspected without reading
+	// the value.
 	SymbolTable() SymbolTable
 
+	// SymbolTableBuilder returns a snapshot of the symbols seen so far, for tools that
+	// want to persist them, say as a shared symbol table for future documents. Text
+	// Readers created with WithSymbolTracking add the text of every field name,
+	// annotation, and symbol value to their builder as Next reaches it, in order of
+	// appearance, skipping system symbols and any in containers that are skipped
+	// rather than stepped in to; other text Readers return an empty builder. For binary
+	// Readers it reflects the current local symbol table. Either way, the builder is
+	// a copy; adding to it doesn't affect the Reader.
+	SymbolTableBuilder() SymbolTableBuilder
+
+	// Encoding returns the format of the Ion being read, as detected when the Reader was
+	// created.
+	Encoding() Format
+
 	// Next advances the Reader to the next position in the current value stream.
 	// It returns true if this is the position of an Ion value, and false if it
 	// is not. On error, it returns false and sets Err.
@@ -91,9 +110,26 @@ type Reader interface {
 	// It returns nil if there is no current value or the current value has no annotations.
 	Annotations() []string
 
+	// FieldNameSymbol returns the field name associated with the current value as a
+	// SymbolToken, distinguishing field names with unknown text such as $0. Its LocalSID
+	// is SymbolIDUnknown and its Text is nil if the current value has no field name.
+	FieldNameSymbol() SymbolToken
+
+	// AnnotationSymbols returns the annotations associated with the current value as
+	// SymbolTokens, distinguishing annotations with unknown text such as $0.
+	AnnotationSymbols() []SymbolToken
+
+	// Comments returns the comments a text Reader created WithComments read while moving
+	// to the current value, in order and including their // or /* */ delimiters. These
+	// are generally the comments preceding the value, or preceding the end of the current
+	// container or stream if Next returned false. Comments inside values that are skipped
+	// over are discarded. It returns nil otherwise.
+	Comments() []string
+
 	// StepIn steps in to the current value if it is a container. It returns an error if there
-	// is no current value or if the value is not a container. On success, the Reader is
-	// positioned before the first value in the container.
+	// is no current value or if the value is not a container, including if it is a null
+	// container such as null.struct. On success, the Reader is positioned before the first
+	// value in the container.
 	StepIn() error
 
 	// StepOut steps out of the current container value being read. It returns an error if
@@ -102,6 +138,18 @@ type Reader interface {
 	// stream.
 	StepOut() error
 
+	// ContainerType returns the type of the container this Reader is currently stepped in
+	// to: StructType, ListType, or SexpType. It returns NoType at the top level.
+	ContainerType() Type
+
+	// FindField advances the Reader through the fields of the struct it is currently stepped
+	// in to until it finds one with the given name, leaving the Reader positioned on that
+	// field's value and returning true. If no more fields in the struct have that name, it
+	// returns false, leaving the Reader at the end of the struct. Fields are only searched
+	// for after the current position, so calling FindField again finds any subsequent field
+	// with the same name. It returns an error if the Reader is not stepped in to a struct.
+	FindField(name string) (bool, error)
+
 	// BoolValue returns the current value as a boolean (if that makes sense). It returns
 	// an error if the current value is not an Ion bool.
 	BoolValue() (bool, error)
@@ -115,6 +163,13 @@ type Reader interface {
 	// 32 bits to represent losslessly.
 	IntValue() (int, error)
 
+	// Int32Value, Int16Value, and Int8Value return the current value as an integer of
+	// the given size (if that makes sense). They return an error if the current value is
+	// not an Ion integer or is out of range for that size.
+	Int32Value() (int32, error)
+	Int16Value() (int16, error)
+	Int8Value() (int8, error)
+
 	// Int64Value returns the current value as a 64-bit integer (if that makes sense). It
 	// returns an error if the current value is not an Ion integer or requires more than
 	// 64 bits to represent losslessly.
@@ -138,44 +193,198 @@ type Reader interface {
 	DecimalValue() (*Decimal, error)
 
 	// TimeValue returns the current value as a timestamp (if that makes sense). It returns
-	// an error if the current value is not an Ion timestamp.
+	// an error if the current value is not an Ion timestamp. A time.Time cannot represent
+	// a leap second, so a timestamp with 60 seconds is normalized to the following second:
+	// 2016-12-31T23:59:60Z is returned as 2017-01-01T00:00:00Z.
 	TimeValue() (time.Time, error)
 
+	// TimestampPrecision returns the precision of the current timestamp value (if that
+	// makes sense). It returns an error if the current value is not an Ion timestamp.
+	TimestampPrecision() (TimestampPrecision, error)
+
 	// StringValue returns the current value as a string (if that makes sense). It returns
 	// an error if the current value is not an Ion symbol or an Ion string.
 	StringValue() (string, error)
 
+	// SymbolValue returns the current value as a SymbolToken (if that makes sense), which,
+	// unlike StringValue, distinguishes a symbol with unknown text such as $0. It returns an
+	// error if the current value is not an Ion symbol.
+	SymbolValue() (SymbolToken, error)
+
 	// ByteValue returns the current value as a byte slice (if that makes sense). It returns
 	// an error if the current value is not an Ion clob or an Ion blob.
 	ByteValue() ([]byte, error)
+
+	// BlobBase64Value returns the current value as a base64 string, in the standard
+	// alphabet with padding (base64.StdEncoding), such as "aGk=". It returns an error
+	// if the current value is not an Ion blob, and an empty string for null.blob.
+	BlobBase64Value() (string, error)
+
+	// Value returns the current value as a Value, for consumers that dispatch on its
+	// type in hot loops, in one call rather than a call to Type and another to the
+	// accessor for that type. Scalars are set as ReadValue sets them, but for a
+	// non-null container only the Type is set: unlike ReadValue, Value doesn't read its
+	// contents, which the Reader can step in to as usual. It returns an error if the
+	// Reader is not positioned on a value.
+	Value() (Value, error)
+
+	// ValueBytes returns the raw, undecoded bytes of the current scalar value's binary
+	// representation: everything after its type descriptor and length, such as the
+	// UTF-8 bytes of a string or the magnitude bytes of an int. It is empty for nulls
+	// and bools. The returned slice may be reused by the Reader, so it is only valid
+	// until the next call to Next unless copied. Since the bytes must be kept as each
+	// value is read, it is only supported by binary Readers created with the
+	// WithValueBytes option. It returns an error if the current value is not a
+	// scalar, or if this Reader was not so created.
+	ValueBytes() ([]byte, error)
+
+	// RawToken returns the text of the current int, float, decimal or timestamp exactly
+	// as it was written, for formatters and the like that want to preserve it: 0x1F
+	// rather than 31, say, or 1_000 rather than 1000. It returns an error if the
+	// current value is anything else, including a null, or if this is not a text
+	// Reader.
+	RawToken() (string, error)
+
+	// BinaryTypeDescriptor returns the type descriptor byte that starts the current
+	// value's binary representation, which holds its type in the high four bits and
+	// its length, or a marker for a longer length or a null, in the low four: 0x21
+	// for a one-byte positive int, for example, or 0x8E for a string whose length
+	// follows. For an annotated value, it is the value's own, not its annotation
+	// wrapper's. It returns false if the Reader is not positioned on a value, or if
+	// this is not a binary Reader.
+	BinaryTypeDescriptor() (byte, bool)
+}
+
+// DefaultMaxDepth is the default maximum depth of nested containers that a
+// Reader will step in to.
+const DefaultMaxDepth = 1000
+
+// A ReaderOption configures optional behavior of a Reader.
+type ReaderOption func(*reader)
+
+// WithMaxDepth sets the maximum depth of nested containers that a Reader will
+// step in to. Attempting to step in any deeper returns a DepthLimitError,
+// guarding against maliciously deep input exhausting the stack of a caller
+// that recurses per container. A value of zero or less means no limit.
+func WithMaxDepth(n int) ReaderOption {
+	return func(r *reader) {
+		r.maxDepth = n
+	}
+}
+
+// WithMaxValueSize sets the maximum length in bytes of a value that a binary reader
+// will read, containers included, returning a SizeLimitError for any value whose
+// encoding declares it to be longer. This guards against input declaring values far
+// larger than it has any business sending, which the reader would otherwise
+// attempt to read until it ran out of input. A value of zero or less, the default,
+// means no limit. Text readers ignore this option.
+func WithMaxValueSize(n int) ReaderOption {
+	return func(r *reader) {
+		r.maxValueSize = n
+	}
+}
+
+// WithComments makes a text reader keep the comments it reads instead of discarding
+// them, for the benefit of tools such as formatters, making them available through
+// Comments. Binary readers, having no comments, ignore this option.
+func WithComments() ReaderOption {
+	return func(r *reader) {
+		r.keepComments = true
+	}
+}
+
+// WithStrict makes a binary reader reject values that aren't encoded canonically,
+// such as ints whose magnitude has leading zero bytes, returning a SyntaxError.
+// By default such values are accepted, since some Ion producers emit them, so long
+// as their meaning is clear. Text readers ignore this option.
+func WithStrict() ReaderOption {
+	return func(r *reader) {
+		r.strict = true
+	}
+}
+
+// WithValueBytes makes a binary reader keep the bytes of each scalar value it reads,
+// so that they can be had from ValueBytes. Text readers ignore this option.
+func WithValueBytes() ReaderOption {
+	return func(r *reader) {
+		r.valueBytes = true
+	}
+}
+
+// WithSymbolTracking makes a text reader collect the symbols it reads, so that they
+// can be had from SymbolTableBuilder. Binary readers ignore this option, since they
+// always have a symbol table.
+func WithSymbolTracking() ReaderOption {
+	return func(r *reader) {
+		r.trackSymbols = true
+	}
+}
+
+// WithBlobHexInput makes a text reader read blobs as hexadecimal, as written by a
+// writer given the WithBlobHex option, instead of base64. This is not standard
+// Ion, and blobs encoded in base64 will fail to read, or worse, be misread. Binary
+// readers ignore this option.
+func WithBlobHexInput() ReaderOption {
+	return func(r *reader) {
+		r.blobHex = true
+	}
+}
+
+// WithSymbolsAsStrings makes a Reader present symbol values as strings, for the
+// benefit of applications that don't care about the difference: Type returns
+// StringType for them, and StringValue returns their text, as it always does.
+// SymbolValue continues to work on them too.
+func WithSymbolsAsStrings() ReaderOption {
+	return func(r *reader) {
+		r.symbolsAsStrings = true
+	}
+}
+
+// WithSymbolTable gives a binary reader a local symbol table to use after each
+// binary version marker in place of the system symbol table, until the stream
+// defines a table of its own. It's for reading documents written by a writer given
+// WithoutLST, which leaves out the table they were written with. Text readers
+// ignore this option.
+func WithSymbolTable(lst SymbolTable) ReaderOption {
+	return func(r *reader) {
+		r.sharedLST = lst
+	}
+}
+
+// WithVersionMarkerHook makes a reader call hook with the version of each version
+// marker it reads, which it otherwise consumes silently.
+func withVersionMarkerHook(hook func(major, minor int)) ReaderOption {
+	return func(r *reader) {
+		r.onVersionMarker = hook
+	}
 }
 
 // NewReader creates a new Ion reader of the appropriate type by peeking
 // at the first several bytes of input for a binary version marker.
-func NewReader(in io.Reader) Reader {
-	return NewReaderCat(in, nil)
+func NewReader(in io.Reader, opts ...ReaderOption) Reader {
+	return NewReaderCat(in, nil, opts...)
 }
 
 // NewReaderStr creates a new reader from a string.
-func NewReaderStr(str string) Reader {
-	return NewReader(strings.NewReader(str))
+func NewReaderStr(str string, opts ...ReaderOption) Reader {
+	return NewReader(strings.NewReader(str), opts...)
 }
 
 // NewReaderBytes creates a new reader for the given bytes.
-func NewReaderBytes(in []byte) Reader {
-	return NewReader(bytes.NewReader(in))
+func NewReaderBytes(in []byte, opts ...ReaderOption) Reader {
+	return NewReader(bytes.NewReader(in), opts...)
 }
 
 // NewReaderCat creates a new reader with the given catalog.
-func NewReaderCat(in io.Reader, cat Catalog) Reader {
+func NewReaderCat(in io.Reader, cat Catalog, opts ...ReaderOption) Reader {
 	br := bufio.NewReader(in)
 
 	bs, err := br.Peek(4)
 	if err == nil && bs[0] == 0xE0 && bs[3] == 0xEA {
-		return newBinaryReaderBuf(br, cat)
+		return newBinaryReaderBuf(br, cat, opts...)
 	}
 
-	return newTextReaderBuf(br)
+	return newTextReaderBuf(br, opts...)
 }
 
 // A reader holds common implementation stuff to both the text and binary readers.
@@ -184,10 +393,67 @@ type reader struct {
 	eof bool
 	err error
 
+	maxDepth     int
+	keepComments bool
+	strict       bool
+	blobHex      bool
+	maxValueSize int
+	valueBytes   bool
+	trackSymbols bool
+
+	symbolsAsStrings bool
+	sharedLST        SymbolTable
+	onVersionMarker  func(major, minor int)
+
 	fieldName   string
 	annotations []string
 	valueType   Type
 	value       interface{}
+	precision   TimestampPrecision
+
+	fieldNameSym   *SymbolToken
+	annotationSyms []SymbolToken
+	symbol         SymbolToken
+	comments       []string
+}
+
+// Init applies the given options on top of the defaults.
+func (r *reader) init(opts []ReaderOption) {
+	r.maxDepth = DefaultMaxDepth
+	for _, opt := range opts {
+		opt(r)
+	}
+}
+
+// VersionMarker tells the version marker hook, if there is one, that we've read a
+// version marker.
+func (r *reader) versionMarker(major, minor int) {
+	if r.onVersionMarker != nil {
+		r.onVersionMarker(major, minor)
+	}
+}
+
+// CheckDepth returns an error if stepping in to another container would
+// exceed the maximum depth.
+func (r *reader) checkDepth(offset uint64) error {
+	if r.maxDepth > 0 && len(r.ctx.arr) >= r.maxDepth {
+		return &DepthLimitError{r.maxDepth, offset}
+	}
+	return nil
+}
+
+// FindStructField implements Reader.FindField in terms of Next for either kind of reader,
+// given that reader's current context.
+func findStructField(r Reader, c ctx, name string) (bool, error) {
+	if c != ctxInStruct {
+		return false, &UsageError{"Reader.FindField", "not in a struct"}
+	}
+	for r.Next() {
+		if r.FieldName() == name {
+			return true, nil
+		}
+	}
+	return false, r.Err()
 }
 
 // Err returns the current error.
@@ -197,9 +463,17 @@ func (r *reader) Err() error {
 
 // Type returns the current value's type.
 func (r *reader) Type() Type {
+	if r.symbolsAsStrings && r.valueType == SymbolType {
+		return StringType
+	}
 	return r.valueType
 }
 
+// ContainerType returns the type of the container we're stepped in to.
+func (r *reader) ContainerType() Type {
+	return ctxToContainerType(r.ctx.peek())
+}
+
 // IsNull returns true if the current value is null.
 func (r *reader) IsNull() bool {
 	return r.valueType != NoType && r.value == nil
@@ -215,6 +489,24 @@ func (r *reader) Annotations() []string {
 	return r.annotations
 }
 
+// FieldNameSymbol returns the current value's field name as a SymbolToken.
+func (r *reader) FieldNameSymbol() SymbolToken {
+	if r.fieldNameSym == nil {
+		return SymbolToken{nil, SymbolIDUnknown}
+	}
+	return *r.fieldNameSym
+}
+
+// AnnotationSymbols returns the current value's annotations as SymbolTokens.
+func (r *reader) AnnotationSymbols() []SymbolToken {
+	return r.annotationSyms
+}
+
+// Comments returns the comments read while moving to the current value.
+func (r *reader) Comments() []string {
+	return r.comments
+}
+
 // BoolValue returns the current value as a bool.
 func (r *reader) BoolValue() (bool, error) {
 	if r.valueType != BoolType {
@@ -252,14 +544,40 @@ func (r *reader) IntSize() (IntSize, error) {
 
 // IntValue returns the current value as an int.
 func (r *reader) IntValue() (int, error) {
+	i, err := r.intNValue("Reader.IntValue", 32)
+	return int(i), err
+}
+
+// Int32Value returns the current value as an int32.
+func (r *reader) Int32Value() (int32, error) {
+	i, err := r.intNValue("Reader.Int32Value", 32)
+	return int32(i), err
+}
+
+// Int16Value returns the current value as an int16.
+func (r *reader) Int16Value() (int16, error) {
+	i, err := r.intNValue("Reader.Int16Value", 16)
+	return int16(i), err
+}
+
+// Int8Value returns the current value as an int8.
+func (r *reader) Int8Value() (int8, error) {
+	i, err := r.intNValue("Reader.Int8Value", 8)
+	return int8(i), err
+}
+
+// IntNValue returns the current value as an int64, or an error if it needs more
+// than the given number of bits.
+func (r *reader) intNValue(api string, bits uint) (int64, error) {
 	i, err := r.Int64Value()
 	if err != nil {
 		return 0, err
 	}
-	if i > math.MaxInt32 || i < math.MinInt32 {
-		return 0, &UsageError{"Reader.IntValue", "value too large for an int32"}
+	max := int64(1)<<(bits-1) - 1
+	if i > max || i < -max-1 {
+		return 0, &UsageError{api, fmt.Sprintf("value too large for an int%v", bits)}
 	}
-	return int(i), nil
+	return i, nil
 }
 
 // Int64Value returns the current value as an int64.
@@ -357,6 +675,17 @@ func (r *reader) TimeValue() (time.Time, error) {
 	return r.value.(time.Time), nil
 }
 
+// TimestampPrecision returns the precision of the current timestamp value.
+func (r *reader) TimestampPrecision() (TimestampPrecision, error) {
+	if r.valueType != TimestampType {
+		return TimestampNoPrecision, &UsageError{"Reader.TimestampPrecision", "value is not a timestamp"}
+	}
+	if r.value == nil {
+		return TimestampNoPrecision, nil
+	}
+	return r.precision, nil
+}
+
 // StringValue returns the current value as a string.
 func (r *reader) StringValue() (string, error) {
 	if r.valueType != StringType && r.valueType != SymbolType {
@@ -368,6 +697,17 @@ func (r *reader) StringValue() (string, error) {
 	return r.value.(string), nil
 }
 
+// SymbolValue returns the current value as a SymbolToken.
+func (r *reader) SymbolValue() (SymbolToken, error) {
+	if r.valueType != SymbolType {
+		return SymbolToken{nil, SymbolIDUnknown}, &UsageError{"Reader.SymbolValue", "value is not a symbol"}
+	}
+	if r.value == nil {
+		return SymbolToken{nil, SymbolIDUnknown}, nil
+	}
+	return r.symbol, nil
+}
+
 // ByteValue returns the current value as a byte slice.
 func (r *reader) ByteValue() ([]byte, error) {
 	if r.valueType != BlobType && r.valueType != ClobType {
@@ -379,10 +719,49 @@ func (r *reader) ByteValue() ([]byte, error) {
 	return r.value.([]byte), nil
 }
 
+// BlobBase64Value returns the current value as a base64 string.
+func (r *reader) BlobBase64Value() (string, error) {
+	if r.valueType != BlobType {
+		return "", &UsageError{"Reader.BlobBase64Value", "value is not a blob"}
+	}
+	if r.value == nil {
+		return "", nil
+	}
+	return base64.StdEncoding.EncodeToString(r.value.([]byte)), nil
+}
+
+// Value returns the current value as a Value, without its contents if it's a container.
+func (r *reader) Value() (Value, error) {
+	if r.valueType == NoType {
+		return Value{}, &UsageError{"Reader.Value", "reader is not positioned on a value"}
+	}
+
+	v := Value{
+		Type:        r.Type(),
+		FieldName:   r.fieldName,
+		Annotations: r.annotations,
+	}
+	switch {
+	case r.value == nil:
+		v.Null = true
+	case r.valueType == TimestampType:
+		v.Scalar = r.value
+		v.Precision = r.precision
+	case r.valueType != ListType && r.valueType != SexpType && r.valueType != StructType:
+		v.Scalar = r.value
+	}
+	return v, nil
+}
+
 // Clear clears the current value from the reader.
 func (r *reader) clear() {
 	r.fieldName = ""
 	r.annotations = nil
 	r.valueType = NoType
 	r.value = nil
+	r.precision = TimestampNoPrecision
+	r.fieldNameSym = nil
+	r.annotationSyms = nil
+	r.symbol = SymbolToken{}
+	r.comments = nil
 }
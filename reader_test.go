@@ -9,12 +9,11 @@ import (
 )
 
 var blacklist = map[string]bool{
-	"ion-tests/iontestdata/good/emptyAnnotatedInt.10n":    true,
-	"ion-tests/iontestdata/good/subfieldVarUInt32bit.ion": true,
-	"ion-tests/iontestdata/good/utf16.ion":                true,
-	"ion-tests/iontestdata/good/utf32.ion":                true,
-	"ion-tests/iontestdata/good/whitespace.ion":           true,
-	"ion-tests/iontestdata/good/item1.10n":                true,
+	"ion-tests/iontestdata/good/emptyAnnotatedInt.10n": true,
+	"ion-tests/iontestdata/good/utf16.ion":             true,
+	"ion-tests/iontestdata/good/utf32.ion":             true,
+	"ion-tests/iontestdata/good/whitespace.ion":        true,
+	"ion-tests/iontestdata/good/item1.10n":             true,
 }
 
 type drainfunc func(t *testing.T, r Reader, f string)
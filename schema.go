@@ -0,0 +1,502 @@
+package ion
+
+import (
+	"encoding/base64"
+	"fmt"
+	"math"
+	"math/big"
+	"time"
+)
+
+// A Schema is a minimal model of an Ion Schema type, constraining the values that
+// may be written to a validating writer. Its zero value accepts any value; each of
+// its fields that is set adds a constraint.
+type Schema struct {
+	// Type, if not NoType, is the type values must have. Typed nulls, like null.int,
+	// have the type they're qualified with.
+	Type Type
+
+	// Required lists the names of fields a non-null struct must have.
+	Required []string
+
+	// Fields constrains the values of the fields of a struct with the given names.
+	// A field that appears more than once must satisfy its constraints each time,
+	// and fields that aren't listed are unconstrained.
+	Fields map[string]*Schema
+
+	// Element, if not nil, constrains each of the values in a list or sexp.
+	Element *Schema
+
+	// ValidValues, if not empty, lists the only values allowed. Values are compared
+	// the same way Equal compares them, annotations included.
+	ValidValues []Value
+}
+
+// Validate returns a SchemaError describing the first constraint v violates, if any.
+func (s *Schema) Validate(v *Value) error {
+	return s.validate("", v)
+}
+
+// Validate validates a value found at the given path.
+func (s *Schema) validate(path string, v *Value) error {
+	if s.Type != NoType && v.Type != s.Type {
+		return &SchemaError{path, fmt.Sprintf("expected a %v, got a %v", s.Type, v.Type)}
+	}
+
+	if len(s.ValidValues) > 0 && !s.isValidValue(v) {
+		return &SchemaError{path, "not one of the valid values"}
+	}
+
+	if v.Null {
+		return nil
+	}
+
+	switch v.Type {
+	case StructType:
+		for _, name := range s.Required {
+			if !hasField(v, name) {
+				return &SchemaError{path, fmt.Sprintf("missing required field '%v'", name)}
+			}
+		}
+		for i := range v.Children {
+			child := &v.Children[i]
+			if fs, ok := s.Fields[child.FieldName]; ok {
+				if err := fs.validate(fieldPath(path, child.FieldName), child); err != nil {
+					return err
+				}
+			}
+		}
+
+	case ListType, SexpType:
+		if s.Element != nil {
+			for i := range v.Children {
+				if err := s.Element.validate(fmt.Sprintf("%v[%v]", path, i), &v.Children[i]); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// IsValidValue determines whether v is one of the schema's valid values.
+func (s *Schema) isValidValue(v *Value) bool {
+	for i := range s.ValidValues {
+		if equivalent(v, &s.ValidValues[i]) {
+			return true
+		}
+	}
+	return false
+}
+
+// HasField determines whether a struct value has a field with the given name.
+func hasField(v *Value, name string) bool {
+	for i := range v.Children {
+		if v.Children[i].FieldName == name {
+			return true
+		}
+	}
+	return false
+}
+
+// FieldPath extends a path to a struct with the name of one of its fields, as
+// described by Transform.
+func fieldPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// NewValidatingWriter returns a Writer that checks each top-level value written to
+// it against the given schema before passing it along to w. Each top-level value is
+// held in memory until it's complete; if it's valid it's then written to w, and if
+// not, the call that completed it returns a SchemaError and nothing of it is written.
+// As with any other error, that error is returned by every subsequent call.
+func NewValidatingWriter(w Writer, schema Schema) Writer {
+	return &validatingWriter{
+		w:      w,
+		schema: schema,
+	}
+}
+
+// A validatingWriter builds each top-level value written to it into a Value to be
+// validated, recording the calls made to write it so they can be replayed on the
+// underlying writer if it's valid.
+type validatingWriter struct {
+	w      Writer
+	schema Schema
+	err    error
+
+	fieldName   string
+	annotations []string
+
+	// The containers currently being written, outermost first.
+	open  []Value
+	calls []func(Writer) error
+
+	// Whether to write each top-level value in canonical form, rather than
+	// replaying the calls that wrote it.
+	canonical bool
+}
+
+// FieldName sets the field name for the next value written.
+func (v *validatingWriter) FieldName(val string) error {
+	if n := len(v.open); v.err == nil && (n == 0 || v.open[n-1].Type != StructType) {
+		v.err = &UsageError{"Writer.FieldName", "not in a struct"}
+	}
+	if v.err == nil {
+		v.fieldName = val
+		v.record(func(w Writer) error { return w.FieldName(val) })
+	}
+	return v.err
+}
+
+// Annotation adds an annotation to the next value written.
+func (v *validatingWriter) Annotation(val string) error {
+	if v.err == nil {
+		v.annotations = append(v.annotations, val)
+		v.record(func(w Writer) error { return w.Annotation(val) })
+	}
+	return v.err
+}
+
+// Annotations adds annotations to the next value written.
+func (v *validatingWriter) Annotations(vals ...string) error {
+	if v.err == nil {
+		vals = append([]string(nil), vals...)
+		v.annotations = append(v.annotations, vals...)
+		v.record(func(w Writer) error { return w.Annotations(vals...) })
+	}
+	return v.err
+}
+
+// WriteNull writes an untyped null.
+func (v *validatingWriter) WriteNull() error {
+	return v.write(Value{Type: NullType, Null: true}, func(w Writer) error {
+		return w.WriteNull()
+	})
+}
+
+// WriteNullType writes a typed null.
+func (v *validatingWriter) WriteNullType(t Type) error {
+	return v.write(Value{Type: t, Null: true}, func(w Writer) error {
+		return w.WriteNullType(t)
+	})
+}
+
+// WriteBool writes a bool.
+func (v *validatingWriter) WriteBool(val bool) error {
+	return v.write(Value{Type: BoolType, Scalar: val}, func(w Writer) error {
+		return w.WriteBool(val)
+	})
+}
+
+// WriteInt writes an int.
+func (v *validatingWriter) WriteInt(val int64) error {
+	return v.write(Value{Type: IntType, Scalar: val}, func(w Writer) error {
+		return w.WriteInt(val)
+	})
+}
+
+// WriteUint writes a uint.
+func (v *validatingWriter) WriteUint(val uint64) error {
+	var scalar interface{}
+	if val > math.MaxInt64 {
+		scalar = new(big.Int).SetUint64(val)
+	} else {
+		scalar = int64(val)
+	}
+	return v.write(Value{Type: IntType, Scalar: scalar}, func(w Writer) error {
+		return w.WriteUint(val)
+	})
+}
+
+// WriteBigInt writes a big int.
+func (v *validatingWriter) WriteBigInt(val *big.Int) error {
+	// Copy it, in case the caller reuses it before we're done with it.
+	val = new(big.Int).Set(val)
+	return v.write(Value{Type: IntType, Scalar: val}, func(w Writer) error {
+		return w.WriteBigInt(val)
+	})
+}
+
+// WriteFloat writes a float.
+func (v *validatingWriter) WriteFloat(val float64) error {
+	return v.write(Value{Type: FloatType, Scalar: val}, func(w Writer) error {
+		return w.WriteFloat(val)
+	})
+}
+
+// WriteDecimal writes a decimal.
+func (v *validatingWriter) WriteDecimal(val *Decimal) error {
+	return v.write(Value{Type: DecimalType, Scalar: val}, func(w Writer) error {
+		return w.WriteDecimal(val)
+	})
+}
+
+// WriteMoney writes an annotated monetary amount.
+func (v *validatingWriter) WriteMoney(annotation string, amount string) error {
+	if v.err != nil {
+		return v.err
+	}
+
+	d, err := ParseDecimal(amount)
+	if err != nil {
+		v.err = &UsageError{"Writer.WriteMoney", err.Error()}
+		return v.err
+	}
+
+	v.annotations = append(v.annotations, annotation)
+	return v.write(Value{Type: DecimalType, Scalar: d}, func(w Writer) error {
+		return w.WriteMoney(annotation, amount)
+	})
+}
+
+// WriteTimestamp writes a timestamp.
+func (v *validatingWriter) WriteTimestamp(val time.Time) error {
+	return v.write(Value{Type: TimestampType, Scalar: val, Precision: TimestampPrecisionNanosecond}, func(w Writer) error {
+		return w.WriteTimestamp(val)
+	})
+}
+
+// WriteTimestampWithPrecision writes a timestamp with the given precision.
+func (v *validatingWriter) WriteTimestampWithPrecision(val time.Time, precision TimestampPrecision) error {
+	return v.write(Value{Type: TimestampType, Scalar: val, Precision: precision}, func(w Writer) error {
+		return w.WriteTimestampWithPrecision(val, precision)
+	})
+}
+
+// WriteSymbol writes a symbol.
+func (v *validatingWriter) WriteSymbol(val string) error {
+	return v.write(Value{Type: SymbolType, Scalar: val}, func(w Writer) error {
+		return w.WriteSymbol(val)
+	})
+}
+
+// WriteSymbolByID writes a symbol by its ID. It's validated as if it were written
+// as text of the form $<id>.
+func (v *validatingWriter) WriteSymbolByID(id uint64) error {
+	return v.write(Value{Type: SymbolType, Scalar: fmt.Sprintf("$%v", id)}, func(w Writer) error {
+		return w.WriteSymbolByID(id)
+	})
+}
+
+// WriteString writes a string.
+func (v *validatingWriter) WriteString(val string) error {
+	return v.write(Value{Type: StringType, Scalar: val}, func(w Writer) error {
+		return w.WriteString(val)
+	})
+}
+
+// WriteClob writes a clob.
+func (v *validatingWriter) WriteClob(val []byte) error {
+	val = append([]byte{}, val...)
+	return v.write(Value{Type: ClobType, Scalar: val}, func(w Writer) error {
+		return w.WriteClob(val)
+	})
+}
+
+// WriteBlob writes a blob.
+func (v *validatingWriter) WriteBlob(val []byte) error {
+	val = append([]byte{}, val...)
+	return v.write(Value{Type: BlobType, Scalar: val}, func(w Writer) error {
+		return w.WriteBlob(val)
+	})
+}
+
+// WriteBlobString writes a blob given as a base64 string.
+func (v *validatingWriter) WriteBlobString(base64Str string) error {
+	if v.err != nil {
+		return v.err
+	}
+
+	val, err := base64.StdEncoding.DecodeString(base64Str)
+	if err != nil {
+		v.err = &UsageError{"Writer.WriteBlobString", err.Error()}
+		return v.err
+	}
+	return v.WriteBlob(val)
+}
+
+// WriteIntList writes a list of ints, validating it as if each were written in turn.
+func (v *validatingWriter) WriteIntList(vals []int64) error {
+	v.BeginList()
+	for _, val := range vals {
+		v.WriteInt(val)
+	}
+	return v.EndList()
+}
+
+// WriteFloatList writes a list of floats, like WriteIntList.
+func (v *validatingWriter) WriteFloatList(vals []float64) error {
+	v.BeginList()
+	for _, val := range vals {
+		v.WriteFloat(val)
+	}
+	return v.EndList()
+}
+
+// WriteStringList writes a list of strings, like WriteIntList.
+func (v *validatingWriter) WriteStringList(vals []string) error {
+	v.BeginList()
+	for _, val := range vals {
+		v.WriteString(val)
+	}
+	return v.EndList()
+}
+
+// WriteRawText writes a value given in Ion text.
+func (v *validatingWriter) WriteRawText(s string) error {
+	if v.err != nil {
+		return v.err
+	}
+
+	val, _, err := parseRawText(s)
+	if err != nil {
+		v.err = err
+		return v.err
+	}
+	return v.write(val, func(w Writer) error {
+		return w.WriteRawText(s)
+	})
+}
+
+// WritePadding writes NOP padding, which isn't a value and so isn't validated.
+func (v *validatingWriter) WritePadding(n int) error {
+	if v.err == nil {
+		v.record(func(w Writer) error { return w.WritePadding(n) })
+	}
+	return v.err
+}
+
+// BeginList begins writing a list.
+func (v *validatingWriter) BeginList() error {
+	return v.begin(ListType, Writer.BeginList)
+}
+
+// EndList finishes writing a list.
+func (v *validatingWriter) EndList() error {
+	return v.end("Writer.EndList", ListType, Writer.EndList)
+}
+
+// BeginSexp begins writing a sexp.
+func (v *validatingWriter) BeginSexp() error {
+	return v.begin(SexpType, Writer.BeginSexp)
+}
+
+// EndSexp finishes writing a sexp.
+func (v *validatingWriter) EndSexp() error {
+	return v.end("Writer.EndSexp", SexpType, Writer.EndSexp)
+}
+
+// BeginStruct begins writing a struct.
+func (v *validatingWriter) BeginStruct() error {
+	return v.begin(StructType, Writer.BeginStruct)
+}
+
+// EndStruct finishes writing a struct.
+func (v *validatingWriter) EndStruct() error {
+	return v.end("Writer.EndStruct", StructType, Writer.EndStruct)
+}
+
+// Finish finishes writing to the underlying writer.
+func (v *validatingWriter) Finish() error {
+	if v.err != nil {
+		return v.err
+	}
+	if len(v.open) > 0 {
+		v.err = &UsageError{"Writer.Finish", "not at top level"}
+		return v.err
+	}
+	v.err = v.w.Finish()
+	return v.err
+}
+
+// SymbolTable returns the underlying writer's symbol table.
+func (v *validatingWriter) SymbolTable() SymbolTable {
+	return v.w.SymbolTable()
+}
+
+// Record records a call to be replayed on the underlying writer.
+func (v *validatingWriter) record(call func(Writer) error) {
+	v.calls = append(v.calls, call)
+}
+
+// Write records the call that writes a scalar value and adds the value to the
+// current container, or validates it and writes it out if it's at the top level.
+func (v *validatingWriter) write(val Value, call func(Writer) error) error {
+	if v.err != nil {
+		return v.err
+	}
+
+	val.FieldName, val.Annotations = v.fieldName, append(v.annotations, val.Annotations...)
+	v.fieldName, v.annotations = "", nil
+
+	v.record(call)
+	return v.add(val)
+}
+
+// Begin records the call that begins a container and starts building its value.
+func (v *validatingWriter) begin(t Type, call func(Writer) error) error {
+	if v.err != nil {
+		return v.err
+	}
+
+	v.open = append(v.open, Value{
+		Type:        t,
+		FieldName:   v.fieldName,
+		Annotations: v.annotations,
+	})
+	v.fieldName, v.annotations = "", nil
+
+	v.record(call)
+	return nil
+}
+
+// End records the call that ends a container and adds the finished container
+// to its parent, or validates it and writes it out if it's at the top level.
+func (v *validatingWriter) end(api string, t Type, call func(Writer) error) error {
+	if v.err != nil {
+		return v.err
+	}
+
+	n := len(v.open)
+	if n == 0 || v.open[n-1].Type != t {
+		v.err = &UsageError{api, fmt.Sprintf("not in a %v", t)}
+		return v.err
+	}
+	val := v.open[n-1]
+	v.open = v.open[:n-1]
+
+	v.record(call)
+	return v.add(val)
+}
+
+// Add adds a finished value to the current container, or validates it and writes
+// it out if it's at the top level.
+func (v *validatingWriter) add(val Value) error {
+	if n := len(v.open); n > 0 {
+		v.open[n-1].Children = append(v.open[n-1].Children, val)
+		return nil
+	}
+
+	calls := v.calls
+	v.calls = nil
+
+	if v.err = v.schema.Validate(&val); v.err != nil {
+		return v.err
+	}
+	if v.canonical {
+		canonicalize(&val)
+		v.err = val.WriteTo(v.w)
+		return v.err
+	}
+	for _, call := range calls {
+		if v.err = call(v.w); v.err != nil {
+			return v.err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,179 @@
+package ion
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSchemaValidate(t *testing.T) {
+	color := Schema{
+		Type: SymbolType,
+		ValidValues: []Value{
+			{Type: SymbolType, Scalar: "red"},
+			{Type: SymbolType, Scalar: "green"},
+		},
+	}
+	schema := Schema{
+		Type:     StructType,
+		Required: []string{"id", "tags"},
+		Fields: map[string]*Schema{
+			"id":    {Type: IntType},
+			"color": &color,
+			"tags":  {Type: ListType, Element: &Schema{Type: StringType}},
+		},
+	}
+
+	test := func(in string, epath string) {
+		t.Run(in, func(t *testing.T) {
+			r := NewReaderStr(in)
+			if !r.Next() {
+				t.Fatal(r.Err())
+			}
+			v, err := ReadValue(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = schema.Validate(&v)
+			if epath == "-" {
+				if err != nil {
+					t.Errorf("expected no error, got %v", err)
+				}
+				return
+			}
+
+			se, ok := err.(*SchemaError)
+			if !ok {
+				t.Fatalf("expected a SchemaError, got %v", err)
+			}
+			if se.Path != epath {
+				t.Errorf("expected an error at '%v', got %v", epath, se)
+			}
+		})
+	}
+
+	test(`{id:1, tags:[]}`, "-")
+	test(`{id:1, tags:["a", "b"], color:red, other:(anything)}`, "-")
+	test(`{id:null.int, tags:null.list}`, "-")
+
+	test(`[id, tags]`, "")
+	test(`null.struct`, "-")
+	test(`{tags:[]}`, "")
+	test(`{id:"1", tags:[]}`, "id")
+	test(`{id:1, tags:["a", b]}`, "tags[1]")
+	test(`{id:1, tags:[], color:blue}`, "color")
+	test(`{id:1, tags:[], color:x::red}`, "color")
+	test(`{id:1, tags:[], color:green, color:"green"}`, "color")
+}
+
+func TestValidatingWriterRequiredField(t *testing.T) {
+	schema := Schema{
+		Type:     StructType,
+		Required: []string{"name"},
+		Fields: map[string]*Schema{
+			"name": {Type: StringType},
+		},
+	}
+
+	buf := strings.Builder{}
+	w := NewValidatingWriter(NewTextWriter(&buf), schema)
+
+	w.Annotation("user")
+	w.BeginStruct()
+	{
+		w.FieldName("name")
+		w.WriteString("beyonce")
+		w.FieldName("roles")
+		w.BeginList()
+		w.WriteSymbol("admin")
+		w.EndList()
+	}
+	if err := w.EndStruct(); err != nil {
+		t.Fatal(err)
+	}
+
+	w.BeginStruct()
+	{
+		w.FieldName("roles")
+		w.BeginList()
+		w.EndList()
+	}
+	err := w.EndStruct()
+	if se, ok := err.(*SchemaError); !ok || se.Msg != "missing required field 'name'" {
+		t.Fatalf("expected a SchemaError for the missing field, got %v", err)
+	}
+
+	// The error sticks, and nothing of the invalid value was written.
+	if err := w.WriteInt(1); err == nil {
+		t.Error("expected an error after the schema violation")
+	}
+	expected := `user::{name:"beyonce",roles:[admin]}`
+	if buf.String() != expected {
+		t.Errorf("expected %q, got %q", expected, buf.String())
+	}
+}
+
+func TestValidatingWriter(t *testing.T) {
+	schema := Schema{Type: StructType, Fields: map[string]*Schema{
+		"n": {ValidValues: []Value{{Type: IntType, Scalar: int64(1)}, {Type: IntType, Null: true}}},
+	}}
+
+	buf := strings.Builder{}
+	w := NewValidatingWriter(NewTextWriter(&buf), schema)
+	w.BeginStruct()
+	w.FieldName("n")
+	w.WriteUint(1)
+	w.FieldName("n")
+	w.WriteNullType(IntType)
+	w.FieldName("m")
+	w.WriteMoney("USD", "12.50")
+	w.FieldName("b")
+	w.WriteBlob([]byte("hi"))
+	w.FieldName("r")
+	w.Annotation("x")
+	w.WriteRawText("y::[1, {n:1}]")
+	w.EndStruct()
+	if err := w.Finish(); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "{n:1,n:null.int,m:USD::12.50,b:{{aGk=}},r:x::y::[1, {n:1}]}\n"
+	if buf.String() != expected {
+		t.Errorf("expected %q, got %q", expected, buf.String())
+	}
+
+	// Unbalanced calls are caught before they get as far as the underlying writer.
+	w = NewValidatingWriter(NewTextWriter(&buf), Schema{})
+	w.BeginList()
+	if err := w.EndStruct(); err == nil {
+		t.Error("expected an error ending a struct in a list")
+	}
+
+	w = NewValidatingWriter(NewTextWriter(&buf), Schema{})
+	if err := w.FieldName("a"); err == nil {
+		t.Error("expected an error setting a field name at the top level")
+	}
+
+	w = NewValidatingWriter(NewTextWriter(&buf), Schema{})
+	w.BeginList()
+	if err := w.Finish(); err == nil {
+		t.Error("expected an error finishing inside a list")
+	}
+}
+
+func TestValidatingWriterLists(t *testing.T) {
+	schema := Schema{Type: ListType, Element: &Schema{Type: IntType}}
+
+	buf := strings.Builder{}
+	w := NewValidatingWriter(NewTextWriter(&buf), schema)
+	if err := w.WriteIntList([]int64{1, 2}); err != nil {
+		t.Fatal(err)
+	}
+	err := w.WriteStringList([]string{"a"})
+	if se, ok := err.(*SchemaError); !ok || se.Path != "[0]" {
+		t.Errorf("expected a SchemaError at [0], got %v", err)
+	}
+	if buf.String() != "[1,2]" {
+		t.Errorf("expected %q, got %q", "[1,2]", buf.String())
+	}
+}
@@ -63,6 +63,8 @@ func (t *tokenizer) skipValue() (int, error) {
 	var c int
 	var err error
 
+	held := len(t.held)
+
 	switch t.token {
 	case tokenNumber:
 		c, err = t.skipNumber()
@@ -98,11 +100,18 @@ func (t *tokenizer) skipValue() (int, error) {
 		return 0, err
 	}
 
+	// Comments inside the value are dropped, but any following it are
+	// held back for the next value.
+	t.held = t.held[:held]
+
 	if isWhitespace(c) {
+		n := len(t.comments)
 		c, _, err = t.skipWhitespace()
 		if err != nil {
 			return 0, err
 		}
+		t.held = append(t.held, t.comments[n:]...)
+		t.comments = t.comments[:n]
 	}
 
 	t.unfinished = false
@@ -541,7 +550,17 @@ func (t *tokenizer) skipEndOfLongString(handler commentHandler) (bool, error) {
 		return false, err
 	}
 
-	// Consume any additional whitespace/comments.
+	more, err := t.skipLongStringSeparator(handler)
+	return !more, err
+}
+
+// SkipLongStringSeparator is called after reading the closing ''' of one
+// segment of a long string. It skips any whitespace and comments that follow,
+// returning true if they're followed by another segment of the same string,
+// whose opening ''' is consumed.
+func (t *tokenizer) skipLongStringSeparator(handler commentHandler) (bool, error) {
+	n := len(t.comments)
+
 	c, _, err := t.skipWhitespaceWith(handler)
 	if err != nil {
 		return false, err
@@ -554,12 +573,18 @@ func (t *tokenizer) skipEndOfLongString(handler commentHandler) (bool, error) {
 			return false, err
 		}
 		if ok {
-			return false, nil
+			return true, nil
 		}
 	}
 
+	// Any comments we skipped come after the string, not inside it.
+	if len(t.comments) > n {
+		t.held = append(t.held, t.comments[n:]...)
+		t.comments = t.comments[:n]
+	}
+
 	t.unread(c)
-	return true, nil
+	return false, nil
 }
 
 // SkipBlob skips over a blob value, returning the next character.
@@ -797,14 +822,23 @@ func (t *tokenizer) skipCommentsHandler() (bool, error) {
 		return false, err
 	}
 
+	if t.keepComments {
+		t.comment = append(t.comment[:0], '/')
+	}
+
 	switch c {
 	case '/':
-		return true, t.skipSingleLineComment()
+		err = t.skipSingleLineComment()
 	case '*':
-		return true, t.skipBlockComment()
+		err = t.skipBlockComment()
 	default:
 		return false, nil
 	}
+
+	if err == nil && t.keepComments {
+		t.comments = append(t.comments, string(t.comment))
+	}
+	return true, err
 }
 
 // SkipSingleLineComment skips over the body of a single-line comment,
@@ -819,6 +853,7 @@ func (t *tokenizer) skipSingleLineComment() error {
 		if c == -1 || c == '\n' {
 			return nil
 		}
+		t.keepCommentChar(c)
 	}
 }
 
@@ -834,6 +869,7 @@ func (t *tokenizer) skipBlockComment() error {
 		if c == -1 {
 			return t.invalidChar(c)
 		}
+		t.keepCommentChar(c)
 
 		if star && c == '/' {
 			return nil
@@ -843,6 +879,14 @@ func (t *tokenizer) skipBlockComment() error {
 	}
 }
 
+// KeepCommentChar adds a character to the text of the comment being
+// skipped, if we're keeping comments.
+func (t *tokenizer) keepCommentChar(c int) {
+	if t.keepComments {
+		t.comment = append(t.comment, byte(c))
+	}
+}
+
 // Peeks ahead to see if the next token is a double colon, and
 // if so skips it. If not, leaves the next token unconsumed.
 func (t *tokenizer) skipDoubleColon() (bool, error) {
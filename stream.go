@@ -0,0 +1,129 @@
+package ion
+
+import (
+	"bytes"
+	"io"
+)
+
+// A StreamWriter writes a continuous stream of Go values as top-level Ion values, as
+// for a log, writing each to the underlying io.Writer in a single call as soon as it
+// is encoded, and flushing it too if the io.Writer has a Flush method, as a
+// bufio.Writer does, so that whatever is reading the stream can process each value as
+// it arrives.
+type StreamWriter struct {
+	out    io.Writer
+	buf    bytes.Buffer
+	binary bool
+	opts   []WriterOption
+	enc    *Encoder
+
+	// The text writer, or the local symbol table binary values have been written with.
+	w   Writer
+	lst SymbolTable
+
+	err    error
+	closed bool
+}
+
+// NewStreamWriter creates a new StreamWriter writing text Ion, with each value followed
+// by the given delimiter, such as "\n" to write one value per line. As with
+// WithTopLevelSeparator, the delimiter must be Ion whitespace, so that the stream can
+// be read back as a sequence of values. Values are encoded with the given encoder
+// options, and the writer options are applied to the text writer they're written with.
+func NewStreamWriter(out io.Writer, delim string, eopts EncoderOpts, opts ...WriterOption) *StreamWriter {
+	s := &StreamWriter{out: out}
+	opts = append(opts, WithTopLevelSeparator(delim))
+	s.w = NewTextWriterOpts(&s.buf, TextWriterQuietFinish, opts...)
+	s.enc = NewEncoderOpts(s.w, eopts)
+	return s
+}
+
+// NewBinaryStreamWriter creates a new StreamWriter writing binary Ion. The first value
+// is written with a binary version marker and a local symbol table, and each value
+// after that with a local symbol table that appends whatever new symbols it needs to
+// the one before, rather than repeating the symbols already written. Values are
+// encoded with the given encoder options, and the writer options are applied to each
+// binary writer they're written with.
+func NewBinaryStreamWriter(out io.Writer, eopts EncoderOpts, opts ...WriterOption) *StreamWriter {
+	return &StreamWriter{
+		out:    out,
+		binary: true,
+		opts:   opts,
+		enc:    NewEncoderOpts(nil, eopts),
+	}
+}
+
+// SetFieldNameMapper sets a function that the names of struct fields without a name
+// given by a tag are passed through before being written, as Encoder's method of the
+// same name does.
+func (s *StreamWriter) SetFieldNameMapper(mapper func(string) string) {
+	s.enc.SetFieldNameMapper(mapper)
+}
+
+// Write encodes v as the next value in the stream, as an Encoder would, and writes it
+// out. Once Write has returned an error, every later call returns the same one.
+func (s *StreamWriter) Write(v interface{}) error {
+	if s.closed {
+		return &UsageError{"StreamWriter.Write", "stream writer is closed"}
+	}
+	if s.err != nil {
+		return s.err
+	}
+
+	s.buf.Reset()
+	if s.binary {
+		s.err = s.encodeBinary(v)
+	} else {
+		s.err = s.enc.Encode(v)
+	}
+	if s.err != nil {
+		return s.err
+	}
+
+	if _, err := s.buf.WriteTo(s.out); err != nil {
+		s.err = &IOError{err}
+		return s.err
+	}
+	s.err = s.flush()
+	return s.err
+}
+
+// EncodeBinary encodes v to the buffer as a binary Ion document, continuing the local
+// symbol table of the previous one, if any.
+func (s *StreamWriter) encodeBinary(v interface{}) error {
+	var w Writer
+	if s.lst == nil {
+		w = NewBinaryWriterOpts(&s.buf, nil, s.opts...)
+	} else {
+		w = NewBinaryWriterAppend(&s.buf, s.lst, s.opts...)
+	}
+
+	s.enc.w = w
+	if err := s.enc.Encode(v); err != nil {
+		return err
+	}
+	if err := w.Finish(); err != nil {
+		return err
+	}
+
+	s.lst = w.SymbolTable()
+	return nil
+}
+
+// Flush flushes the underlying io.Writer, if it can be flushed.
+func (s *StreamWriter) flush() error {
+	if f, ok := s.out.(interface{ Flush() error }); ok {
+		if err := f.Flush(); err != nil {
+			return &IOError{err}
+		}
+	}
+	return nil
+}
+
+// Close ends the stream, after which Write returns an error. It returns the error, if
+// any, that stopped an earlier call to Write. It does not close the underlying
+// io.Writer.
+func (s *StreamWriter) Close() error {
+	s.closed = true
+	return s.err
+}
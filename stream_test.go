@@ -0,0 +1,176 @@
+package ion
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type logRecord struct {
+	Seq   int    `json:"seq"`
+	Level string `json:"level"`
+	Msg   string `json:"msg,omitempty"`
+}
+
+func streamRecords() []logRecord {
+	var recs []logRecord
+	for i := 0; i < 1000; i++ {
+		rec := logRecord{Seq: i, Level: "info"}
+		if i%3 == 0 {
+			rec.Level = "warn"
+			rec.Msg = fmt.Sprintf("message %v", i)
+		}
+		recs = append(recs, rec)
+	}
+	return recs
+}
+
+func TestStreamWriterText(t *testing.T) {
+	buf := bytes.Buffer{}
+	bw := bufio.NewWriter(&buf)
+	s := NewStreamWriter(bw, "\n", 0)
+
+	recs := streamRecords()
+	for i, rec := range recs {
+		if err := s.Write(rec); err != nil {
+			t.Fatal(err)
+		}
+		if i == 0 {
+			// Flushed through the bufio.Writer already.
+			if eval := "{seq:0,level:\"warn\",msg:\"message 0\"}\n"; buf.String() != eval {
+				t.Errorf("expected %q, got %q", eval, buf.String())
+			}
+		}
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if lines := strings.Count(buf.String(), "\n"); lines != len(recs) {
+		t.Errorf("expected %v lines, got %v", len(recs), lines)
+	}
+	testStreamRecords(t, buf.Bytes(), recs)
+}
+
+func TestStreamWriterBinary(t *testing.T) {
+	buf := bytes.Buffer{}
+	s := NewBinaryStreamWriter(&buf, 0)
+
+	recs := streamRecords()
+	var sizes []int
+	for _, rec := range recs {
+		if err := s.Write(rec); err != nil {
+			t.Fatal(err)
+		}
+		sizes = append(sizes, buf.Len())
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// One version marker up front, with the symbols appended to as they come up.
+	if n := bytes.Count(buf.Bytes(), []byte{0xE0, 0x01, 0x00, 0xEA}); n != 1 {
+		t.Errorf("expected 1 version marker, got %v", n)
+	}
+	if sizes[2]-sizes[1] != sizes[5]-sizes[4] {
+		t.Errorf("expected records without new symbols to be the same size, got %v", sizes[:6])
+	}
+	testStreamRecords(t, buf.Bytes(), recs)
+}
+
+func TestStreamWriterErrors(t *testing.T) {
+	buf := bytes.Buffer{}
+	s := NewStreamWriter(&buf, ",", 0)
+	if err := s.Write(1); err == nil {
+		t.Error("expected an error writing with a non-whitespace delimiter")
+	}
+	if buf.Len() != 0 {
+		t.Errorf("expected nothing written, got %q", buf.String())
+	}
+
+	s = NewStreamWriter(&buf, "\n", 0)
+	if err := s.Write(func() {}); err == nil {
+		t.Error("expected an error writing a func")
+	}
+	if err := s.Write(1); err == nil {
+		t.Error("expected the error to stick")
+	}
+
+	s = NewBinaryStreamWriter(&buf, 0)
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := s.Write(1).(*UsageError); !ok {
+		t.Error("expected a UsageError writing to a closed stream writer")
+	}
+}
+
+func testStreamRecords(t *testing.T, data []byte, recs []logRecord) {
+	d := NewDecoder(NewReaderBytes(data))
+	var out []logRecord
+	for {
+		var rec logRecord
+		if err := d.DecodeTo(&rec); err == ErrNoInput {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		out = append(out, rec)
+	}
+	if len(out) != len(recs) {
+		t.Fatalf("expected %v records back, got %v", len(recs), len(out))
+	}
+	for i := range recs {
+		if !reflect.DeepEqual(out[i], recs[i]) {
+			t.Fatalf("expected %+v, got %+v", recs[i], out[i])
+		}
+	}
+}
+
+func TestStreamWriterEncoderOpts(t *testing.T) {
+	type rec struct {
+		UserName string
+		Attrs    map[string]int
+	}
+	v := rec{"a", map[string]int{"z": 1, "y": 2, "x": 3, "c": 4, "b": 5, "a": 6}}
+	eval := "{user_name:\"a\",attrs:{a:6,b:5,c:4,x:3,y:2,z:1}}"
+
+	test := func(name string, s *StreamWriter, buf *bytes.Buffer) {
+		t.Run(name, func(t *testing.T) {
+			s.SetFieldNameMapper(SnakeCase)
+			for i := 0; i < 2; i++ {
+				if err := s.Write(v); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			// Read it back through a reader to compare binary with text.
+			out := strings.Builder{}
+			w := NewTextWriterOpts(&out, TextWriterQuietFinish)
+			r := NewReaderBytes(buf.Bytes())
+			for r.Next() {
+				val, err := ReadValue(r)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := val.WriteTo(w); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := w.Finish(); err != nil {
+				t.Fatal(err)
+			}
+			if out.String() != eval+"\n"+eval {
+				t.Errorf("expected %v twice, got %v", eval, out.String())
+			}
+		})
+	}
+
+	text := bytes.Buffer{}
+	test("text", NewStreamWriter(&text, "\n", EncodeSortMaps), &text)
+	bin := bytes.Buffer{}
+	test("binary", NewBinaryStreamWriter(&bin, EncodeSortMaps), &bin)
+}
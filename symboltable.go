@@ -169,6 +169,19 @@ var V1SystemSymbolTable = NewSharedSymbolTable("$ion", 1, []string{
 	"$ion_shared_symbol_table",
 })
 
+// SystemSymbolTable returns the system symbol table for Ion v1.0, whose symbols
+// every local symbol table implicitly imports first.
+func SystemSymbolTable() SharedSymbolTable {
+	return V1SystemSymbolTable
+}
+
+// IsSystemSymbol returns true if the given symbol ID refers to one of the symbols of
+// the Ion v1.0 system symbol table, which are always IDs 1 through 9. ID 0, which
+// stands for a symbol with unknown text, is not a system symbol.
+func IsSystemSymbol(id int) bool {
+	return id > 0 && uint64(id) <= V1SystemSymbolTable.MaxID()
+}
+
 // A BogusSST represents an SST imported by an LST that cannot be found in the
 // local catalog. It exists to reserve some part of the symbol ID space so other
 // symbol tables get mapped to the right IDs.
@@ -248,10 +261,20 @@ type lst struct {
 
 	symbols []string
 	index   map[string]uint64
+
+	// Unknown holds the indexes into symbols of slots reserved by null or
+	// non-string entries, which have unknown text rather than empty text.
+	unknown map[int]bool
 }
 
 // NewLocalSymbolTable creates a new local symbol table.
 func NewLocalSymbolTable(imports []SharedSymbolTable, symbols []string) SymbolTable {
+	return newLocalSymbolTable(imports, symbols, nil)
+}
+
+// NewLocalSymbolTable creates a new local symbol table, some of whose symbols
+// may have unknown text.
+func newLocalSymbolTable(imports []SharedSymbolTable, symbols []string, unknown map[int]bool) *lst {
 	imps, offsets, maxID := processImports(imports)
 	syms := make([]string, len(symbols))
 	copy(syms, symbols)
@@ -264,6 +287,7 @@ func NewLocalSymbolTable(imports []SharedSymbolTable, symbols []string) SymbolTa
 		maxImportID: maxID,
 		symbols:     syms,
 		index:       index,
+		unknown:     unknown,
 	}
 }
 
@@ -307,7 +331,7 @@ func (t *lst) FindByID(id uint64) (string, bool) {
 
 	// Local to this symbol table.
 	idx := id - t.maxImportID - 1
-	if idx < uint64(len(t.symbols)) {
+	if idx < uint64(len(t.symbols)) && !t.unknown[int(idx)] {
 		return t.symbols[idx], true
 	}
 
@@ -361,8 +385,12 @@ func (t *lst) WriteTo(w Writer) error {
 		w.FieldName("symbols")
 
 		w.BeginList()
-		for _, sym := range t.symbols {
-			w.WriteString(sym)
+		for i, sym := range t.symbols {
+			if t.unknown[i] {
+				w.WriteNull()
+			} else {
+				w.WriteString(sym)
+			}
 		}
 		w.EndList()
 	}
@@ -370,6 +398,31 @@ func (t *lst) WriteTo(w Writer) error {
 	return w.EndStruct()
 }
 
+// WriteAppendedLST writes a local symbol table that appends the given symbols to
+// the previous local symbol table in the stream, importing it via $ion_symbol_table
+// rather than repeating it.
+func writeAppendedLST(w Writer, symbols []string) error {
+	if len(symbols) == 0 {
+		// Nothing new; the previous table remains in effect.
+		return nil
+	}
+
+	w.Annotation("$ion_symbol_table")
+	w.BeginStruct()
+
+	w.FieldName("imports")
+	w.WriteSymbol("$ion_symbol_table")
+
+	w.FieldName("symbols")
+	w.BeginList()
+	for _, sym := range symbols {
+		w.WriteString(sym)
+	}
+	w.EndList()
+
+	return w.EndStruct()
+}
+
 func (t *lst) String() string {
 	buf := strings.Builder{}
 
@@ -385,6 +438,9 @@ type SymbolTableBuilder interface {
 
 	// Add adds a symbol to this symbol table.
 	Add(symbol string) (uint64, bool)
+	// AddAll adds each of the given symbols to this symbol table in order, as if by
+	// Add, returning their IDs.
+	AddAll(symbols []string) []uint64
 	// Build creates an immutable local symbol table.
 	Build() SymbolTable
 }
@@ -406,6 +462,40 @@ func NewSymbolTableBuilder(imports ...SharedSymbolTable) SymbolTableBuilder {
 	}
 }
 
+// NewSymbolTableBuilderFromLST creates a new symbol table builder seeded with
+// the imports and symbols of an existing local symbol table, such as one from a
+// prior segment of a stream. Existing symbols keep their IDs, and added symbols
+// are assigned IDs following the existing max ID. The tables it builds are
+// complete in themselves, the existing symbols included, so they can be used
+// anywhere; to write only the added symbols after the existing table, use
+// NewBinaryWriterAppend.
+func NewSymbolTableBuilderFromLST(prev SymbolTable) SymbolTableBuilder {
+	return copySymbolTable(prev)
+}
+
+// CopySymbolTable creates a new symbol table builder with the same imports and
+// symbols as an existing local symbol table, which it does not append to.
+func copySymbolTable(prev SymbolTable) *symbolTableBuilder {
+	imps, offsets, maxID := processImports(prev.Imports())
+	syms := prev.Symbols()
+
+	var unknown map[int]bool
+	if l, ok := prev.(*lst); ok {
+		unknown = copyUnknown(l.unknown)
+	}
+
+	return &symbolTableBuilder{
+		lst{
+			imports:     imps,
+			offsets:     offsets,
+			maxImportID: maxID,
+			symbols:     syms,
+			index:       buildIndex(syms, maxID+1),
+			unknown:     unknown,
+		},
+	}
+}
+
 func (b *symbolTableBuilder) Add(symbol string) (uint64, bool) {
 	if id, ok := b.FindByName(symbol); ok {
 		return id, false
@@ -418,6 +508,14 @@ func (b *symbolTableBuilder) Add(symbol string) (uint64, bool) {
 	return id, true
 }
 
+func (b *symbolTableBuilder) AddAll(symbols []string) []uint64 {
+	ids := make([]uint64, len(symbols))
+	for i, sym := range symbols {
+		ids[i], _ = b.Add(sym)
+	}
+	return ids
+}
+
 func (b *symbolTableBuilder) Build() SymbolTable {
 	symbols := append([]string{}, b.symbols...)
 	index := make(map[string]uint64)
@@ -431,21 +529,38 @@ func (b *symbolTableBuilder) Build() SymbolTable {
 		maxImportID: b.maxImportID,
 		symbols:     symbols,
 		index:       index,
+		unknown:     copyUnknown(b.unknown),
 	}
 }
 
+// CopyUnknown copies a set of symbols with unknown text.
+func copyUnknown(unknown map[int]bool) map[int]bool {
+	if len(unknown) == 0 {
+		return nil
+	}
+	cp := make(map[int]bool, len(unknown))
+	for i := range unknown {
+		cp[i] = true
+	}
+	return cp
+}
+
 // ProcessImports processes a slice of imports, returning an (augmented) copy, a set of
 // offsets for each import, and the overall max ID.
 func processImports(imports []SharedSymbolTable) ([]SharedSymbolTable, []uint64, uint64) {
-	// Add in V1SystemSymbolTable at the head of the list if it's not already included.
-	var imps []SharedSymbolTable
-	if len(imports) > 0 && imports[0].Name() == "$ion" {
-		imps = make([]SharedSymbolTable, len(imports))
-		copy(imps, imports)
-	} else {
-		imps = make([]SharedSymbolTable, len(imports)+1)
+	// The system symbol table is always import zero, with the rest after it in the
+	// order given. Use V1SystemSymbolTable unless we're given a system table, wherever
+	// in the list it appears.
+	imps := make([]SharedSymbolTable, 1, len(imports)+1)
+	for _, imp := range imports {
+		if imp.Name() != "$ion" {
+			imps = append(imps, imp)
+		} else if imps[0] == nil {
+			imps[0] = imp
+		}
+	}
+	if imps[0] == nil {
 		imps[0] = V1SystemSymbolTable
-		copy(imps[1:], imports)
 	}
 
 	// Calculate offsets.
@@ -1,7 +1,9 @@
 package ion
 
 import (
+	"bytes"
 	"fmt"
+	"reflect"
 	"testing"
 )
 
@@ -37,6 +39,26 @@ func TestSharedSymbolTable(t *testing.T) {
 	testString(t, st, `$ion_shared_symbol_table::{name:"test",version:2,symbols:["abc","def","foo'bar","null","def","ghi"]}`)
 }
 
+func TestSystemSymbols(t *testing.T) {
+	if SystemSymbolTable() != V1SystemSymbolTable {
+		t.Error("expected SystemSymbolTable to return V1SystemSymbolTable")
+	}
+	if sst := SystemSymbolTable(); sst.Name() != "$ion" || sst.Version() != 1 {
+		t.Errorf("expected $ion version 1, got %v version %v", sst.Name(), sst.Version())
+	}
+
+	for id := 1; id <= 9; id++ {
+		if !IsSystemSymbol(id) {
+			t.Errorf("expected $%v to be a system symbol", id)
+		}
+	}
+	for _, id := range []int{-1, 0, 10, 11, 100} {
+		if IsSystemSymbol(id) {
+			t.Errorf("expected $%v not to be a system symbol", id)
+		}
+	}
+}
+
 func TestLocalSymbolTable(t *testing.T) {
 	st := NewLocalSymbolTable(nil, []string{"foo", "bar"})
 
@@ -94,6 +116,42 @@ func TestLocalSymbolTableWithImports(t *testing.T) {
 	testString(t, st, `$ion_symbol_table::{imports:[{name:"shared",version:1,max_id:2}],symbols:["foo2","bar2"]}`)
 }
 
+func TestLocalSymbolTableImportOrder(t *testing.T) {
+	shared := NewSharedSymbolTable("shared", 1, []string{"foo", "bar"})
+
+	// The system symbol table comes first no matter where it's given, and only once.
+	for _, imports := range [][]SharedSymbolTable{
+		{shared},
+		{V1SystemSymbolTable, shared},
+		{shared, V1SystemSymbolTable},
+	} {
+		st := NewLocalSymbolTable(imports, []string{"baz"})
+		if st.MaxID() != 12 {
+			t.Errorf("wrong maxid: %v", st.MaxID())
+		}
+		if imps := st.Imports(); len(imps) != 2 || imps[0].Name() != "$ion" || imps[1] != shared {
+			t.Errorf("expected imports [$ion shared], got %v", imps)
+		}
+
+		testFindByName(t, st, "$ion_shared_symbol_table", 9)
+		testFindByName(t, st, "foo", 10)
+		testFindByName(t, st, "bar", 11)
+		testFindByName(t, st, "baz", 12)
+		testString(t, st, `$ion_symbol_table::{imports:[{name:"shared",version:1,max_id:2}],symbols:["baz"]}`)
+	}
+
+	buf := bytes.Buffer{}
+	w := NewBinaryWriter(&buf, shared, V1SystemSymbolTable)
+	w.WriteSymbol("foo")
+	if err := w.Finish(); err != nil {
+		t.Fatal(err)
+	}
+	eval := []byte{0x71, 0x0A}
+	if val := buf.Bytes()[buf.Len()-len(eval):]; !bytes.Equal(val, eval) {
+		t.Errorf("expected %v, got %v", fmtbytes(eval), fmtbytes(val))
+	}
+}
+
 func TestSymbolTableBuilder(t *testing.T) {
 	b := NewSymbolTableBuilder()
 
@@ -135,6 +193,101 @@ func TestSymbolTableBuilder(t *testing.T) {
 	testFindByID(t, st, 11, "")
 }
 
+func TestSymbolTableBuilderAddAll(t *testing.T) {
+	b := NewSymbolTableBuilder()
+	b.Add("foo")
+
+	ids := b.AddAll([]string{"bar", "foo", "baz", "bar", "name"})
+	eids := []uint64{11, 10, 12, 11, 4}
+	if !reflect.DeepEqual(ids, eids) {
+		t.Errorf("expected %v, got %v", eids, ids)
+	}
+
+	st := b.Build()
+	if st.MaxID() != 12 {
+		t.Errorf("maxid returned %v", st.MaxID())
+	}
+	testFindByID(t, st, 11, "bar")
+	testFindByID(t, st, 12, "baz")
+	testFindByID(t, st, 13, "")
+}
+
+func TestSymbolTableBuilderFromLST(t *testing.T) {
+	imp := NewSharedSymbolTable("table", 1, []string{"a", "b"})
+	prev := NewLocalSymbolTable([]SharedSymbolTable{imp}, []string{"foo", "bar"})
+
+	b := NewSymbolTableBuilderFromLST(prev)
+
+	id, ok := b.Add("bar")
+	if ok {
+		t.Error("Add(bar) returned true")
+	}
+	if id != 13 {
+		t.Errorf("Add(bar) returned %v", id)
+	}
+
+	id, ok = b.Add("baz")
+	if !ok {
+		t.Error("Add(baz) returned false")
+	}
+	if id != 14 {
+		t.Errorf("Add(baz) returned %v", id)
+	}
+
+	id, ok = b.Add("qux")
+	if !ok {
+		t.Error("Add(qux) returned false")
+	}
+	if id != 15 {
+		t.Errorf("Add(qux) returned %v", id)
+	}
+
+	st := b.Build()
+	if st.MaxID() != 15 {
+		t.Errorf("maxid returned %v", st.MaxID())
+	}
+
+	testFindByName(t, st, "$ion", 1)
+	testFindByName(t, st, "b", 11)
+	testFindByName(t, st, "foo", 12)
+	testFindByName(t, st, "bar", 13)
+	testFindByName(t, st, "qux", 15)
+
+	testFindByID(t, st, 10, "a")
+	testFindByID(t, st, 12, "foo")
+	testFindByID(t, st, 14, "baz")
+	testFindByID(t, st, 16, "")
+
+	testString(t, st, `$ion_symbol_table::{imports:[{name:"table",version:1,max_id:2}],symbols:["foo","bar","baz","qux"]}`)
+
+	// The original table is untouched.
+	if prev.MaxID() != 13 {
+		t.Errorf("original maxid changed to %v", prev.MaxID())
+	}
+}
+
+func TestSymbolTableBuilderFromLSTNewDocument(t *testing.T) {
+	prev := NewLocalSymbolTable(nil, []string{"foo", "bar"})
+	b := NewSymbolTableBuilderFromLST(prev)
+	b.Add("baz")
+
+	// The built table is complete, so a new document written with it stands alone.
+	buf := bytes.Buffer{}
+	w := NewBinaryWriterLST(&buf, b.Build())
+	w.WriteSymbol("foo")
+	w.WriteSymbol("bar")
+	w.WriteSymbol("baz")
+	if err := w.Finish(); err != nil {
+		t.Fatal(err)
+	}
+
+	r := NewReaderBytes(buf.Bytes())
+	_symbol(t, r, "foo")
+	_symbol(t, r, "bar")
+	_symbol(t, r, "baz")
+	_eof(t, r)
+}
+
 func testFindByName(t *testing.T, st SymbolTable, sym string, expected uint64) {
 	t.Run("FindByName("+sym+")", func(t *testing.T) {
 		actual, ok := st.FindByName(sym)
@@ -0,0 +1,33 @@
+package ion
+
+import "strconv"
+
+// SymbolIDUnknown is the LocalSID of a SymbolToken whose symbol ID is not known,
+// such as a symbol written out as text in a text Ion stream.
+const SymbolIDUnknown = -1
+
+// A SymbolToken is a symbol as it appears in an Ion stream: its text, if known,
+// along with its local symbol ID, if known. Symbols whose text is not known,
+// such as $0 or a symbol ID that cannot be resolved against the current symbol
+// table, have nil Text.
+type SymbolToken struct {
+	Text     *string
+	LocalSID int64
+}
+
+// NewSymbolTokenText returns a SymbolToken with the given text and an unknown
+// symbol ID.
+func NewSymbolTokenText(text string) SymbolToken {
+	return SymbolToken{&text, SymbolIDUnknown}
+}
+
+// String returns the token's text if it has any, or its $<sid> form if not.
+func (t SymbolToken) String() string {
+	if t.Text != nil {
+		return *t.Text
+	}
+	if t.LocalSID == SymbolIDUnknown {
+		return ""
+	}
+	return "$" + strconv.FormatInt(t.LocalSID, 10)
+}
@@ -3,6 +3,7 @@ package ion
 import (
 	"bufio"
 	"encoding/base64"
+	"encoding/hex"
 	"fmt"
 	"math"
 	"strconv"
@@ -42,15 +43,32 @@ type textReader struct {
 
 	tok   tokenizer
 	state trs
+	lstb  SymbolTableBuilder
+
+	// The text of the current number or timestamp, as written.
+	raw string
+	// Whether the current string was written as a long string.
+	longString bool
 }
 
-func newTextReaderBuf(in *bufio.Reader) Reader {
-	return &textReader{
+func newTextReaderBuf(in *bufio.Reader, opts ...ReaderOption) Reader {
+	t := &textReader{
 		tok: tokenizer{
 			in: in,
 		},
 		state: trsBeforeTypeAnnotations,
 	}
+	t.init(opts)
+	t.tok.keepComments = t.keepComments
+	if t.trackSymbols {
+		t.lstb = NewSymbolTableBuilder()
+	}
+	return t
+}
+
+// Encoding returns FormatText.
+func (t *textReader) Encoding() Format {
+	return FormatText
 }
 
 // SymbolTable returns the current symbol table.
@@ -59,6 +77,43 @@ func (t *textReader) SymbolTable() SymbolTable {
 	return nil
 }
 
+// SymbolTableBuilder returns a copy of the builder holding the symbols seen so far,
+// or an empty builder if the reader wasn't created with WithSymbolTracking.
+func (t *textReader) SymbolTableBuilder() SymbolTableBuilder {
+	if t.lstb == nil {
+		return NewSymbolTableBuilder()
+	}
+	return copySymbolTable(t.lstb)
+}
+
+// AddSymbol adds a symbol's text, if it has any, to the builder, if we're
+// tracking symbols.
+func (t *textReader) addSymbol(sym SymbolToken) {
+	if t.lstb != nil && sym.Text != nil {
+		t.lstb.Add(*sym.Text)
+	}
+}
+
+// ValueBytes is not supported by text readers, which have no binary
+// representation to return.
+func (t *textReader) ValueBytes() ([]byte, error) {
+	return nil, &UsageError{"Reader.ValueBytes", "only supported by binary readers"}
+}
+
+// RawToken returns the text of the current number or timestamp as written.
+func (t *textReader) RawToken() (string, error) {
+	if t.raw == "" {
+		return "", &UsageError{"Reader.RawToken", "not positioned on a number or timestamp"}
+	}
+	return t.raw, nil
+}
+
+// BinaryTypeDescriptor is not supported by text readers, which have no binary
+// representation to describe.
+func (t *textReader) BinaryTypeDescriptor() (byte, bool) {
+	return 0, false
+}
+
 // Next moves the reader to the next value.
 func (t *textReader) Next() bool {
 	if t.state == trsDone || t.eof {
@@ -72,7 +127,13 @@ func (t *textReader) Next() bool {
 		return false
 	}
 
+	// Comments held back from after the current value belong to the next one, but
+	// any inside the part of it we skipped over are dropped.
+	t.tok.comments, t.tok.held = t.tok.held, nil
+
 	t.clear()
+	t.raw = ""
+	t.longString = false
 
 	// Loop until we've consumed enough tokens to know what the next value is.
 	for {
@@ -100,6 +161,8 @@ func (t *textReader) Next() bool {
 		}
 
 		if done {
+			t.comments, t.tok.comments = t.tok.comments, nil
+
 			// We're done reading tokens. If we hit the end of the current sequence,
 			// return false. Otherwise, we've got a value for the caller.
 			return !t.eof
@@ -113,7 +176,8 @@ func (t *textReader) nextAfterValue() (bool, error) {
 	tok := t.tok.Token()
 	switch tok {
 	case tokenComma:
-		// There's another value coming; eat the comma and move to the
+		// There's another value coming, or the end of the container, since
+		// Ion allows a trailing comma; eat the comma and move to the
 		// appropriate next state.
 		switch t.ctx.peek() {
 		case ctxInStruct:
@@ -167,6 +231,7 @@ func (t *textReader) nextBeforeFieldName() (bool, error) {
 				return false, err
 			}
 		}
+		tsym := textSymbolToken(val, tok)
 
 		// Skip over the following colon.
 		if err = t.tok.Next(); err != nil {
@@ -176,7 +241,9 @@ func (t *textReader) nextBeforeFieldName() (bool, error) {
 			return false, &UnexpectedTokenError{tok.String(), t.tok.Pos() - 1}
 		}
 
-		t.fieldName = val
+		t.fieldName = tsym.String()
+		t.fieldNameSym = &tsym
+		t.addSymbol(tsym)
 		t.state = trsBeforeTypeAnnotations
 
 		return false, nil
@@ -223,7 +290,22 @@ func (t *textReader) nextBeforeTypeAnnotations() (bool, error) {
 					return false, err
 				}
 			}
-			t.annotations = append(t.annotations, val)
+			tsym := textSymbolToken(val, tok)
+			t.annotations = append(t.annotations, tsym.String())
+			t.annotationSyms = append(t.annotationSyms, tsym)
+			t.addSymbol(tsym)
+			return false, nil
+		}
+
+		// An unannotated $ion_1_0 at the top level is a version marker rather than a
+		// value, so it resets the symbol table and we move on. With annotations, or
+		// anywhere else, it's just a symbol.
+		if tok == tokenSymbol && val == "$ion_1_0" && len(t.annotations) == 0 && t.ctx.peek() == ctxAtTopLevel {
+			t.state = t.stateAfterValue()
+			if t.lstb != nil {
+				t.lstb = NewSymbolTableBuilder()
+			}
+			t.versionMarker(versionMajor, versionMinor)
 			return false, nil
 		}
 
@@ -242,6 +324,7 @@ func (t *textReader) nextBeforeTypeAnnotations() (bool, error) {
 		t.state = t.stateAfterValue()
 		t.valueType = StringType
 		t.value = val
+		t.longString = tok == tokenLongString
 		return true, nil
 
 	case tokenBinary, tokenHex, tokenNumber, tokenFloatInf, tokenFloatMinusInf:
@@ -307,8 +390,15 @@ func (t *textReader) StepIn() error {
 		return t.err
 	}
 	if t.state != trsBeforeContainer {
+		if t.IsNull() && (t.valueType == ListType || t.valueType == SexpType || t.valueType == StructType) {
+			return &UsageError{"Reader.StepIn", "cannot step in to a null container"}
+		}
 		return &UsageError{"Reader.StepIn", fmt.Sprintf("cannot step in to a %v", t.valueType)}
 	}
+	if err := t.checkDepth(t.tok.Pos()); err != nil {
+		t.explode(err)
+		return err
+	}
 
 	ctx := containerTypeToCtx(t.valueType)
 	t.ctx.push(ctx)
@@ -325,6 +415,11 @@ func (t *textReader) StepIn() error {
 	return nil
 }
 
+// FindField advances to the next field in the current struct with the given name.
+func (t *textReader) FindField(name string) (bool, error) {
+	return findStructField(t, t.ctx.peek(), name)
+}
+
 // StepOut steps out of a container.
 func (t *textReader) StepOut() error {
 	if t.err != nil {
@@ -356,6 +451,7 @@ func (t *textReader) StepOut() error {
 	t.state = t.stateAfterValue()
 	t.clear()
 	t.eof = false
+	t.tok.held = nil
 
 	return nil
 }
@@ -396,16 +492,39 @@ func (t *textReader) onSymbol(val string, tok token, ws bool) error {
 		case "nan":
 			valueType = FloatType
 			value = math.NaN()
+			t.raw = val
 		}
 	}
 
 	t.state = t.stateAfterValue()
 	t.valueType = valueType
 	t.value = value
+	if valueType == SymbolType && value != nil {
+		// Symbol IDs resolve against the system symbol table, so $4 reads as name.
+		t.symbol = textSymbolToken(val, tok)
+		t.value = t.symbol.String()
+		t.addSymbol(t.symbol)
+	}
 
 	return nil
 }
 
+// TextSymbolToken creates a SymbolToken for a symbol read from text. Unquoted
+// symbols of the form $<sid> are symbol IDs; lacking support for local symbol
+// tables, we can only resolve those defined by the system symbol table.
+func textSymbolToken(val string, tok token) SymbolToken {
+	if tok == tokenSymbol && isSymbolRef(val) {
+		id, err := strconv.ParseInt(val[1:], 10, 64)
+		if err == nil {
+			if text, ok := V1SystemSymbolTable.FindByID(uint64(id)); ok {
+				return SymbolToken{&text, id}
+			}
+			return SymbolToken{nil, id}
+		}
+	}
+	return NewSymbolTokenText(val)
+}
+
 // OnNull handles finding a null token.
 func (t *textReader) onNull(ws bool) (Type, error) {
 	if !ws {
@@ -473,6 +592,11 @@ func (t *textReader) onNumber(tok token) error {
 	var valueType Type
 	var value interface{}
 
+	t.tok.startRecording()
+	defer func() {
+		t.raw = t.tok.stopRecording()
+	}()
+
 	switch tok {
 	case tokenBinary:
 		val, err := t.tok.ReadValue(tok)
@@ -524,10 +648,12 @@ func (t *textReader) onNumber(tok token) error {
 	case tokenFloatInf:
 		valueType = FloatType
 		value = math.Inf(1)
+		t.tok.rec = append(t.tok.rec, "+inf"...)
 
 	case tokenFloatMinusInf:
 		valueType = FloatType
 		value = math.Inf(-1)
+		t.tok.rec = append(t.tok.rec, "-inf"...)
 
 	default:
 		panic(fmt.Sprintf("unexpected token type %v", tok))
@@ -542,12 +668,14 @@ func (t *textReader) onNumber(tok token) error {
 
 // OnTimestamp handles finding a timestamp token.
 func (t *textReader) onTimestamp() error {
+	t.tok.startRecording()
 	val, err := t.tok.ReadValue(tokenTimestamp)
+	t.raw = t.tok.stopRecording()
 	if err != nil {
 		return err
 	}
 
-	value, err := parseTimestamp(val)
+	value, precision, err := parseTimestamp(val)
 	if err != nil {
 		return err
 	}
@@ -555,6 +683,7 @@ func (t *textReader) onTimestamp() error {
 	t.state = t.stateAfterValue()
 	t.valueType = TimestampType
 	t.value = value
+	t.precision = precision
 
 	return nil
 }
@@ -606,12 +735,16 @@ func (t *textReader) onLob() error {
 		valType = BlobType
 		t.tok.unread(c)
 
-		b64, err := t.tok.ReadBlob()
+		str, err := t.tok.ReadBlob()
 		if err != nil {
 			return err
 		}
 
-		val, err = base64.StdEncoding.DecodeString(b64)
+		if t.blobHex {
+			val, err = hex.DecodeString(str)
+		} else {
+			val, err = base64.StdEncoding.DecodeString(str)
+		}
 		if err != nil {
 			return err
 		}
@@ -2,8 +2,10 @@ package ion
 
 import (
 	"bytes"
+	"fmt"
 	"math"
 	"math/big"
+	"strings"
 	"testing"
 	"time"
 )
@@ -70,6 +72,12 @@ func TestStructs(t *testing.T) {
 		_symbolAF(t, r, "bar", nil, "b")
 		_symbolAF(t, r, "baz", nil, "c")
 	})
+
+	// Ion allows a trailing comma.
+	test("{foo: a, /* b */ }", func(t *testing.T, r Reader) {
+		_symbolAF(t, r, "foo", nil, "a")
+		_eof(t, r)
+	})
 }
 
 func TestMultipleStructs(t *testing.T) {
@@ -116,6 +124,31 @@ func TestLists(t *testing.T) {
 		_symbolAF(t, r, "", []string{"baz"}, "boop")
 		_eof(t, r)
 	})
+
+	// Ion allows a trailing comma.
+	test("[1, 2, 3,]", func(t *testing.T, r Reader) {
+		_int(t, r, 1)
+		_int(t, r, 2)
+		_int(t, r, 3)
+		_eof(t, r)
+	})
+}
+
+func TestReadBadCommas(t *testing.T) {
+	test := func(str string) {
+		t.Run(str, func(t *testing.T) {
+			if _, err := ReadValues(NewReaderStr(str)); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+
+	test("[,]")
+	test("[1,,2]")
+	test("{,}")
+	test("{a:1,,}")
+	test("(1 2,)")
+	test("1,")
 }
 
 func TestReadNestedLists(t *testing.T) {
@@ -160,6 +193,23 @@ func TestClobs(t *testing.T) {
 	test("{{ \"hello world\" }}", []byte("hello world"))
 	test("{{'''hello world'''}}", []byte("hello world"))
 	test("{{'''hello'''\n'''world'''}}", []byte("helloworld"))
+	test("{{'''it's'''}}", []byte("it's"))
+
+	// Escapes are bytes, not UTF-8 encoded code points.
+	test(`{{"a\xFF\x80\0"}}`, []byte{'a', 0xFF, 0x80, 0})
+	test(`{{ '''b\xe9''' '''c''' }}`, []byte{'b', 0xE9, 'c'})
+	test(`{{"\"\n"}}`, []byte{'"', '\n'})
+
+	// Clobs are ASCII, with no Unicode escapes.
+	for _, str := range []string{`{{"é"}}`, `{{'''é'''}}`, `{{"\u0041"}}`, `{{"\U00000041"}}`} {
+		r := NewReaderStr(str)
+		if r.Next() {
+			t.Errorf("expected no value reading %v", str)
+		}
+		if r.Err() == nil {
+			t.Errorf("expected an error reading %v", str)
+		}
+	}
 }
 
 func TestBlobs(t *testing.T) {
@@ -183,6 +233,22 @@ func TestBlobs(t *testing.T) {
 	test("{{}}", []byte{})
 	test("{{AA==}}", []byte{0})
 	test("{{  SGVsbG8g\r\nV29ybGQ=  }}", []byte("Hello World"))
+
+	// Whitespace is ignored anywhere, even within the padding.
+	test("{{ a G k = }}", []byte("hi"))
+	test("{{YQ= =}}", []byte("a"))
+	test("{{\tYWJj\n}}", []byte("abc"))
+
+	// Padding is required, and invalid base64 is an error.
+	for _, str := range []string{"{{YQ}}", "{{YWI}}", "{{YQ===}}", "{{Y!==}}", "{{YWJj"} {
+		r := NewReaderStr(str)
+		if r.Next() {
+			t.Errorf("expected no value reading %v", str)
+		}
+		if r.Err() == nil {
+			t.Errorf("expected an error reading %v", str)
+		}
+	}
 }
 
 func TestTimestamps(t *testing.T) {
@@ -257,6 +323,22 @@ func TestDecimals(t *testing.T) {
 	testA("  foo :: 'bar' :: 123.  ", []string{"foo", "bar"}, "123")
 }
 
+func TestNegativeZeroDecimals(t *testing.T) {
+	r := NewReaderStr("-0. 0. -0d-1")
+
+	for _, eneg := range []bool{true, false, true} {
+		_next(t, r, DecimalType)
+		val, err := r.DecimalValue()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if val.IsNegativeZero() != eneg {
+			t.Errorf("expected IsNegativeZero=%v for %v", eneg, val)
+		}
+	}
+	_eof(t, r)
+}
+
 func TestFloats(t *testing.T) {
 	testA := func(str string, etas []string, eval float64) {
 		t.Run(str, func(t *testing.T) {
@@ -351,6 +433,248 @@ func TestInts(t *testing.T) {
 	testBigInt("-0x1_FFFF_FFFF_FFFF_FFFF", "-0x1FFFFFFFFFFFFFFFF")
 }
 
+func TestHexAndBinaryInts(t *testing.T) {
+	test := func(str string, esize IntSize, estr string) {
+		t.Run(str, func(t *testing.T) {
+			r := NewReaderStr(str)
+			_next(t, r, IntType)
+
+			size, err := r.IntSize()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if size != esize {
+				t.Errorf("expected size %v, got %v", esize, size)
+			}
+
+			val, err := r.BigIntValue()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if val.String() != estr {
+				t.Errorf("expected %v, got %v", estr, val)
+			}
+
+			_eof(t, r)
+		})
+	}
+
+	test("0xFF", Int32, "255")
+	test("0Xff", Int32, "255")
+	test("-0x10", Int32, "-16")
+	test("0x0", Int32, "0")
+	test("-0x0", Int32, "0")
+	test("0b1010", Int32, "10")
+	test("0B1111_1111", Int32, "255")
+	test("-0b1111_1111", Int32, "-255")
+	test("0x7FFF_FFFF_FFFF_FFFF", Int64, "9223372036854775807")
+	test("-0x8000_0000_0000_0000", Int64, "-9223372036854775808")
+	test("0xFFFF_FFFF_FFFF_FFFF", Uint64, "18446744073709551615")
+	test("0x1_0000_0000_0000_0000", BigInt, "18446744073709551616")
+	test("-0xDEAD_BEEF_DEAD_BEEF_DEAD_BEEF", BigInt, "-68915718021581205938132336367")
+
+	// Digits that don't belong to the radix are errors.
+	for _, str := range []string{"0xG", "0x1G", "0b2", "0b12", "0x", "0b", "-0x", "0x-1"} {
+		r := NewReaderStr(str)
+		if r.Next() {
+			t.Errorf("expected no value reading %v, got %v", str, r.Type())
+		}
+		if r.Err() == nil {
+			t.Errorf("expected an error reading %v", str)
+		}
+	}
+}
+
+func TestIntBoundaries(t *testing.T) {
+	type result struct {
+		i64 string
+		u64 string
+	}
+	test := func(str string, eval result) {
+		t.Run(str, func(t *testing.T) {
+			r := NewReaderStr(str)
+			_next(t, r, IntType)
+
+			// Whatever the size, BigIntValue gets it exactly.
+			bi, err := r.BigIntValue()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if bi.String() != str {
+				t.Errorf("expected %v, got %v", str, bi)
+			}
+
+			i64, err := r.Int64Value()
+			if err != nil {
+				if _, ok := err.(*UsageError); !ok {
+					t.Errorf("expected a UsageError, got %v", err)
+				}
+				if eval.i64 != "" {
+					t.Errorf("expected int64 %v, got %v", eval.i64, err)
+				}
+			} else if fmt.Sprint(i64) != eval.i64 {
+				t.Errorf("expected int64 %v, got %v", eval.i64, i64)
+			}
+
+			u64, err := r.Uint64Value()
+			if err != nil {
+				if _, ok := err.(*UsageError); !ok {
+					t.Errorf("expected a UsageError, got %v", err)
+				}
+				if eval.u64 != "" {
+					t.Errorf("expected uint64 %v, got %v", eval.u64, err)
+				}
+			} else if fmt.Sprint(u64) != eval.u64 {
+				t.Errorf("expected uint64 %v, got %v", eval.u64, u64)
+			}
+		})
+	}
+
+	test("9223372036854775807", result{"9223372036854775807", "9223372036854775807"})
+	test("9223372036854775808", result{"", "9223372036854775808"})
+	test("-9223372036854775808", result{"-9223372036854775808", ""})
+	test("-9223372036854775809", result{"", ""})
+	test("18446744073709551615", result{"", "18446744073709551615"})
+	test("18446744073709551616", result{"", ""})
+	test("-1", result{"-1", ""})
+}
+
+func TestSmallIntBoundaries(t *testing.T) {
+	check := func(t *testing.T, bits int, val int64, err error, ok bool, str string) {
+		if ok {
+			if err != nil {
+				t.Errorf("int%v: %v", bits, err)
+			} else if fmt.Sprint(val) != str {
+				t.Errorf("int%v: expected %v, got %v", bits, str, val)
+			}
+			return
+		}
+		emsg := fmt.Sprintf("value too large for an int%v", bits)
+		if ue, isUE := err.(*UsageError); !isUE || ue.Msg != emsg {
+			t.Errorf("int%v: expected a UsageError saying %q, got %v", bits, emsg, err)
+		}
+	}
+
+	test := func(str string, ok8, ok16, ok32 bool) {
+		t.Run(str, func(t *testing.T) {
+			r := NewReaderStr(str)
+			_next(t, r, IntType)
+
+			i8, err := r.Int8Value()
+			check(t, 8, int64(i8), err, ok8, str)
+			i16, err := r.Int16Value()
+			check(t, 16, int64(i16), err, ok16, str)
+			i32, err := r.Int32Value()
+			check(t, 32, int64(i32), err, ok32, str)
+		})
+	}
+
+	test("0", true, true, true)
+	test("127", true, true, true)
+	test("-128", true, true, true)
+	test("128", false, true, true)
+	test("-129", false, true, true)
+	test("32767", false, true, true)
+	test("-32768", false, true, true)
+	test("32768", false, false, true)
+	test("-32769", false, false, true)
+	test("2147483647", false, false, true)
+	test("-2147483648", false, false, true)
+	test("2147483648", false, false, false)
+	test("-2147483649", false, false, false)
+
+	// Ints too big for Int64Value fail the same way.
+	r := NewReaderStr("0x1_0000_0000_0000_0000")
+	_next(t, r, IntType)
+	if _, err := r.Int8Value(); err == nil {
+		t.Error("expected an error for a big int")
+	}
+
+	r = NewReaderStr("1.5")
+	_next(t, r, DecimalType)
+	if _, err := r.Int32Value(); err == nil {
+		t.Error("expected an error for a decimal")
+	}
+}
+func TestReadSymbolTableBuilder(t *testing.T) {
+	// Without WithSymbolTracking, nothing is collected.
+	r := NewReaderStr("foo::bar")
+	_symbolAF(t, r, "", []string{"foo"}, "bar")
+	if lstb := r.SymbolTableBuilder(); lstb.MaxID() != 9 {
+		t.Errorf("expected an empty builder, got maxid=%v", lstb.MaxID())
+	}
+
+	// Strings aren't symbols, and neither are the contents of skipped containers.
+	r = NewReaderStr(`foo::{bar:baz, name:qux::'quux', skipped:{a:b}, 'foo':"str"} [baz, $10, $4] corge`, WithSymbolTracking())
+
+	_nextAF(t, r, StructType, "", []string{"foo"})
+	if err := r.StepIn(); err != nil {
+		t.Fatal(err)
+	}
+	for r.Next() {
+	}
+	if err := r.StepOut(); err != nil {
+		t.Fatal(err)
+	}
+	_next(t, r, ListType)
+
+	lstb := r.SymbolTableBuilder()
+	if lstb.MaxID() != 15 {
+		t.Errorf("expected maxid=15, got %v", lstb.MaxID())
+	}
+	for id, esym := range map[uint64]string{4: "name", 10: "foo", 11: "bar", 12: "baz", 13: "qux", 14: "quux", 15: "skipped"} {
+		if sym, ok := lstb.FindByID(id); !ok || sym != esym {
+			t.Errorf("expected $%v=%v, got %v", id, esym, sym)
+		}
+	}
+
+	// It's a copy: adding to it doesn't affect the reader's.
+	lstb.Add("grault")
+
+	// Stepping in to the list picks up its symbols, except $10, which has no
+	// text, and $4, which is a system symbol.
+	if err := r.StepIn(); err != nil {
+		t.Fatal(err)
+	}
+	_symbol(t, r, "baz")
+	_next(t, r, SymbolType)
+	_next(t, r, SymbolType)
+	_eof(t, r)
+	if err := r.StepOut(); err != nil {
+		t.Fatal(err)
+	}
+	_symbol(t, r, "corge")
+
+	lstb = r.SymbolTableBuilder()
+	if lstb.MaxID() != 16 {
+		t.Errorf("expected maxid=16, got %v", lstb.MaxID())
+	}
+	if id, ok := lstb.FindByName("corge"); !ok || id != 16 {
+		t.Errorf("expected corge=$16, got %v", id)
+	}
+}
+
+func TestBlobBase64Value(t *testing.T) {
+	r := NewReaderStr(`{{AAEC/f7/}} {{ aGk= }} {{}} null.blob {{"clob"}}`)
+
+	for _, eval := range []string{"AAEC/f7/", "aGk=", "", ""} {
+		_next(t, r, BlobType)
+		val, err := r.BlobBase64Value()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if val != eval {
+			t.Errorf("expected %q, got %q", eval, val)
+		}
+	}
+
+	_next(t, r, ClobType)
+	if _, err := r.BlobBase64Value(); err == nil {
+		t.Error("expected an error for a clob")
+	}
+	_eof(t, r)
+}
+
 func TestStrings(t *testing.T) {
 	r := NewReaderStr(`foo::"bar" "baz" 'a'::'b'::'''beep''' '''boop''' null.string`)
 
@@ -362,6 +686,35 @@ func TestStrings(t *testing.T) {
 	_eof(t, r)
 }
 
+func TestLongStrings(t *testing.T) {
+	r := NewReaderStr("'''one\ntwo\r\n''' '''three'''\n'''\\\nfour''' 'five' '''it's''' '''a '' b''' '''''' 1")
+
+	_string(t, r, "one\ntwo\nthreefour")
+	_symbol(t, r, "five")
+	_string(t, r, "it'sa '' b")
+	_int(t, r, 1)
+
+	_eof(t, r)
+}
+
+func TestLongStringsWithComments(t *testing.T) {
+	in := "'''a''' // '''x'''\n'''b''' /* '''y''' */ '''c''' /* end */ [ '''d''' /**/ '''e''', '''f''' ]"
+
+	r := NewReaderStr(in)
+	_string(t, r, "abc")
+	_list(t, r, func(t *testing.T, r Reader) {
+		_string(t, r, "de")
+		_string(t, r, "f")
+	})
+	_eof(t, r)
+
+	// And skipping over them rather than reading them.
+	r = NewReaderStr(in)
+	_next(t, r, StringType)
+	_next(t, r, ListType)
+	_eof(t, r)
+}
+
 func TestSymbols(t *testing.T) {
 	r := NewReaderStr("'null'::foo bar a::b::'baz' null.symbol")
 
@@ -385,6 +738,89 @@ func TestSpecialSymbols(t *testing.T) {
 	_eof(t, r)
 }
 
+func TestFindField(t *testing.T) {
+	testFindField(t, func(s string) Reader {
+		return NewReaderStr(s)
+	})
+}
+
+// testFindField tests FindField against readers of the given text.
+func testFindField(t *testing.T, read func(string) Reader) {
+	doc := "{a:1, b:2, a:3, c:{a:4}} 5"
+
+	t.Run("present", func(t *testing.T) {
+		r := read(doc)
+		_struct(t, r, func(t *testing.T, r Reader) {
+			_findField(t, r, "b", true)
+			_fieldInt(t, r, "b", 2)
+			_findField(t, r, "c", true)
+			if r.Type() != StructType {
+				t.Errorf("expected struct, got %v", r.Type())
+			}
+		})
+		_int(t, r, 5)
+	})
+
+	t.Run("absent", func(t *testing.T) {
+		r := read(doc)
+		_struct(t, r, func(t *testing.T, r Reader) {
+			_findField(t, r, "d", false)
+			_eof(t, r)
+		})
+		_int(t, r, 5)
+	})
+
+	t.Run("duplicate", func(t *testing.T) {
+		r := read(doc)
+		_struct(t, r, func(t *testing.T, r Reader) {
+			_findField(t, r, "a", true)
+			_fieldInt(t, r, "a", 1)
+			_findField(t, r, "a", true)
+			_fieldInt(t, r, "a", 3)
+			_findField(t, r, "a", false)
+		})
+		_int(t, r, 5)
+	})
+
+	t.Run("not in struct", func(t *testing.T) {
+		r := read(doc)
+		if _, err := r.FindField("a"); err == nil {
+			t.Error("expected an error at top level")
+		}
+	})
+}
+
+func TestSymbolTokens(t *testing.T) {
+	r := NewReaderStr("$0 '$0' $3 {$0:1, 'a':2} $0::$ion::b")
+
+	_next(t, r, SymbolType)
+	_symbolToken(t, r.SymbolValue, nil, 0)
+
+	_next(t, r, SymbolType)
+	_symbolToken(t, r.SymbolValue, _str("$0"), SymbolIDUnknown)
+
+	_next(t, r, SymbolType)
+	_symbolToken(t, r.SymbolValue, _str("$ion_symbol_table"), 3)
+
+	_struct(t, r, func(t *testing.T, r Reader) {
+		_nextAF(t, r, IntType, "$0", nil)
+		_symbolToken(t, _noErr(r.FieldNameSymbol), nil, 0)
+
+		_nextAF(t, r, IntType, "a", nil)
+		_symbolToken(t, _noErr(r.FieldNameSymbol), _str("a"), SymbolIDUnknown)
+	})
+
+	_nextAF(t, r, SymbolType, "", []string{"$0", "$ion"})
+	as := r.AnnotationSymbols()
+	if len(as) != 2 {
+		t.Fatalf("expected 2 annotations, got %v", len(as))
+	}
+	_symbolToken(t, func() (SymbolToken, error) { return as[0], nil }, nil, 0)
+	_symbolToken(t, func() (SymbolToken, error) { return as[1], nil }, _str("$ion"), SymbolIDUnknown)
+
+	_eof(t, r)
+}
+
 func TestOperators(t *testing.T) {
 	r := NewReaderStr("(a*(b+c))")
 
@@ -414,6 +850,403 @@ func TestTopLevelOperators(t *testing.T) {
 	}
 }
 
+func TestReadTextEncoding(t *testing.T) {
+	test := func(name string, r Reader) {
+		t.Run(name, func(t *testing.T) {
+			if f := r.Encoding(); f != FormatText {
+				t.Errorf("expected %v, got %v", FormatText, f)
+			}
+		})
+	}
+
+	test("text", NewReaderStr("{a:1}"))
+	test("empty", NewReaderStr(""))
+	// Not enough bytes for a binary version marker.
+	test("short", NewReaderBytes([]byte{0xE0, 0x01}))
+}
+
+func TestReadTextValueBytes(t *testing.T) {
+	r := NewReaderStr("1")
+	r.Next()
+	if _, err := r.ValueBytes(); err == nil {
+		t.Error("expected an error from a text reader")
+	}
+}
+
+func TestNumbersWithUnderscores(t *testing.T) {
+	test := func(str string, etype Type, eval string) {
+		t.Run(str, func(t *testing.T) {
+			r := NewReaderStr(str)
+			_next(t, r, etype)
+			val, err := r.Value()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if fmt.Sprint(val.Scalar) != eval {
+				t.Errorf("expected %v, got %v", eval, val.Scalar)
+			}
+			_eof(t, r)
+		})
+	}
+
+	test("1_000", IntType, "1000")
+	test("-1_000_000", IntType, "-1000000")
+	test("0x1_F", IntType, "31")
+	test("0b1_0", IntType, "2")
+	test("1_0.5", DecimalType, "10.5")
+	test("1_000.000_1d3", DecimalType, "1000000.1")
+	test("1_0.5e2", FloatType, "1050")
+
+	// Underscores may only go between digits, and not in exponents.
+	for _, str := range []string{
+		"1__0", "1_", "1_.5", "1._5", "1.5_", "0_1", "0x_1", "0x1_", "0b_1", "1_e1", "1e_1", "1e1_0", "1d1_0",
+	} {
+		r := NewReaderStr(str)
+		if r.Next() {
+			t.Errorf("expected no value reading %v, got %v", str, r.Type())
+		}
+		if r.Err() == nil {
+			t.Errorf("expected an error reading %v", str)
+		}
+	}
+}
+
+func TestRawToken(t *testing.T) {
+	r := NewReaderStr("1_000 0x1_F -0b1 1_0.5 1.5e+3 -inf +inf nan 2019-08-04T18:15Z [0X1F] {a:1_0} null.int foo")
+	for _, eval := range []string{"1_000", "0x1_F", "-0b1", "1_0.5", "1.5e+3", "-inf", "+inf", "nan", "2019-08-04T18:15Z"} {
+		if !r.Next() {
+			t.Fatalf("expected %v, got %v", eval, r.Err())
+		}
+		raw, err := r.RawToken()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if raw != eval {
+			t.Errorf("expected %q, got %q", eval, raw)
+		}
+	}
+
+	testRawToken := func(r Reader, eval string) {
+		raw, err := r.RawToken()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if raw != eval {
+			t.Errorf("expected %q, got %q", eval, raw)
+		}
+	}
+
+	r.Next()
+	r.StepIn()
+	r.Next()
+	testRawToken(r, "0X1F")
+	r.StepOut()
+
+	r.Next()
+	r.StepIn()
+	r.Next()
+	testRawToken(r, "1_0")
+	r.StepOut()
+
+	// Nulls and other types have no raw token.
+	for _, etype := range []Type{IntType, SymbolType} {
+		_next(t, r, etype)
+		if _, err := r.RawToken(); err == nil {
+			t.Errorf("expected an error for %v", etype)
+		}
+	}
+	_eof(t, r)
+
+	bin := toBinary(t, "1")
+	r = NewReaderBytes(bin)
+	r.Next()
+	if _, err := r.RawToken(); err == nil {
+		t.Error("expected an error from a binary reader")
+	}
+}
+
+func TestReadMaxDepth(t *testing.T) {
+	deep := strings.Repeat("[", DefaultMaxDepth+1) + strings.Repeat("]", DefaultMaxDepth+1)
+
+	t.Run("default", func(t *testing.T) {
+		r := NewReaderStr(deep)
+		depth, err := _stepInAll(r)
+		if _, ok := err.(*DepthLimitError); !ok {
+			t.Fatalf("expected DepthLimitError, got %v", err)
+		}
+		if depth != DefaultMaxDepth {
+			t.Errorf("expected depth %v, got %v", DefaultMaxDepth, depth)
+		}
+		if r.Next() {
+			t.Error("Next returned true after exceeding max depth")
+		}
+		if r.Err() != err {
+			t.Errorf("expected Err to return %v, got %v", err, r.Err())
+		}
+	})
+
+	t.Run("custom", func(t *testing.T) {
+		r := NewReaderStr("{a:[(b)]}", WithMaxDepth(2))
+		depth, err := _stepInAll(r)
+		if e, ok := err.(*DepthLimitError); !ok || e.Max != 2 {
+			t.Fatalf("expected DepthLimitError{2}, got %v", err)
+		}
+		if depth != 2 {
+			t.Errorf("expected depth 2, got %v", depth)
+		}
+	})
+
+	t.Run("unlimited", func(t *testing.T) {
+		r := NewReaderStr(deep, WithMaxDepth(0))
+		depth, err := _stepInAll(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if depth != DefaultMaxDepth+1 {
+			t.Errorf("expected depth %v, got %v", DefaultMaxDepth+1, depth)
+		}
+	})
+
+	t.Run("skipped", func(t *testing.T) {
+		// Containers that are never stepped in to don't count.
+		r := NewReaderStr(deep+" 1", WithMaxDepth(1))
+		_next(t, r, ListType)
+		_int(t, r, 1)
+		_eof(t, r)
+	})
+}
+
+func TestReadComments(t *testing.T) {
+	in := "// header\r\n/* a */ a:: /* b */ 1 // one\n" +
+		"'''x''' /* x */ '''y''' /* y */ " +
+		"{f: /* f */ 2, g: [/* skipped */ 3] /* g */} 4 /* end */"
+
+	r := NewReaderStr(in, WithComments())
+	_intAF(t, r, "", []string{"a"}, 1)
+	_comments(t, r, "// header", "/* a */", "/* b */")
+	_string(t, r, "xy")
+	_comments(t, r, "// one", "/* x */")
+
+	_next(t, r, StructType)
+	_comments(t, r, "/* y */")
+	if err := r.StepIn(); err != nil {
+		t.Fatal(err)
+	}
+	_intAF(t, r, "f", nil, 2)
+	_comments(t, r, "/* f */")
+	_nextAF(t, r, ListType, "g", nil)
+	_comments(t, r)
+	_eof(t, r)
+	_comments(t, r, "/* g */")
+	if err := r.StepOut(); err != nil {
+		t.Fatal(err)
+	}
+
+	_int(t, r, 4)
+	_comments(t, r)
+	_eof(t, r)
+	_comments(t, r, "/* end */")
+
+	t.Run("default", func(t *testing.T) {
+		r := NewReaderStr(in)
+		for r.Next() {
+			_comments(t, r)
+		}
+		if r.Err() != nil {
+			t.Fatal(r.Err())
+		}
+	})
+}
+
+func TestReadSymbolsAsStrings(t *testing.T) {
+	in := `sym "str" a::'quoted sym' null.symbol [$0]`
+
+	bin := bytes.Buffer{}
+	w := NewBinaryWriter(&bin)
+	for tr := NewReaderStr(in); tr.Next(); {
+		v, err := ReadValue(tr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		v.WriteTo(w)
+	}
+	if err := w.Finish(); err != nil {
+		t.Fatal(err)
+	}
+
+	test := func(name string, r func(opts ...ReaderOption) Reader) {
+		t.Run(name, func(t *testing.T) {
+			rs := r(WithSymbolsAsStrings())
+			_string(t, rs, "sym")
+			if st, err := rs.SymbolValue(); err != nil || st.Text == nil || *st.Text != "sym" {
+				t.Errorf("expected symbol sym, got %v (%v)", st, err)
+			}
+			if v, err := rs.Value(); err != nil || v.Type != StringType || v.Scalar != "sym" {
+				t.Errorf("expected a string Value, got %v (%v)", v, err)
+			}
+			_string(t, rs, "str")
+			_stringAF(t, rs, "", []string{"a"}, "quoted sym")
+			_null(t, rs, StringType)
+			_list(t, rs, func(t *testing.T, r Reader) {
+				_next(t, r, StringType)
+			})
+			_eof(t, rs)
+
+			// Off, they're the symbols they always were.
+			rs = r()
+			_symbol(t, rs, "sym")
+			_string(t, rs, "str")
+			_symbolAF(t, rs, "", []string{"a"}, "quoted sym")
+			_null(t, rs, SymbolType)
+			_list(t, rs, func(t *testing.T, r Reader) {
+				_next(t, r, SymbolType)
+			})
+			_eof(t, rs)
+		})
+	}
+
+	test("text", func(opts ...ReaderOption) Reader {
+		return NewReaderStr(in, opts...)
+	})
+	test("binary", func(opts ...ReaderOption) Reader {
+		return NewReaderBytes(bin.Bytes(), opts...)
+	})
+}
+
+func TestReadVersionMarker(t *testing.T) {
+	r := NewReaderStr("foo $ion_1_0 a::$ion_1_0 '$ion_1_0' [$ion_1_0] ($ion_1_0) $ion_1_0 bar", WithSymbolTracking())
+
+	// A bare version marker isn't a value, and starts a fresh symbol table.
+	_symbol(t, r, "foo")
+	_symbolAF(t, r, "", []string{"a"}, "$ion_1_0")
+
+	// Annotated, quoted, or inside a container, it's just a symbol.
+	_symbol(t, r, "$ion_1_0")
+	_list(t, r, func(t *testing.T, r Reader) {
+		_symbol(t, r, "$ion_1_0")
+	})
+	_sexp(t, r, func(t *testing.T, r Reader) {
+		_symbol(t, r, "$ion_1_0")
+	})
+
+	_symbol(t, r, "bar")
+	if syms := r.SymbolTableBuilder().Symbols(); len(syms) != 1 || syms[0] != "bar" {
+		t.Errorf("expected symbols [bar] after the version marker, got %v", syms)
+	}
+	_eof(t, r)
+
+	// So a::$ion_1_0 means the same as it does in binary, while a bare one is no value at all.
+	bin := bytes.Buffer{}
+	w := NewBinaryWriter(&bin)
+	w.Annotation("a")
+	w.WriteSymbol("$ion_1_0")
+	if err := w.Finish(); err != nil {
+		t.Fatal(err)
+	}
+
+	test := func(a, b string, eeq bool) {
+		eq, err := Equal([]byte(a), []byte(b))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if eq != eeq {
+			t.Errorf("expected Equal(%q, %q) to be %v", a, b, eeq)
+		}
+	}
+	test("$ion_1_0 a::$ion_1_0", bin.String(), true)
+	test("a::$ion_1_0", "$ion_1_0", false)
+	test("$ion_1_0 1 $ion_1_0", "1", true)
+}
+
+func TestReadSystemSymbolIDs(t *testing.T) {
+	syms := V1SystemSymbolTable.Symbols()
+	for i, sym := range syms {
+		id := i + 1
+		t.Run(sym, func(t *testing.T) {
+			in := fmt.Sprintf("$%v $%v::1 {$%v:2}", id, id, id)
+			r := NewReaderStr(in)
+
+			_symbol(t, r, sym)
+			_symbolToken(t, r.SymbolValue, &sym, int64(id))
+			_intAF(t, r, "", []string{sym}, 1)
+			_struct(t, r, func(t *testing.T, r Reader) {
+				_intAF(t, r, sym, nil, 2)
+			})
+			_eof(t, r)
+		})
+	}
+
+	// IDs beyond the system symbol table have no known text, and quoted symbols
+	// are never IDs.
+	r := NewReaderStr("$10 '$4' a::$10::b")
+	_symbol(t, r, "$10")
+	_symbolToken(t, r.SymbolValue, nil, 10)
+	_symbol(t, r, "$4")
+	_symbolToken(t, r.SymbolValue, _str("$4"), SymbolIDUnknown)
+	_symbolAF(t, r, "", []string{"a", "$10"}, "b")
+	_eof(t, r)
+}
+
+func TestReadContainerType(t *testing.T) {
+	in := `{a:[(b {c:[1]} ()) ], d:(x [y, {e:f}])} [{}] (([]))`
+
+	bin := bytes.Buffer{}
+	w := NewBinaryWriter(&bin)
+	for tr := NewReaderStr(in); tr.Next(); {
+		v, err := ReadValue(tr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		v.WriteTo(w)
+	}
+	if err := w.Finish(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Walk the whole tree, checking each value's container against the type of
+	// the value we stepped in to, before and after reading its contents.
+	var walk func(t *testing.T, r Reader, ect Type) int
+	walk = func(t *testing.T, r Reader, ect Type) int {
+		n := 0
+		for r.Next() {
+			n++
+			if ct := r.ContainerType(); ct != ect {
+				t.Errorf("expected container type %v, got %v", ect, ct)
+			}
+			if typ := r.Type(); typ == StructType || typ == ListType || typ == SexpType {
+				if err := r.StepIn(); err != nil {
+					t.Fatal(err)
+				}
+				n += walk(t, r, typ)
+				if ct := r.ContainerType(); ct != typ {
+					t.Errorf("expected container type %v at the end, got %v", typ, ct)
+				}
+				if err := r.StepOut(); err != nil {
+					t.Fatal(err)
+				}
+			}
+		}
+		if err := r.Err(); err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+
+	test := func(name string, r Reader) {
+		t.Run(name, func(t *testing.T) {
+			if ct := r.ContainerType(); ct != NoType {
+				t.Errorf("expected no container type before reading, got %v", ct)
+			}
+			if n := walk(t, r, NoType); n != 19 {
+				t.Errorf("expected 19 values, got %v", n)
+			}
+		})
+	}
+
+	test("text", NewReaderStr(in))
+	test("binary", NewReaderBytes(bin.Bytes()))
+}
+
 func TestTrsToString(t *testing.T) {
 	for i := trsDone; i <= trsAfterValue+1; i++ {
 		str := i.String()
@@ -425,6 +1258,22 @@ func TestTrsToString(t *testing.T) {
 
 type containerhandler func(t *testing.T, r Reader)
 
+// _stepInAll steps in to the first container at each level for as long as it
+// can, returning how deep it got.
+func _stepInAll(r Reader) (int, error) {
+	depth := 0
+	for r.Next() {
+		if r.Type() != ListType && r.Type() != SexpType && r.Type() != StructType {
+			break
+		}
+		if err := r.StepIn(); err != nil {
+			return depth, err
+		}
+		depth++
+	}
+	return depth, r.Err()
+}
+
 func _sexp(t *testing.T, r Reader, f containerhandler) {
 	_sexpAF(t, r, "", nil, f)
 }
@@ -638,6 +1487,18 @@ func _timestampAF(t *testing.T, r Reader, efn string, etas []string, eval time.T
 	}
 }
 
+func _timestampP(t *testing.T, r Reader, eval time.Time, eprec TimestampPrecision) {
+	_timestamp(t, r, eval)
+
+	prec, err := r.TimestampPrecision()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if prec != eprec {
+		t.Errorf("expected precision %v, got %v", eprec, prec)
+	}
+}
+
 func _string(t *testing.T, r Reader, eval string) {
 	_stringAF(t, r, "", nil, eval)
 }
@@ -676,6 +1537,57 @@ func _symbolAF(t *testing.T, r Reader, efn string, etas []string, eval string) {
 	}
 }
 
+func _findField(t *testing.T, r Reader, name string, efound bool) {
+	found, err := r.FindField(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found != efound {
+		t.Fatalf("expected FindField(%v)=%v, got %v", name, efound, found)
+	}
+}
+
+// _fieldInt checks the int field the reader is currently positioned on.
+func _fieldInt(t *testing.T, r Reader, efn string, eval int) {
+	if r.FieldName() != efn {
+		t.Errorf("expected fieldname=%v, got %v", efn, r.FieldName())
+	}
+	val, err := r.IntValue()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if val != eval {
+		t.Errorf("expected %v, got %v", eval, val)
+	}
+}
+
+func _symbolToken(t *testing.T, f func() (SymbolToken, error), etext *string, esid int64) {
+	tok, err := f()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tok.LocalSID != esid {
+		t.Errorf("expected sid %v, got %v", esid, tok.LocalSID)
+	}
+	if etext == nil {
+		if tok.Text != nil {
+			t.Errorf("expected no text, got %v", *tok.Text)
+		}
+	} else if tok.Text == nil || *tok.Text != *etext {
+		t.Errorf("expected text %v, got %v", *etext, tok)
+	}
+}
+
+func _noErr(f func() SymbolToken) func() (SymbolToken, error) {
+	return func() (SymbolToken, error) {
+		return f(), nil
+	}
+}
+
+func _str(s string) *string {
+	return &s
+}
+
 func _bool(t *testing.T, r Reader, eval bool) {
 	_boolAF(t, r, "", nil, eval)
 }
@@ -786,3 +1698,15 @@ func _eof(t *testing.T, r Reader) {
 		t.Fatal(r.Err())
 	}
 }
+
+func _comments(t *testing.T, r Reader, ecs ...string) {
+	cs := r.Comments()
+	if len(cs) != len(ecs) {
+		t.Fatalf("expected comments %q, got %q", ecs, cs)
+	}
+	for i := range ecs {
+		if cs[i] != ecs[i] {
+			t.Errorf("expected comments %q, got %q", ecs, cs)
+		}
+	}
+}
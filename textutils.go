@@ -14,6 +14,9 @@ func symbolNeedsQuoting(sym string) bool {
 	switch sym {
 	case "", "null", "true", "false", "nan":
 		return true
+	case "$ion_1_0":
+		// Unquoted, it would read back as a version marker at the top level.
+		return true
 	}
 
 	if !isIdentifierStart(int(sym[0])) {
@@ -86,6 +89,20 @@ func isDigit(c int) bool {
 	return c >= '0' && c <= '9'
 }
 
+// Can this symbol be written unquoted as an operator inside an sexp? Not if it
+// would read back as the start of a comment.
+func isOperatorSymbol(sym string) bool {
+	if sym == "" || strings.Contains(sym, "//") || strings.Contains(sym, "/*") {
+		return false
+	}
+	for i := 0; i < len(sym); i++ {
+		if !isOperatorChar(int(sym[i])) {
+			return false
+		}
+	}
+	return true
+}
+
 // Is this a valid part of an operator symbol?
 func isOperatorChar(c int) bool {
 	switch c {
@@ -119,6 +136,19 @@ func isWhitespace(c int) bool {
 	return false
 }
 
+// Is this string non-empty and made up entirely of whitespace?
+func isWhitespaceString(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if !isWhitespace(int(c)) {
+			return false
+		}
+	}
+	return true
+}
+
 // Formats a float64 in Ion text style.
 func formatFloat(val float64) string {
 	str := strconv.FormatFloat(val, 'e', -1, 64)
@@ -303,7 +333,8 @@ func parseInt(str string, radix int) (interface{}, error) {
 	return bi, nil
 }
 
-func parseTimestamp(val string) (time.Time, error) {
+// ParseTimestamp parses a timestamp, returning its value and precision.
+func parseTimestamp(val string) (time.Time, TimestampPrecision, error) {
 	if len(val) < 5 {
 		return invalidTimestamp(val)
 	}
@@ -314,7 +345,7 @@ func parseTimestamp(val string) (time.Time, error) {
 	}
 	if len(val) == 5 && (val[4] == 't' || val[4] == 'T') {
 		// yyyyT
-		return time.Date(int(year), 1, 1, 0, 0, 0, 0, time.UTC), nil
+		return time.Date(int(year), 1, 1, 0, 0, 0, 0, time.UTC), TimestampPrecisionYear, nil
 	}
 	if val[4] != '-' {
 		return invalidTimestamp(val)
@@ -331,7 +362,7 @@ func parseTimestamp(val string) (time.Time, error) {
 
 	if len(val) == 8 && (val[7] == 't' || val[7] == 'T') {
 		// yyyy-mmT
-		return time.Date(int(year), time.Month(month), 1, 0, 0, 0, 0, time.UTC), nil
+		return time.Date(int(year), time.Month(month), 1, 0, 0, 0, 0, time.UTC), TimestampPrecisionMonth, nil
 	}
 	if val[7] != '-' {
 		return invalidTimestamp(val)
@@ -348,7 +379,7 @@ func parseTimestamp(val string) (time.Time, error) {
 
 	if len(val) == 10 || (len(val) == 11 && (val[10] == 't' || val[10] == 'T')) {
 		// yyyy-mm-dd or yyyy-mm-ddT
-		return time.Date(int(year), time.Month(month), int(day), 0, 0, 0, 0, time.UTC), nil
+		return time.Date(int(year), time.Month(month), int(day), 0, 0, 0, 0, time.UTC), TimestampPrecisionDay, nil
 	}
 	if val[10] != 't' && val[10] != 'T' {
 		return invalidTimestamp(val)
@@ -358,7 +389,18 @@ func parseTimestamp(val string) (time.Time, error) {
 		return invalidTimestamp(val)
 	}
 	if val[16] != ':' {
-		return time.Parse("2006-01-02T15:04Z07:00", val)
+		t, err := time.Parse("2006-01-02T15:04Z07:00", val)
+		return t, TimestampPrecisionMinute, err
+	}
+
+	if len(val) >= 19 && val[17:19] == "60" {
+		// A leap second, which time.Time can't represent. Normalize it to the
+		// following second, the same way time.Date does for binary timestamps.
+		t, precision, err := parseTimestamp(val[:17] + "59" + val[19:])
+		if err != nil {
+			return t, precision, err
+		}
+		return t.Add(time.Second), precision, nil
 	}
 
 	if len(val) > 19 && val[19] == '.' {
@@ -370,13 +412,37 @@ func parseTimestamp(val string) (time.Time, error) {
 		if i >= 29 {
 			// Too much precision for a go Time.
 			// TODO: We should probably round instead of truncating? Ah well.
-			return time.Parse(time.RFC3339Nano, val[:29]+val[i:])
+			t, err := time.Parse(time.RFC3339Nano, val[:29]+val[i:])
+			return t, TimestampPrecisionNanosecond, err
 		}
+
+		t, err := time.Parse(time.RFC3339Nano, val)
+		return t, TimestampPrecisionNanosecond, err
 	}
 
-	return time.Parse(time.RFC3339Nano, val)
+	t, err := time.Parse(time.RFC3339Nano, val)
+	return t, TimestampPrecisionSecond, err
+}
+
+func invalidTimestamp(val string) (time.Time, TimestampPrecision, error) {
+	return time.Time{}, TimestampNoPrecision, fmt.Errorf("ion: invalid timestamp: %v", val)
 }
 
-func invalidTimestamp(val string) (time.Time, error) {
-	return time.Time{}, fmt.Errorf("ion: invalid timestamp: %v", val)
+// FormatTimestamp formats a timestamp in Ion text style, omitting any
+// components finer than the given precision.
+func formatTimestamp(val time.Time, precision TimestampPrecision) string {
+	switch precision {
+	case TimestampPrecisionYear:
+		return val.Format("2006T")
+	case TimestampPrecisionMonth:
+		return val.Format("2006-01T")
+	case TimestampPrecisionDay:
+		return val.Format("2006-01-02")
+	case TimestampPrecisionMinute:
+		return val.Format("2006-01-02T15:04Z07:00")
+	case TimestampPrecisionSecond:
+		return val.Format(time.RFC3339)
+	default:
+		return val.Format(time.RFC3339Nano)
+	}
 }
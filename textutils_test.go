@@ -7,12 +7,15 @@ import (
 )
 
 func TestParseTimestamp(t *testing.T) {
-	test := func(str string, eval string) {
+	test := func(str string, eval string, eprec TimestampPrecision) {
 		t.Run(str, func(t *testing.T) {
-			val, err := parseTimestamp(str)
+			val, prec, err := parseTimestamp(str)
 			if err != nil {
 				t.Fatal(err)
 			}
+			if prec != eprec {
+				t.Errorf("expected precision %v, got %v", eprec, prec)
+			}
 
 			et, err := time.Parse(time.RFC3339Nano, eval)
 			if err != nil {
@@ -25,17 +28,22 @@ func TestParseTimestamp(t *testing.T) {
 		})
 	}
 
-	test("1234T", "1234-01-01T00:00:00Z")
-	test("1234-05T", "1234-05-01T00:00:00Z")
-	test("1234-05-06", "1234-05-06T00:00:00Z")
-	test("1234-05-06T", "1234-05-06T00:00:00Z")
-	test("1234-05-06T07:08Z", "1234-05-06T07:08:00Z")
-	test("1234-05-06T07:08:09Z", "1234-05-06T07:08:09Z")
-	test("1234-05-06T07:08:09.100Z", "1234-05-06T07:08:09.100Z")
-	test("1234-05-06T07:08:09.100100Z", "1234-05-06T07:08:09.100100Z")
-
-	test("1234-05-06T07:08+09:10", "1234-05-06T07:08:00+09:10")
-	test("1234-05-06T07:08:09-10:11", "1234-05-06T07:08:09-10:11")
+	test("1234T", "1234-01-01T00:00:00Z", TimestampPrecisionYear)
+	test("1234-05T", "1234-05-01T00:00:00Z", TimestampPrecisionMonth)
+	test("1234-05-06", "1234-05-06T00:00:00Z", TimestampPrecisionDay)
+	test("1234-05-06T", "1234-05-06T00:00:00Z", TimestampPrecisionDay)
+	test("1234-05-06T07:08Z", "1234-05-06T07:08:00Z", TimestampPrecisionMinute)
+	test("1234-05-06T07:08:09Z", "1234-05-06T07:08:09Z", TimestampPrecisionSecond)
+	test("1234-05-06T07:08:09.100Z", "1234-05-06T07:08:09.100Z", TimestampPrecisionNanosecond)
+	test("1234-05-06T07:08:09.100100Z", "1234-05-06T07:08:09.100100Z", TimestampPrecisionNanosecond)
+
+	test("1234-05-06T07:08+09:10", "1234-05-06T07:08:00+09:10", TimestampPrecisionMinute)
+	test("1234-05-06T07:08:09-10:11", "1234-05-06T07:08:09-10:11", TimestampPrecisionSecond)
+
+	// Leap seconds are normalized to the following second.
+	test("2016-12-31T23:59:60Z", "2017-01-01T00:00:00Z", TimestampPrecisionSecond)
+	test("2016-12-31T23:59:60.5Z", "2017-01-01T00:00:00.5Z", TimestampPrecisionNanosecond)
+	test("2016-12-31T18:59:60-05:00", "2016-12-31T19:00:00-05:00", TimestampPrecisionSecond)
 }
 
 func TestWriteSymbol(t *testing.T) {
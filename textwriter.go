@@ -2,9 +2,12 @@ package ion
 
 import (
 	"encoding/base64"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"math/big"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -26,18 +29,22 @@ type textWriter struct {
 }
 
 // NewTextWriter returns a new text writer.
-func NewTextWriter(out io.Writer) Writer {
-	return NewTextWriterOpts(out, 0)
+func NewTextWriter(out io.Writer, wopts ...WriterOption) Writer {
+	return NewTextWriterOpts(out, 0, wopts...)
 }
 
 // NewTextWriterOpts returns a new text writer with the given options.
-func NewTextWriterOpts(out io.Writer, opts TextWriterOpts) Writer {
-	return &textWriter{
+func NewTextWriterOpts(out io.Writer, opts TextWriterOpts, wopts ...WriterOption) Writer {
+	w := &textWriter{
 		writer: writer{
 			out: out,
 		},
 		opts: opts,
 	}
+	for _, o := range wopts {
+		o(&w.writer)
+	}
+	return w.wrapErrors(w)
 }
 
 // WriteNull writes an untyped null.
@@ -84,9 +91,27 @@ func (w *textWriter) WriteDecimal(val *Decimal) error {
 	return w.writeValue("Writer.WriteDecimal", val.String())
 }
 
+// WriteMoney writes an annotated monetary amount.
+func (w *textWriter) WriteMoney(annotation string, amount string) error {
+	d, err := w.parseMoney(amount)
+	if err != nil {
+		return err
+	}
+	w.Annotation(annotation)
+	return w.WriteDecimal(d)
+}
+
 // WriteTimestamp writes a timestamp.
 func (w *textWriter) WriteTimestamp(val time.Time) error {
-	return w.writeValue("Writer.WriteTimestamp", val.Format(time.RFC3339Nano))
+	return w.writeValue("Writer.WriteTimestamp", formatTimestamp(val, TimestampPrecisionNanosecond))
+}
+
+// WriteTimestampWithPrecision writes a timestamp with the given precision.
+func (w *textWriter) WriteTimestampWithPrecision(val time.Time, precision TimestampPrecision) error {
+	if w.err == nil {
+		w.err = checkPrecision("Writer.WriteTimestampWithPrecision", precision)
+	}
+	return w.writeValue("Writer.WriteTimestampWithPrecision", formatTimestamp(val, precision))
 }
 
 // WriteSymbol writes a symbol.
@@ -94,18 +119,37 @@ func (w *textWriter) WriteSymbol(val string) error {
 	if w.err != nil {
 		return w.err
 	}
+
+	// Operators can go unquoted inside an sexp, where the reader knows to expect them.
+	op := w.ctx.peek() == ctxInSexp && isOperatorSymbol(val)
+
 	if w.err = w.beginValue("Writer.WriteSymbol"); w.err != nil {
 		return w.err
 	}
 
-	if w.err = writeSymbol(val, w.out); w.err != nil {
+	if op {
+		w.err = writeRawString(val, w.out)
+	} else {
+		w.err = writeSymbol(val, w.out)
+	}
+	if w.err != nil {
 		return w.err
 	}
 
-	w.endValue()
+	w.err = w.endValue()
+	return w.err
+}
+
+// SymbolTable returns nil, since text writers don't have one.
+func (w *textWriter) SymbolTable() SymbolTable {
 	return nil
 }
 
+// WriteSymbolByID writes a symbol by its $<id> form.
+func (w *textWriter) WriteSymbolByID(id uint64) error {
+	return w.writeValue("Writer.WriteSymbolByID", fmt.Sprintf("$%v", id))
+}
+
 // WriteString writes a string.
 func (w *textWriter) WriteString(val string) error {
 	if w.err != nil {
@@ -125,8 +169,47 @@ func (w *textWriter) WriteString(val string) error {
 		return w.err
 	}
 
-	w.endValue()
-	return nil
+	w.err = w.endValue()
+	return w.err
+}
+
+// WriteIntList writes a list of integer values.
+func (w *textWriter) WriteIntList(vals []int64) error {
+	if err := w.BeginList(); err != nil {
+		return err
+	}
+	for _, val := range vals {
+		if err := w.writeValue("Writer.WriteIntList", strconv.FormatInt(val, 10)); err != nil {
+			return err
+		}
+	}
+	return w.EndList()
+}
+
+// WriteFloatList writes a list of floating-point values.
+func (w *textWriter) WriteFloatList(vals []float64) error {
+	if err := w.BeginList(); err != nil {
+		return err
+	}
+	for _, val := range vals {
+		if err := w.writeValue("Writer.WriteFloatList", formatFloat(val)); err != nil {
+			return err
+		}
+	}
+	return w.EndList()
+}
+
+// WriteStringList writes a list of strings.
+func (w *textWriter) WriteStringList(vals []string) error {
+	if err := w.BeginList(); err != nil {
+		return err
+	}
+	for _, val := range vals {
+		if err := w.WriteString(val); err != nil {
+			return err
+		}
+	}
+	return w.EndList()
 }
 
 // WriteClob writes a clob.
@@ -134,7 +217,7 @@ func (w *textWriter) WriteClob(val []byte) error {
 	if w.err != nil {
 		return w.err
 	}
-	if w.err = w.beginValue("Writer.WriteBlob"); w.err != nil {
+	if w.err = w.beginValue("Writer.WriteClob"); w.err != nil {
 		return w.err
 	}
 
@@ -142,7 +225,7 @@ func (w *textWriter) WriteClob(val []byte) error {
 		return w.err
 	}
 	for _, c := range val {
-		if c < 32 || c == '\\' || c == '"' || c > 0x7F {
+		if c < 32 || c == '\\' || c == '"' || c >= 0x7F {
 			if err := writeEscapedChar(c, w.out); err != nil {
 				return err
 			}
@@ -156,8 +239,8 @@ func (w *textWriter) WriteClob(val []byte) error {
 		return w.err
 	}
 
-	w.endValue()
-	return nil
+	w.err = w.endValue()
+	return w.err
 }
 
 // WriteBlob writes a blob.
@@ -173,18 +256,73 @@ func (w *textWriter) WriteBlob(val []byte) error {
 		return w.err
 	}
 
-	enc := base64.NewEncoder(base64.StdEncoding, w.out)
-	enc.Write(val)
-	if w.err = enc.Close(); w.err != nil {
-		return w.err
+	if w.blobHex {
+		if w.err = writeRawString(hex.EncodeToString(val), w.out); w.err != nil {
+			return w.err
+		}
+	} else {
+		enc := base64.NewEncoder(base64.StdEncoding, w.out)
+		enc.Write(val)
+		if w.err = enc.Close(); w.err != nil {
+			return w.err
+		}
 	}
 
 	if w.err = writeRawString("}}", w.out); w.err != nil {
 		return w.err
 	}
 
-	w.endValue()
-	return nil
+	w.err = w.endValue()
+	return w.err
+}
+
+// WriteBlobString writes a blob given as a base64 string.
+func (w *textWriter) WriteBlobString(base64Str string) error {
+	val, err := w.parseBlobString(base64Str)
+	if err != nil {
+		return err
+	}
+	return w.WriteBlob(val)
+}
+
+// WriteRawText writes the given Ion text verbatim, once it's been checked.
+func (w *textWriter) WriteRawText(s string) error {
+	if w.err != nil {
+		return w.err
+	}
+
+	val, long, err := parseRawText(s)
+	if err != nil {
+		w.err = err
+		return w.err
+	}
+	if w.err = w.checkAnnotations("Writer.WriteRawText", val.Annotations...); w.err != nil {
+		return w.err
+	}
+
+	// Long strings next to each other run together into one.
+	if long {
+		w.err = &UsageError{"Writer.WriteRawText", "value is a long string"}
+		return w.err
+	}
+
+	s = strings.TrimSpace(s)
+
+	// A line comment would swallow whatever we write after it on the same line;
+	// it's harmless to end the line whether or not there really is one.
+	if strings.Contains(s, "//") {
+		s += "\n"
+	}
+
+	return w.writeValue("Writer.WriteRawText", s)
+}
+
+// WritePadding is not supported by text writers, which have no padding to write.
+func (w *textWriter) WritePadding(n int) error {
+	if w.err == nil {
+		w.err = &UsageError{"Writer.WritePadding", "only supported by binary writers"}
+	}
+	return w.err
 }
 
 // BeginList begins writing a list.
@@ -244,7 +382,9 @@ func (w *textWriter) Finish() error {
 		return &UsageError{"Writer.Finish", "not at top level"}
 	}
 
-	if w.opts&TextWriterQuietFinish == 0 {
+	// If there's a top-level separator, it's already been written after the
+	// last value.
+	if w.opts&TextWriterQuietFinish == 0 && w.topLevelSep == "" {
 		if w.err = writeRawChar('\n', w.out); w.err != nil {
 			return w.err
 		}
@@ -252,7 +392,8 @@ func (w *textWriter) Finish() error {
 	}
 
 	w.clear()
-	return nil
+	w.err = w.finalize()
+	return w.err
 }
 
 // writeValue writes a stringified value to the output stream.
@@ -268,15 +409,25 @@ func (w *textWriter) writeValue(api string, val string) error {
 		return w.err
 	}
 
-	w.endValue()
-	return nil
+	w.err = w.endValue()
+	return w.err
 }
 
 // beginValue begins the process of writing a value, by writing out
 // a separator (if needed), field name (if in a struct), and type
 // annotations (if any).
 func (w *textWriter) beginValue(api string) error {
-	if w.needsSeparator {
+	if w.pretty && w.ctx.peek() != ctxAtTopLevel {
+		// Every value in a container goes on its own line.
+		if w.needsSeparator && w.ctx.peek() != ctxInSexp {
+			if err := writeRawChar(',', w.out); err != nil {
+				return err
+			}
+		}
+		if err := w.writeIndent(len(w.ctx.arr)); err != nil {
+			return err
+		}
+	} else if w.needsSeparator {
 		var sep byte
 		switch w.ctx.peek() {
 		case ctxInStruct, ctxInList:
@@ -324,9 +475,28 @@ func (w *textWriter) beginValue(api string) error {
 	return nil
 }
 
-// endValue finishes the process of writing a value.
-func (w *textWriter) endValue() {
+// writeIndent starts a new line, indented to the given depth.
+func (w *textWriter) writeIndent(depth int) error {
+	if err := writeRawChar('\n', w.out); err != nil {
+		return err
+	}
+	for i := 0; i < depth; i++ {
+		if err := writeRawString(w.indent, w.out); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// endValue finishes the process of writing a value, writing out the
+// top-level separator if there is one and we're at the top level.
+func (w *textWriter) endValue() error {
+	if w.topLevelSep != "" && w.ctx.peek() == ctxAtTopLevel {
+		w.needsSeparator = false
+		return writeRawString(w.topLevelSep, w.out)
+	}
 	w.needsSeparator = true
+	return nil
 }
 
 // begin starts writing a container of the given type.
@@ -347,13 +517,19 @@ func (w *textWriter) end(api string, t ctx, c byte) error {
 		return &UsageError{api, "not in that kind of container"}
 	}
 
+	if w.pretty && w.needsSeparator {
+		// Put the end of a non-empty container on its own line.
+		if err := w.writeIndent(len(w.ctx.arr) - 1); err != nil {
+			return err
+		}
+	}
+
 	if err := writeRawChar(c, w.out); err != nil {
 		return err
 	}
 
 	w.clear()
 	w.ctx.pop()
-	w.endValue()
 
-	return nil
+	return w.endValue()
 }
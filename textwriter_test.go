@@ -1,6 +1,9 @@
 package ion
 
 import (
+	"encoding/base64"
+	"fmt"
+	"hash/crc32"
 	"math"
 	"math/big"
 	"strings"
@@ -236,6 +239,40 @@ func TestWriteTextDecimal(t *testing.T) {
 	})
 }
 
+func TestWriteTextMoney(t *testing.T) {
+	expected := "USD::12.50\n[EUR::-5d-2,JPY::1000.]"
+	testTextWriter(t, expected, func(w Writer) {
+		w.WriteMoney("USD", "12.50")
+		w.BeginList()
+		w.WriteMoney("EUR", "-0.05")
+		w.WriteMoney("JPY", "1000")
+		w.EndList()
+	})
+
+	w := NewTextWriter(&strings.Builder{})
+	if err := w.WriteMoney("USD", "twelve"); err == nil {
+		t.Error("expected an error writing an invalid amount")
+	}
+	if err := w.Finish(); err == nil {
+		t.Error("expected the error to be remembered")
+	}
+}
+
+func TestWriteTextNegativeZeroDecimal(t *testing.T) {
+	r := NewReaderStr("-0. 0. -0.00")
+
+	expected := "-0.\n0.\n-0d-2"
+	testTextWriter(t, expected, func(w Writer) {
+		for r.Next() {
+			val, err := r.DecimalValue()
+			if err != nil {
+				t.Fatal(err)
+			}
+			w.WriteDecimal(val)
+		}
+	})
+}
+
 func TestWriteTextTimestamp(t *testing.T) {
 	expected := "1970-01-01T00:00:00.001Z\n1970-01-01T01:23:00+01:23"
 	testTextWriter(t, expected, func(w Writer) {
@@ -244,6 +281,34 @@ func TestWriteTextTimestamp(t *testing.T) {
 	})
 }
 
+func TestWriteTextTimestampWithPrecision(t *testing.T) {
+	val := time.Date(2020, 6, 15, 12, 30, 45, 500000000, time.FixedZone("wtf", 7200))
+
+	expected := "2020T\n2020-06T\n2020-06-15\n2020-06-15T12:30+02:00\n" +
+		"2020-06-15T12:30:45+02:00\n2020-06-15T12:30:45.5+02:00"
+	testTextWriter(t, expected, func(w Writer) {
+		w.WriteTimestampWithPrecision(val, TimestampPrecisionYear)
+		w.WriteTimestampWithPrecision(val, TimestampPrecisionMonth)
+		w.WriteTimestampWithPrecision(val, TimestampPrecisionDay)
+		w.WriteTimestampWithPrecision(val, TimestampPrecisionMinute)
+		w.WriteTimestampWithPrecision(val, TimestampPrecisionSecond)
+		w.WriteTimestampWithPrecision(val, TimestampPrecisionNanosecond)
+	})
+
+	r := NewReaderStr(writeText(func(w Writer) {
+		w.WriteTimestampWithPrecision(val, TimestampPrecisionYear)
+	}))
+	_timestampP(t, r, time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), TimestampPrecisionYear)
+	_eof(t, r)
+
+	writeText(func(w Writer) {
+		err := w.WriteTimestampWithPrecision(val, TimestampNoPrecision)
+		if _, ok := err.(*UsageError); !ok {
+			t.Errorf("expected a UsageError, got %v", err)
+		}
+	})
+}
+
 func TestWriteTextSymbol(t *testing.T) {
 	expected := "{foo:bar,empty:'','null':'null',f:a::b::u::'lo🇺🇸',$123:$456}"
 	testTextWriter(t, expected, func(w Writer) {
@@ -269,6 +334,72 @@ func TestWriteTextSymbol(t *testing.T) {
 	})
 }
 
+func TestWriteTextSymbolVersionMarker(t *testing.T) {
+	expected := "'$ion_1_0'\n['$ion_1_0']"
+	testTextWriter(t, expected, func(w Writer) {
+		w.WriteSymbol("$ion_1_0")
+		w.BeginList()
+		w.WriteSymbol("$ion_1_0")
+		w.EndList()
+	})
+}
+
+func TestWriteTextSexpOperators(t *testing.T) {
+	ops := []string{"+", "++", "&&", "-", ".", "...", "<=", "//", "/*", "*/", "+a"}
+
+	buf := strings.Builder{}
+	w := NewTextWriter(&buf)
+	w.BeginSexp()
+	w.WriteSymbol("a")
+	for _, op := range ops {
+		w.WriteSymbol(op)
+	}
+	w.WriteInt(1)
+	w.Annotation("x")
+	w.WriteSymbol("+")
+	w.EndSexp()
+	w.BeginList()
+	w.WriteSymbol("+")
+	w.EndList()
+	w.WriteSymbol("&&")
+	if err := w.Finish(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Quoted outside of an sexp, or where it would look like a comment.
+	expected := "(a + ++ && - . ... <= '//' '/*' */ '+a' 1 x::+)\n['+']\n'&&'\n"
+	if buf.String() != expected {
+		t.Fatalf("expected %q, got %q", expected, buf.String())
+	}
+
+	r := NewReaderStr(buf.String())
+	_sexp(t, r, func(t *testing.T, r Reader) {
+		_symbol(t, r, "a")
+		for _, op := range ops {
+			_symbol(t, r, op)
+		}
+		_int(t, r, 1)
+		_symbolAF(t, r, "", []string{"x"}, "+")
+	})
+	_list(t, r, func(t *testing.T, r Reader) {
+		_symbol(t, r, "+")
+	})
+	_symbol(t, r, "&&")
+	_eof(t, r)
+}
+
+func TestWriteTextSymbolZero(t *testing.T) {
+	expected := "$0\n{$0:$0::$0}"
+	testTextWriter(t, expected, func(w Writer) {
+		w.WriteSymbolByID(0)
+		w.BeginStruct()
+		w.FieldName("$0")
+		w.Annotation("$0")
+		w.WriteSymbolByID(0)
+		w.EndStruct()
+	})
+}
+
 func TestWriteTextString(t *testing.T) {
 	expected := `("hello" "" ("\\\"\n\"\\" zany::"🤪"))`
 	testTextWriter(t, expected, func(w Writer) {
@@ -296,6 +427,183 @@ func TestWriteTextBlob(t *testing.T) {
 	})
 }
 
+func TestWriteTextBlobRoundTrip(t *testing.T) {
+	// Lengths that need two, one, and no padding characters.
+	for n := 0; n < 10; n++ {
+		val := make([]byte, n)
+		for i := range val {
+			val[i] = byte(0xFF - i*37)
+		}
+
+		buf := strings.Builder{}
+		w := NewTextWriter(&buf)
+		w.WriteBlob(val)
+		if err := w.Finish(); err != nil {
+			t.Fatal(err)
+		}
+
+		eval := "{{" + base64.StdEncoding.EncodeToString(val) + "}}\n"
+		if buf.String() != eval {
+			t.Errorf("expected %q, got %q", eval, buf.String())
+		}
+
+		r := NewReaderStr(buf.String())
+		_blob(t, r, val)
+		_eof(t, r)
+	}
+}
+
+func TestWriteTextBlobString(t *testing.T) {
+	expected := "{{AAEC/f7/}}\n{{aGk=}}\n{{aGkh}}\n{{}}"
+	testTextWriter(t, expected, func(w Writer) {
+		w.WriteBlobString("AAEC/f7/")
+		w.WriteBlobString("aGk=")
+		w.WriteBlobString("aGkh")
+		w.WriteBlobString("")
+	})
+
+	// The standard alphabet with padding, and nothing else.
+	for _, str := range []string{"AAEC_f7_", "aGk", "a G k =", "!!!!"} {
+		buf := strings.Builder{}
+		w := NewTextWriter(&buf)
+		if _, ok := w.WriteBlobString(str).(*UsageError); !ok {
+			t.Errorf("expected a UsageError for %q", str)
+		}
+		if err := w.WriteInt(1); err == nil {
+			t.Errorf("expected the error for %q to stick", str)
+		}
+	}
+}
+
+func TestWriteTextLists(t *testing.T) {
+	testTextWriter(t, "[1,-2,0]\n[]\n{a:b::[1.5e+0,0e+0],c:[\"x\",\"y\\n\"]}", func(w Writer) {
+		w.WriteIntList([]int64{1, -2, 0})
+		w.WriteStringList(nil)
+		w.BeginStruct()
+		w.FieldName("a")
+		w.Annotation("b")
+		w.WriteFloatList([]float64{1.5, 0})
+		w.FieldName("c")
+		w.WriteStringList([]string{"x", "y\n"})
+		w.EndStruct()
+	})
+
+	buf := strings.Builder{}
+	w := NewTextWriter(&buf, WithIndent("  "))
+	w.WriteIntList([]int64{1, 2})
+	if err := w.Finish(); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "[\n  1,\n  2\n]\n" {
+		t.Errorf("unexpected pretty output %q", buf.String())
+	}
+}
+
+func TestWriteTextRawText(t *testing.T) {
+	expected := "[{a:1,b:[2]},x::y::(+ 1 2),3]\n{f:\"short\" /* c */,g:z::\"s\" // c\n}"
+	testTextWriter(t, expected, func(w Writer) {
+		w.BeginList()
+		w.WriteRawText("  {a:1,b:[2]}\n")
+		w.Annotation("x")
+		w.WriteRawText("y::(+ 1 2)")
+		w.WriteInt(3)
+		w.EndList()
+
+		w.BeginStruct()
+		w.FieldName("f")
+		w.WriteRawText(`"short" /* c */`)
+		w.FieldName("g")
+		w.WriteRawText(`z::"s" // c`)
+		w.EndStruct()
+	})
+
+	// Only a long string as the value itself would run together with the next.
+	testTextWriter(t, `["it'''s",['''a'''],{a:'''b'''}]`, func(w Writer) {
+		w.BeginList()
+		w.WriteRawText(`"it'''s"`)
+		w.WriteRawText(`['''a''']`)
+		w.WriteRawText(`{a:'''b'''}`)
+		w.EndList()
+	})
+
+	for _, str := range []string{"", "// nothing", "1 2", "{a:", "{a 1}", "'''a'''", "'''a''' '''b'''", "x::'''a'''",
+		"$ion_1_0", "$ion_1_0 1", "1 $ion_1_0", `$ion_symbol_table::{symbols:["a"]}`} {
+		buf := strings.Builder{}
+		w := NewTextWriter(&buf)
+		if _, ok := w.WriteRawText(str).(*UsageError); !ok {
+			t.Errorf("expected a UsageError for %q", str)
+		}
+		if err := w.WriteInt(1); err == nil {
+			t.Errorf("expected the error for %q to stick", str)
+		}
+	}
+}
+
+func TestWriteTextClobRoundTrip(t *testing.T) {
+	val := make([]byte, 256)
+	for i := range val {
+		val[i] = byte(i)
+	}
+
+	buf := strings.Builder{}
+	w := NewTextWriter(&buf)
+	w.WriteClob(val)
+	w.WriteClob([]byte(`a "quoted" clob`))
+	if err := w.Finish(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Everything but printable ASCII is escaped.
+	for i := 0; i < buf.Len(); i++ {
+		if c := buf.String()[i]; (c < 0x20 && c != '\n') || c >= 0x7F {
+			t.Fatalf("unescaped byte %#x in %q", c, buf.String())
+		}
+	}
+	if !strings.Contains(buf.String(), `{{"a \"quoted\" clob"}}`) {
+		t.Errorf("expected the second clob as is, got %q", buf.String())
+	}
+
+	r := NewReaderStr(buf.String())
+	_clob(t, r, val)
+	_clob(t, r, []byte(`a "quoted" clob`))
+	_eof(t, r)
+}
+
+func TestWriteTextBlobHex(t *testing.T) {
+	buf := strings.Builder{}
+	w := NewTextWriter(&buf, WithBlobHex())
+	w.WriteBlob([]byte{0, 1, 2, 0xFD, 0xFE, 0xFF})
+	w.Annotation("empty")
+	w.WriteBlob(nil)
+	w.WriteClob([]byte("clob"))
+	if err := w.Finish(); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "{{000102fdfeff}}\nempty::{{}}\n{{\"clob\"}}\n"
+	if buf.String() != expected {
+		t.Errorf("expected %q, got %q", expected, buf.String())
+	}
+
+	r := NewReaderStr(buf.String(), WithBlobHexInput())
+	_blob(t, r, []byte{0, 1, 2, 0xFD, 0xFE, 0xFF})
+	_blobAF(t, r, "", []string{"empty"}, []byte{})
+	_clob(t, r, []byte("clob"))
+	_eof(t, r)
+
+	// Spaces are allowed between digits, just as with base64.
+	r = NewReaderStr("{{ 00 01\n02 }}", WithBlobHexInput())
+	_blob(t, r, []byte{0, 1, 2})
+
+	r = NewReaderStr("{{000}}", WithBlobHexInput())
+	if r.Next() {
+		t.Error("expected an error for an odd number of hex digits")
+	}
+	if r.Err() == nil {
+		t.Error("expected an error for an odd number of hex digits")
+	}
+}
+
 func TestWriteTextClob(t *testing.T) {
 	expected := "{hello:{{\"world\"}},bits:{{\"\\0\\x01\\xFE\\xFF\"}}}"
 	testTextWriter(t, expected, func(w Writer) {
@@ -334,6 +642,218 @@ func TestWriteTextBadFinish(t *testing.T) {
 	}
 }
 
+func TestWriteTextTopLevelSeparator(t *testing.T) {
+	test := func(sep, expected string) {
+		t.Run(strings.Replace(sep, "\n", "\\n", -1), func(t *testing.T) {
+			buf := strings.Builder{}
+			w := NewTextWriter(&buf, WithTopLevelSeparator(sep))
+
+			w.WriteInt(1)
+			w.BeginStruct()
+			w.FieldName("a")
+			w.BeginList()
+			w.WriteInt(2)
+			w.WriteInt(3)
+			w.EndList()
+			w.EndStruct()
+			w.BeginSexp()
+			w.WriteSymbol("b")
+			w.WriteSymbol("c")
+			w.EndSexp()
+			w.WriteString("d")
+			if err := w.Finish(); err != nil {
+				t.Fatal(err)
+			}
+
+			actual := buf.String()
+			if actual != expected {
+				t.Errorf("expected %q, got %q", expected, actual)
+			}
+
+			r := NewReaderStr(actual)
+			n := 0
+			for r.Next() {
+				n++
+			}
+			if r.Err() != nil {
+				t.Fatal(r.Err())
+			}
+			if n != 4 {
+				t.Errorf("expected 4 values, got %v", n)
+			}
+		})
+	}
+
+	test("\n", "1\n{a:[2,3]}\n(b c)\n\"d\"\n")
+	test(" ", "1 {a:[2,3]} (b c) \"d\" ")
+	test("\r\n", "1\r\n{a:[2,3]}\r\n(b c)\r\n\"d\"\r\n")
+}
+
+func TestWriteTextBadTopLevelSeparator(t *testing.T) {
+	test := func(sep string) {
+		t.Run(sep, func(t *testing.T) {
+			buf := strings.Builder{}
+			w := NewTextWriter(&buf, WithTopLevelSeparator(sep))
+
+			err := w.WriteInt(1)
+			if _, ok := err.(*UsageError); !ok {
+				t.Errorf("expected a UsageError, got %v", err)
+			}
+			if buf.Len() != 0 {
+				t.Errorf("expected no output, got %q", buf.String())
+			}
+		})
+	}
+
+	test("")
+	test(",")
+	test(" x ")
+}
+
+func TestWriteTextFinalizeHook(t *testing.T) {
+	buf := strings.Builder{}
+	calls := 0
+	w := NewTextWriter(&buf, WithFinalizeHook(func(doc []byte) []byte {
+		calls++
+		return append(doc, fmt.Sprintf("// crc32: %08x\n", crc32.ChecksumIEEE(doc))...)
+	}))
+
+	w.WriteSymbol("foo")
+	w.WriteInt(42)
+	if buf.Len() != 0 {
+		t.Errorf("expected nothing to be written before Finish, got %q", buf.String())
+	}
+	if err := w.Finish(); err != nil {
+		t.Fatal(err)
+	}
+
+	w.WriteString("bar")
+	if err := w.Finish(); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "foo\n42\n// crc32: " + fmt.Sprintf("%08x", crc32.ChecksumIEEE([]byte("foo\n42\n"))) + "\n" +
+		"\"bar\"\n// crc32: " + fmt.Sprintf("%08x", crc32.ChecksumIEEE([]byte("\"bar\"\n"))) + "\n"
+	if buf.String() != expected {
+		t.Errorf("expected %q, got %q", expected, buf.String())
+	}
+	if calls != 2 {
+		t.Errorf("expected 2 calls to the hook, got %v", calls)
+	}
+
+	// It's still valid Ion.
+	vs, err := ReadValues(NewReaderStr(buf.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(vs) != 3 {
+		t.Errorf("expected 3 values, got %v", len(vs))
+	}
+}
+
+func TestWriteTextIndent(t *testing.T) {
+	test := func(indent, expected string) {
+		t.Run(strings.Replace(indent, "\t", "\\t", -1), func(t *testing.T) {
+			buf := strings.Builder{}
+			w := NewTextWriter(&buf, WithIndent(indent))
+
+			w.Annotation("a")
+			w.BeginStruct()
+			{
+				w.FieldName("b")
+				w.WriteInt(1)
+				w.FieldName("c")
+				w.BeginList()
+				{
+					w.WriteString("d")
+					w.BeginSexp()
+					w.WriteSymbol("e")
+					w.WriteSymbol("f")
+					w.EndSexp()
+					w.BeginStruct()
+					w.EndStruct()
+				}
+				w.EndList()
+			}
+			w.EndStruct()
+			w.WriteInt(2)
+			if err := w.Finish(); err != nil {
+				t.Fatal(err)
+			}
+
+			actual := buf.String()
+			if actual != expected {
+				t.Errorf("expected:\n%v\ngot:\n%v", expected, actual)
+			}
+
+			eq, err := Equal([]byte(actual), []byte(writeText(func(w Writer) {
+				w.Annotation("a")
+				w.BeginStruct()
+				w.FieldName("b")
+				w.WriteInt(1)
+				w.FieldName("c")
+				w.BeginList()
+				w.WriteString("d")
+				w.BeginSexp()
+				w.WriteSymbol("e")
+				w.WriteSymbol("f")
+				w.EndSexp()
+				w.BeginStruct()
+				w.EndStruct()
+				w.EndList()
+				w.EndStruct()
+				w.WriteInt(2)
+			})))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !eq {
+				t.Error("expected pretty output to be equivalent to regular output")
+			}
+		})
+	}
+
+	test("\t", "a::{\n\tb:1,\n\tc:[\n\t\t\"d\",\n\t\t(\n\t\t\te\n\t\t\tf\n\t\t),\n\t\t{}\n\t]\n}\n2\n")
+	test("  ", "a::{\n  b:1,\n  c:[\n    \"d\",\n    (\n      e\n      f\n    ),\n    {}\n  ]\n}\n2\n")
+	test("", "a::{\nb:1,\nc:[\n\"d\",\n(\ne\nf\n),\n{}\n]\n}\n2\n")
+}
+
+func TestWriteTextBadIndent(t *testing.T) {
+	buf := strings.Builder{}
+	w := NewTextWriter(&buf, WithIndent("--"))
+
+	err := w.WriteInt(1)
+	if _, ok := err.(*UsageError); !ok {
+		t.Errorf("expected a UsageError, got %v", err)
+	}
+}
+
+func TestWriteTextAllowedAnnotations(t *testing.T) {
+	allowed := map[string]bool{"foo": true, "bar": true}
+
+	buf := strings.Builder{}
+	w := NewTextWriter(&buf, WithAllowedAnnotations(allowed))
+
+	if err := w.Annotations("foo", "bar"); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.WriteInt(1); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := w.Annotation("baz"); err == nil {
+		t.Error("expected an error from disallowed annotation")
+	}
+	err := w.WriteInt(2)
+	if _, ok := err.(*UsageError); !ok {
+		t.Errorf("expected a UsageError, got %v", err)
+	}
+
+	if buf.String() != "foo::bar::1" {
+		t.Errorf("expected foo::bar::1, got %v", buf.String())
+	}
+}
+
 func testTextWriter(t *testing.T, expected string, f func(Writer)) {
 	actual := writeText(f)
 	if actual != expected {
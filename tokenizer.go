@@ -115,6 +115,19 @@ type tokenizer struct {
 	token      token
 	unfinished bool
 	pos        uint64
+
+	// If keepComments is set, the text of comments is kept in comments as they're
+	// skipped over, except that those following the end of a long string are held
+	// back in held, since they may turn out to belong to the next value instead.
+	keepComments bool
+	comment      []byte
+	comments     []string
+	held         []string
+
+	// If recording is set, the bytes read are kept in rec, less any unread again,
+	// so the text of a value can be had as it was written.
+	recording bool
+	rec       []byte
 }
 
 func tokenizeString(in string) *tokenizer {
@@ -328,12 +341,15 @@ func (t *tokenizer) ReadValue(tok token) (string, error) {
 		str, err = t.readSymbol()
 	case tokenSymbolQuoted:
 		str, err = t.readQuotedSymbol()
-	case tokenSymbolOperator, tokenDot:
+	case tokenSymbolOperator:
 		str, err = t.readOperator()
+	case tokenDot:
+		// A lone dot, which the tokenizer has already consumed.
+		str = "."
 	case tokenString:
-		str, err = t.readString()
+		str, err = t.readString(false)
 	case tokenLongString:
-		str, err = t.readLongString()
+		str, err = t.readLongString(false)
 	case tokenBinary:
 		str, err = t.readBinary()
 	case tokenHex:
@@ -440,7 +456,17 @@ func (t *tokenizer) readExponent(w io.ByteWriter) (int, error) {
 		}
 	}
 
-	return t.readDigits(c, w)
+	// Unlike the rest of the number, the exponent can't have underscores.
+	if !isDigit(c) {
+		return 0, t.invalidChar(c)
+	}
+	for isDigit(c) {
+		w.WriteByte(byte(c))
+		if c, err = t.read(); err != nil {
+			return 0, err
+		}
+	}
+	return c, nil
 }
 
 func (t *tokenizer) readDigits(c int, w io.ByteWriter) (int, error) {
@@ -533,8 +559,10 @@ func (t *tokenizer) readOperator() (string, error) {
 	return ret.String(), nil
 }
 
-// ReadString reads a quoted string.
-func (t *tokenizer) readString() (string, error) {
+// ReadString reads a quoted string. If it's the contents of a clob, it must be ASCII,
+// and escapes stand for the byte with that value rather than a UTF-8 encoded code point,
+// so that the bytes of the string are exactly the bytes of the clob.
+func (t *tokenizer) readString(clob bool) (string, error) {
 	ret := strings.Builder{}
 
 	for {
@@ -561,20 +589,28 @@ func (t *tokenizer) readString() (string, error) {
 				continue
 			}
 
-			r, err := t.readEscapedChar(false)
+			r, err := t.readEscapedChar(clob)
 			if err != nil {
 				return "", err
 			}
-			ret.WriteRune(r)
+			if clob {
+				ret.WriteByte(byte(r))
+			} else {
+				ret.WriteRune(r)
+			}
 
 		default:
+			if clob && c > 0x7F {
+				return "", t.invalidChar(c)
+			}
 			ret.WriteByte(byte(c))
 		}
 	}
 }
 
-// ReadLongString reads a triple-quoted string.
-func (t *tokenizer) readLongString() (string, error) {
+// ReadLongString reads a triple-quoted string, or the contents of a long clob as for
+// readString.
+func (t *tokenizer) readLongString(clob bool) (string, error) {
 	ret := strings.Builder{}
 
 	for {
@@ -588,11 +624,21 @@ func (t *tokenizer) readLongString() (string, error) {
 			return "", t.invalidChar(c)
 
 		case '\'':
-			ok, err := t.skipEndOfLongString(t.skipCommentsHandler)
+			// One or two quotes are part of the string; three end this segment.
+			end, err := t.IsTripleQuote()
 			if err != nil {
 				return "", err
 			}
-			if ok {
+			if !end {
+				ret.WriteByte('\'')
+				continue
+			}
+
+			more, err := t.skipLongStringSeparator(t.skipCommentsHandler)
+			if err != nil {
+				return "", err
+			}
+			if !more {
 				return ret.String(), nil
 			}
 
@@ -607,13 +653,20 @@ func (t *tokenizer) readLongString() (string, error) {
 				continue
 			}
 
-			r, err := t.readEscapedChar(false)
+			r, err := t.readEscapedChar(clob)
 			if err != nil {
 				return "", err
 			}
-			ret.WriteRune(r)
+			if clob {
+				ret.WriteByte(byte(r))
+			} else {
+				ret.WriteRune(r)
+			}
 
 		default:
+			if clob && c > 0x7F {
+				return "", t.invalidChar(c)
+			}
 			ret.WriteByte(byte(c))
 		}
 	}
@@ -660,6 +713,9 @@ func (t *tokenizer) readEscapedChar(clob bool) (rune, error) {
 		}
 		return t.readHexEscapeSeq(8)
 	case 'u':
+		if clob {
+			return 0, t.invalidChar('u')
+		}
 		return t.readHexEscapeSeq(4)
 	case 'x':
 		return t.readHexEscapeSeq(2)
@@ -749,6 +805,15 @@ func (t *tokenizer) readRadix(pok, dok matcher) (string, error) {
 	}
 	w.WriteByte(byte(c))
 
+	// There must be at least one digit, and no underscore before it.
+	if c, err = t.read(); err != nil {
+		return "", err
+	}
+	if !dok(c) {
+		return "", t.invalidChar(c)
+	}
+	w.WriteByte(byte(c))
+
 	c, err = t.readRadixDigits(dok, &w)
 	if err != nil {
 		return "", err
@@ -766,6 +831,8 @@ func (t *tokenizer) readRadix(pok, dok matcher) (string, error) {
 	return w.String(), nil
 }
 
+// ReadRadixDigits reads the rest of a run of digits, the first of which has already
+// been read, dropping the underscores that may separate them.
 func (t *tokenizer) readRadixDigits(dok matcher, w io.ByteWriter) (int, error) {
 	var c int
 	var err error
@@ -776,7 +843,13 @@ func (t *tokenizer) readRadixDigits(dok matcher, w io.ByteWriter) (int, error) {
 			return 0, err
 		}
 		if c == '_' {
-			continue
+			// An underscore must be followed by another digit.
+			if c, err = t.read(); err != nil {
+				return 0, err
+			}
+			if !dok(c) {
+				return 0, t.invalidChar(c)
+			}
 		}
 		if !dok(c) {
 			return c, nil
@@ -972,7 +1045,7 @@ func (t *tokenizer) ReadBlob() (string, error) {
 }
 
 func (t *tokenizer) ReadShortClob() (string, error) {
-	str, err := t.readString()
+	str, err := t.readString(true)
 	if err != nil {
 		return "", err
 	}
@@ -997,7 +1070,7 @@ func (t *tokenizer) ReadShortClob() (string, error) {
 }
 
 func (t *tokenizer) ReadLongClob() (string, error) {
-	str, err := t.readLongString()
+	str, err := t.readLongString(true)
 	if err != nil {
 		return "", err
 	}
@@ -1229,6 +1302,7 @@ func (t *tokenizer) read() (int, error) {
 		// We've already peeked ahead; read from our buffer.
 		c := t.buffer[len(t.buffer)-1]
 		t.buffer = t.buffer[:len(t.buffer)-1]
+		t.record(c)
 		return c, nil
 	}
 
@@ -1251,15 +1325,39 @@ func (t *tokenizer) read() (int, error) {
 			// Skip over the '\n' as well.
 			t.in.ReadByte()
 		}
+		t.record('\n')
 		return '\n', nil
 	}
 
+	t.record(int(c))
 	return int(c), nil
 }
 
+// Record keeps a character just read, if we're recording.
+func (t *tokenizer) record(c int) {
+	if t.recording && c != -1 {
+		t.rec = append(t.rec, byte(c))
+	}
+}
+
+// StartRecording starts recording the text read from here on.
+func (t *tokenizer) startRecording() {
+	t.recording = true
+	t.rec = t.rec[:0]
+}
+
+// StopRecording stops recording, returning the text recorded.
+func (t *tokenizer) stopRecording() string {
+	t.recording = false
+	return string(t.rec)
+}
+
 // Unread pushes a character (or -1) back into the input stream to
 // be read again later.
 func (t *tokenizer) unread(c int) {
 	t.pos--
 	t.buffer = append(t.buffer, c)
+	if t.recording && c != -1 && len(t.rec) > 0 {
+		t.rec = t.rec[:len(t.rec)-1]
+	}
 }
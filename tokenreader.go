@@ -0,0 +1,173 @@
+package ion
+
+import (
+	"fmt"
+	"io"
+)
+
+// A TokenType identifies the kind of token read by a TokenReader.
+type TokenType uint8
+
+const (
+	// NoToken is returned by a TokenReader that is not currently on a token.
+	NoToken TokenType = iota
+
+	// VersionMarkerToken is a version marker, which resets the symbol table. Its
+	// value is the marker's symbolic form, such as "$ion_1_0".
+	VersionMarkerToken
+
+	// FieldNameToken is the field name of the value that follows. Its value is
+	// the name, as a string.
+	FieldNameToken
+
+	// AnnotationToken is one annotation of the value that follows. Its value is
+	// the annotation, as a string.
+	AnnotationToken
+
+	// ScalarToken is a scalar value, or a null of any type, including null
+	// containers. Its value is a Value holding it, without the field name or
+	// annotations, which come as tokens of their own.
+	ScalarToken
+
+	// BeginContainerToken is the start of a non-null list, sexp, or struct. Its
+	// value is the container's Type.
+	BeginContainerToken
+
+	// EndContainerToken is the end of the most recently begun container. Its
+	// value is the container's Type.
+	EndContainerToken
+)
+
+// String implements fmt.Stringer for TokenType.
+func (t TokenType) String() string {
+	switch t {
+	case NoToken:
+		return "<no token>"
+	case VersionMarkerToken:
+		return "version marker"
+	case FieldNameToken:
+		return "field name"
+	case AnnotationToken:
+		return "annotation"
+	case ScalarToken:
+		return "scalar"
+	case BeginContainerToken:
+		return "begin container"
+	case EndContainerToken:
+		return "end container"
+	default:
+		return fmt.Sprintf("<unknown token type %v>", uint8(t))
+	}
+}
+
+// A flatToken is a token read by a TokenReader.
+type flatToken struct {
+	typ TokenType
+	val interface{}
+}
+
+// A TokenReader reads a stream of Ion, text or binary, as a flat sequence of tokens,
+// in the style of a bufio.Scanner, for processors that would rather not keep track
+// of stepping in to and out of containers. Each value comes as its field name, if
+// it's in a struct, then its annotations, then either a ScalarToken or a
+// BeginContainerToken, followed by the tokens of the container's contents and an
+// EndContainerToken. Local symbol tables are handled internally and don't appear.
+//
+//	tr := ion.NewTokenReader(in)
+//	for tr.Next() {
+//		typ, val := tr.Token()
+//		// ...
+//	}
+//	if err := tr.Err(); err != nil {
+//		return err
+//	}
+type TokenReader struct {
+	r   Reader
+	err error
+
+	// Tokens read but not yet returned, and the current one.
+	pending []flatToken
+	cur     flatToken
+}
+
+// NewTokenReader creates a new TokenReader reading from in, which may be text or
+// binary Ion, configured by the given options as a Reader would be.
+func NewTokenReader(in io.Reader, opts ...ReaderOption) *TokenReader {
+	t := &TokenReader{}
+	opts = append(opts, withVersionMarkerHook(func(major, minor int) {
+		t.push(VersionMarkerToken, fmt.Sprintf("$ion_%v_%v", major, minor))
+	}))
+	t.r = NewReader(in, opts...)
+	return t
+}
+
+// Next moves to the next token, returning false when there are no more, either at
+// the end of the input or because of an error, which Err returns.
+func (t *TokenReader) Next() bool {
+	if len(t.pending) == 0 && t.err == nil {
+		t.err = t.read()
+	}
+	if len(t.pending) == 0 {
+		t.cur = flatToken{}
+		return false
+	}
+	t.cur, t.pending = t.pending[0], t.pending[1:]
+	return true
+}
+
+// Token returns the type and value of the current token. See the documentation
+// of each TokenType for the type of its value.
+func (t *TokenReader) Token() (TokenType, interface{}) {
+	return t.cur.typ, t.cur.val
+}
+
+// Err returns the error, if any, that stopped Next.
+func (t *TokenReader) Err() error {
+	return t.err
+}
+
+// Read reads the tokens of the next value, or the end of the current container,
+// into the pending list. At the end of the input, it reads nothing.
+func (t *TokenReader) read() error {
+	if !t.r.Next() {
+		if err := t.r.Err(); err != nil {
+			return err
+		}
+		if typ := t.r.ContainerType(); typ != NoType {
+			t.push(EndContainerToken, typ)
+			return t.r.StepOut()
+		}
+		return nil
+	}
+
+	if t.r.ContainerType() == StructType {
+		t.push(FieldNameToken, t.r.FieldName())
+	}
+	for _, a := range t.r.Annotations() {
+		t.push(AnnotationToken, a)
+	}
+
+	typ := t.r.Type()
+	switch {
+	case t.r.IsNull():
+		t.push(ScalarToken, Value{Type: typ, Null: true})
+
+	case typ == ListType || typ == SexpType || typ == StructType:
+		t.push(BeginContainerToken, typ)
+		return t.r.StepIn()
+
+	default:
+		v, err := ReadValue(t.r)
+		if err != nil {
+			return err
+		}
+		v.FieldName, v.Annotations = "", nil
+		t.push(ScalarToken, v)
+	}
+	return nil
+}
+
+// Push adds a token to the pending list.
+func (t *TokenReader) push(typ TokenType, val interface{}) {
+	t.pending = append(t.pending, flatToken{typ, val})
+}
@@ -0,0 +1,114 @@
+package ion
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTokenReader(t *testing.T) {
+	in := `a::1 {b:c::d::[2, "x"], 'e f':null.list} (+ 3.5 2019T)`
+	etoks := []string{
+		"annotation: a",
+		"scalar: 1",
+		"begin container: struct",
+		"field name: b",
+		"annotation: c",
+		"annotation: d",
+		"begin container: list",
+		"scalar: 2",
+		`scalar: "x"`,
+		"end container: list",
+		"field name: e f",
+		"scalar: null.list",
+		"end container: struct",
+		"begin container: sexp",
+		"scalar: '+'",
+		"scalar: 3.5",
+		"scalar: 2019T",
+		"end container: sexp",
+	}
+
+	t.Run("text", func(t *testing.T) {
+		testTokenReader(t, NewTokenReader(strings.NewReader(in)), etoks)
+	})
+
+	t.Run("binary", func(t *testing.T) {
+		vs, err := ReadValues(NewReaderStr(in))
+		if err != nil {
+			t.Fatal(err)
+		}
+		buf := bytes.Buffer{}
+		w := NewBinaryWriter(&buf)
+		for i := range vs {
+			vs[i].WriteTo(w)
+		}
+		if err := w.Finish(); err != nil {
+			t.Fatal(err)
+		}
+
+		// The binary version marker comes first, but the local symbol table doesn't show.
+		etoks := append([]string{"version marker: $ion_1_0"}, etoks...)
+		testTokenReader(t, NewTokenReader(&buf), etoks)
+	})
+}
+
+func TestTokenReaderVersionMarkers(t *testing.T) {
+	tr := NewTokenReader(strings.NewReader("$ion_1_0 1 [$ion_1_0] $ion_1_0"))
+	testTokenReader(t, tr, []string{
+		"version marker: $ion_1_0",
+		"scalar: 1",
+		"begin container: list",
+		"scalar: '$ion_1_0'",
+		"end container: list",
+		"version marker: $ion_1_0",
+	})
+}
+
+func TestTokenReaderError(t *testing.T) {
+	tr := NewTokenReader(strings.NewReader("[1, {a:"))
+
+	var toks []string
+	for tr.Next() {
+		toks = append(toks, fmtToken(t, tr))
+	}
+	etoks := []string{"begin container: list", "scalar: 1", "begin container: struct"}
+	if !reflect.DeepEqual(toks, etoks) {
+		t.Errorf("expected %q, got %q", etoks, toks)
+	}
+	if tr.Err() == nil {
+		t.Error("expected an error")
+	}
+	if typ, _ := tr.Token(); typ != NoToken {
+		t.Errorf("expected no token, got %v", typ)
+	}
+}
+
+func testTokenReader(t *testing.T, tr *TokenReader, etoks []string) {
+	var toks []string
+	for tr.Next() {
+		toks = append(toks, fmtToken(t, tr))
+	}
+	if err := tr.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(toks, etoks) {
+		t.Errorf("expected %q, got %q", etoks, toks)
+	}
+}
+
+// FmtToken formats the current token, writing scalars as Ion text.
+func fmtToken(t *testing.T, tr *TokenReader) string {
+	typ, val := tr.Token()
+	if v, ok := val.(Value); ok {
+		buf := strings.Builder{}
+		w := NewTextWriter(&buf)
+		if err := v.WriteTo(w); err != nil {
+			t.Fatal(err)
+		}
+		val = buf.String()
+	}
+	return fmt.Sprintf("%v: %v", typ, val)
+}
@@ -0,0 +1,127 @@
+package ion
+
+import "fmt"
+
+// Transform copies the remaining values from r to w, calling rewrite for each value
+// along the way. If rewrite returns a Value and true, that Value is written in place
+// of the original (keeping the original's field name); otherwise the original value
+// is copied over unchanged.
+//
+// The path passed to rewrite identifies where the value is in the document: it is
+// empty for top-level values, and is extended with ".name" for fields of a struct
+// (omitting the dot at the top level) and with "[i]" for the i'th value in a list
+// or sexp, as in "orders[2].price".
+//
+// Values are transformed as they are read, without reading whole containers into
+// memory. As a consequence, the Value passed to rewrite for a non-null container
+// has no Children: if rewrite replaces it, its contents are skipped, and if not,
+// rewrite is then called for each of its contents in turn.
+//
+// Transform does not call Finish on w.
+func Transform(r Reader, w Writer, rewrite func(path string, v Value) (Value, bool)) error {
+	return transformValues(r, w, "", NoType, rewrite)
+}
+
+// TransformValues transforms the values remaining in the current container (of the
+// given type, or NoType at the top level), whose path is given.
+func transformValues(r Reader, w Writer, path string, container Type, rewrite func(string, Value) (Value, bool)) error {
+	for i := 0; r.Next(); i++ {
+		vpath := path
+		switch container {
+		case StructType:
+			vpath = fieldPath(path, r.FieldName())
+		case ListType, SexpType:
+			vpath = fmt.Sprintf("%v[%v]", path, i)
+		}
+
+		if err := transformValue(r, w, vpath, container == StructType, rewrite); err != nil {
+			return err
+		}
+	}
+	return r.Err()
+}
+
+// TransformValue transforms the value the reader is currently positioned on.
+func transformValue(r Reader, w Writer, path string, inStruct bool, rewrite func(string, Value) (Value, bool)) error {
+	t := r.Type()
+	isContainer := !r.IsNull() && (t == ListType || t == SexpType || t == StructType)
+
+	var v Value
+	if isContainer {
+		v = Value{
+			Type:        t,
+			FieldName:   r.FieldName(),
+			Annotations: r.Annotations(),
+		}
+	} else {
+		var err error
+		if v, err = ReadValue(r); err != nil {
+			return err
+		}
+	}
+
+	if inStruct {
+		if err := w.FieldName(v.FieldName); err != nil {
+			return err
+		}
+	}
+
+	if nv, ok := rewrite(path, v); ok {
+		return nv.WriteTo(w)
+	}
+	if !isContainer {
+		return v.WriteTo(w)
+	}
+
+	if len(v.Annotations) > 0 {
+		if err := w.Annotations(v.Annotations...); err != nil {
+			return err
+		}
+	}
+
+	var end func() error
+	switch t {
+	case ListType:
+		w.BeginList()
+		end = w.EndList
+	case SexpType:
+		w.BeginSexp()
+		end = w.EndSexp
+	case StructType:
+		w.BeginStruct()
+		end = w.EndStruct
+	}
+
+	if err := r.StepIn(); err != nil {
+		return err
+	}
+	if err := transformValues(r, w, path, t, rewrite); err != nil {
+		return err
+	}
+	if err := r.StepOut(); err != nil {
+		return err
+	}
+
+	return end()
+}
+
+// StripAnnotations copies the remaining values from r to w with all of their
+// annotations, at any depth, removed.
+func StripAnnotations(r Reader, w Writer) error {
+	return Transform(r, annotationStripper{w}, func(path string, v Value) (Value, bool) {
+		return v, false
+	})
+}
+
+// An annotationStripper is a Writer that ignores any annotations it's given.
+type annotationStripper struct {
+	Writer
+}
+
+func (annotationStripper) Annotation(val string) error {
+	return nil
+}
+
+func (annotationStripper) Annotations(vals ...string) error {
+	return nil
+}
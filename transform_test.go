@@ -0,0 +1,94 @@
+package ion
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTransformDoubleInts(t *testing.T) {
+	in := `{a:{b:1, c:2}, d:[3, 4, (5 6)]} x::{a:{b:7}}`
+
+	var paths []string
+	out := testTransform(t, in, func(path string, v Value) (Value, bool) {
+		paths = append(paths, path)
+		if (path == "a.b" || path == "d[1]") && v.Type == IntType {
+			v.Scalar = v.Scalar.(int64) * 2
+			return v, true
+		}
+		return v, false
+	})
+
+	expected := `{a:{b:2,c:2},d:[3,8,(5 6)]}` + "\n" + `x::{a:{b:14}}` + "\n"
+	if out != expected {
+		t.Errorf("expected %q, got %q", expected, out)
+	}
+
+	expectedPaths := []string{"", "a", "a.b", "a.c", "d", "d[0]", "d[1]", "d[2]", "d[2][0]", "d[2][1]", "", "a", "a.b"}
+	if strings.Join(paths, " ") != strings.Join(expectedPaths, " ") {
+		t.Errorf("expected paths %v, got %v", expectedPaths, paths)
+	}
+}
+
+func TestTransformReplaceContainer(t *testing.T) {
+	in := `{user:{name:"beyonce", password:secret::{hash:"abc", salt:"def"}}, n:null.struct}` + "\n"
+
+	out := testTransform(t, in, func(path string, v Value) (Value, bool) {
+		if path == "user.password" {
+			return Value{Type: StringType, Scalar: "****"}, true
+		}
+		return v, false
+	})
+
+	expected := `{user:{name:"beyonce",password:"****"},n:null.struct}` + "\n"
+	if out != expected {
+		t.Errorf("expected %q, got %q", expected, out)
+	}
+}
+
+func TestTransformUnchanged(t *testing.T) {
+	in := `a::b::1 "c" [d, e::{f:null, g:(h [i])}] 1.5 2000-01-01T`
+
+	out := testTransform(t, in, func(path string, v Value) (Value, bool) {
+		return v, false
+	})
+
+	eq, err := Equal([]byte(in), []byte(out))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !eq {
+		t.Errorf("expected %v, got %v", in, out)
+	}
+}
+
+func testTransform(t *testing.T, in string, rewrite func(string, Value) (Value, bool)) string {
+	buf := strings.Builder{}
+	w := NewTextWriter(&buf)
+
+	if err := Transform(NewReaderStr(in), w, rewrite); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Finish(); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.String()
+}
+
+func TestStripAnnotations(t *testing.T) {
+	in := `a::1 b::c::{d:e::[f::"g", h::(i::j k)], l:m::null.int} n`
+
+	buf := strings.Builder{}
+	w := NewTextWriter(&buf)
+	if err := StripAnnotations(NewReaderStr(in), w); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Finish(); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "1\n" + `{d:["g",(j k)],l:null.int}` + "\nn\n"
+	if buf.String() != expected {
+		t.Errorf("expected %q, got %q", expected, buf.String())
+	}
+}
@@ -123,3 +123,72 @@ func (i IntSize) String() string {
 		return fmt.Sprintf("<unknown size %v>", uint8(i))
 	}
 }
+
+// TimestampPrecision is the precision of a timestamp: which of its components
+// are significant.
+type TimestampPrecision uint8
+
+const (
+	// TimestampNoPrecision is the precision of null.timestamp and other things that
+	// aren't actually timestamps.
+	TimestampNoPrecision TimestampPrecision = iota
+	// TimestampPrecisionYear is the precision of a timestamp with only a year, like 2020T.
+	TimestampPrecisionYear
+	// TimestampPrecisionMonth is the precision of a timestamp with a year and month, like 2020-06T.
+	TimestampPrecisionMonth
+	// TimestampPrecisionDay is the precision of a timestamp with a full date, like 2020-06-15.
+	TimestampPrecisionDay
+	// TimestampPrecisionMinute is the precision of a timestamp with a date, hours, and
+	// minutes, like 2020-06-15T12:30Z.
+	TimestampPrecisionMinute
+	// TimestampPrecisionSecond is the precision of a timestamp with whole seconds, like
+	// 2020-06-15T12:30:45Z.
+	TimestampPrecisionSecond
+	// TimestampPrecisionNanosecond is the precision of a timestamp with fractional seconds,
+	// like 2020-06-15T12:30:45.123Z, to the limit of what a time.Time can hold.
+	TimestampPrecisionNanosecond
+)
+
+// String implements fmt.Stringer for TimestampPrecision.
+func (p TimestampPrecision) String() string {
+	switch p {
+	case TimestampNoPrecision:
+		return "<no precision>"
+	case TimestampPrecisionYear:
+		return "year"
+	case TimestampPrecisionMonth:
+		return "month"
+	case TimestampPrecisionDay:
+		return "day"
+	case TimestampPrecisionMinute:
+		return "minute"
+	case TimestampPrecisionSecond:
+		return "second"
+	case TimestampPrecisionNanosecond:
+		return "nanosecond"
+	default:
+		return fmt.Sprintf("<unknown precision %v>", uint8(p))
+	}
+}
+
+// Format is an encoding of Ion: either text or binary.
+type Format uint8
+
+const (
+	// FormatText is Ion's human-readable text encoding.
+	FormatText Format = iota
+	// FormatBinary is Ion's compact binary encoding.
+	FormatBinary
+)
+
+// String implements fmt.Stringer for Format.
+func (f Format) String() string {
+	switch f {
+	case FormatText:
+		return "text"
+	case FormatBinary:
+		return "binary"
+	default:
+		return fmt.Sprintf("<unknown format %v>", uint8(f))
+	}
+}
@@ -19,3 +19,21 @@ func TestIntSizeToString(t *testing.T) {
 		}
 	}
 }
+
+func TestTimestampPrecisionToString(t *testing.T) {
+	for i := TimestampNoPrecision; i <= TimestampPrecisionNanosecond+1; i++ {
+		str := i.String()
+		if str == "" {
+			t.Errorf("expected a non-empty string for precision %v", uint8(i))
+		}
+	}
+}
+
+func TestFormatToString(t *testing.T) {
+	for i := FormatText; i <= FormatBinary+1; i++ {
+		str := i.String()
+		if str == "" {
+			t.Errorf("expected a non-empty string for format %v", uint8(i))
+		}
+	}
+}
@@ -2,6 +2,9 @@ package ion
 
 import (
 	"bytes"
+	"database/sql"
+	"database/sql/driver"
+	"encoding"
 	"errors"
 	"fmt"
 	"io"
@@ -9,6 +12,7 @@ import (
 	"reflect"
 	"strconv"
 	"strings"
+	"time"
 )
 
 var (
@@ -28,15 +32,33 @@ func UnmarshalStr(data string, v interface{}) error {
 
 // UnmarshalFrom unmarshal Ion data from a reader to the given object.
 func UnmarshalFrom(r Reader, v interface{}) error {
-	d := Decoder{
-		r: r,
-	}
-	return d.DecodeTo(v)
+	return NewDecoder(r).DecodeTo(v)
 }
 
+// A KeyValue is a single field of an OrderedMap.
+type KeyValue struct {
+	Key   string
+	Value interface{}
+}
+
+// An OrderedMap holds the fields of an Ion struct in the order they were read,
+// including any repeated field names, which a map can't. A Decoder decodes structs
+// to OrderedMaps rather than maps if SetOrderedStructs is on, or when asked to
+// decode to one directly, and an Encoder writes one back out as a struct with its
+// fields in the same order.
+type OrderedMap []KeyValue
+
 // A Decoder decodes go values from an Ion reader.
 type Decoder struct {
 	r Reader
+
+	maxValues      int
+	numValues      int
+	timeStrings    bool
+	orderedStructs bool
+	types          map[string]reflect.Type
+	enums          map[reflect.Type]func(string) (interface{}, error)
+	names          fieldNamer
 }
 
 // NewDecoder creates a new decoder.
@@ -46,6 +68,87 @@ func NewDecoder(r Reader) *Decoder {
 	}
 }
 
+// SetMaxTotalValues sets the maximum number of values, counting the values
+// inside of containers, that will be decoded in a single call to Decode or
+// DecodeTo before giving up with a TooManyValuesError. This bounds the amount
+// of memory that decoding an untrusted document can consume. There is no limit by
+// default, nor if n is zero or less.
+func (d *Decoder) SetMaxTotalValues(n int) {
+	d.maxValues = n
+}
+
+// SetTimeStrings sets whether DecodeTo accepts strings, as well as timestamps, when
+// decoding to a time.Time, for data from systems that encode timestamps as strings.
+// Such strings are parsed as RFC 3339 timestamps, for example "2019-08-04T18:15:43Z",
+// and anything else is an error. It's off by default, since a string that only
+// happens to look like a timestamp probably isn't meant to be one.
+func (d *Decoder) SetTimeStrings(on bool) {
+	d.timeStrings = on
+}
+
+// SetOrderedStructs sets whether structs decoded without a more specific type to
+// decode them to, by Decode or by DecodeTo into an interface{}, become OrderedMaps
+// instead of map[string]interface{}s, preserving the order of their fields so that
+// encoding them again gives the same struct back. It's off by default.
+func (d *Decoder) SetOrderedStructs(on bool) {
+	d.orderedStructs = on
+}
+
+// SetFieldNameMapper sets a function that the names of struct fields without a name
+// given by a tag are passed through before being matched against the names of the
+// fields being decoded, as with the Encoder method of the same name: so given
+// SnakeCase, the field user_id is decoded to a struct field named UserID. Names are
+// still matched case-insensitively if there's no exact match.
+func (d *Decoder) SetFieldNameMapper(mapper func(string) string) {
+	d.names.setMapper(mapper)
+}
+
+// RegisterType registers the type of proto as the one to decode values annotated
+// with the given annotation to, when decoding them to an interface: so having called
+// RegisterType("Dog", &Dog{}), DecodeTo decodes Dog::{name:"Rex"} to a *Dog, given a
+// []Animal to fill. The type must implement the interface in question, or it's an
+// error. If a value has more than one registered annotation, the first one wins;
+// values without one are decoded to interfaces as usual. RegisterType panics if
+// proto is nil.
+func (d *Decoder) RegisterType(annotation string, proto interface{}) {
+	if proto == nil {
+		panic("ion: RegisterType with a nil proto")
+	}
+	if d.types == nil {
+		d.types = map[string]reflect.Type{}
+	}
+	d.types[annotation] = reflect.TypeOf(proto)
+}
+
+// RegisterEnum registers a function to parse strings decoded to values of the type
+// of proto with, as written for enums with the encoder option EncodeEnumsAsStrings:
+// so having called
+//
+//	d.RegisterEnum(Red, func(s string) (interface{}, error) { return ParseColor(s) })
+//
+// DecodeTo decodes "Green" to a Color by calling ParseColor("Green"). The function
+// must return a value of that type, or an error. Ints are decoded to the type as
+// usual, whether or not a function is registered for it. RegisterEnum panics if proto
+// or parse is nil.
+func (d *Decoder) RegisterEnum(proto interface{}, parse func(string) (interface{}, error)) {
+	if proto == nil || parse == nil {
+		panic("ion: RegisterEnum with a nil proto or parse function")
+	}
+	if d.enums == nil {
+		d.enums = map[reflect.Type]func(string) (interface{}, error){}
+	}
+	d.enums[reflect.TypeOf(proto)] = parse
+}
+
+// Count counts another decoded value against the limit on total values.
+func (d *Decoder) count() error {
+	d.numValues++
+	if d.maxValues > 0 && d.numValues > d.maxValues {
+		return &TooManyValuesError{d.maxValues}
+	}
+	return nil
+}
+
 // NewTextDecoder creates a new text decoder. Well, a decoder that uses a reader with
 // no shared symbol tables, it'll work to read binary too if the binary doesn't reference
 // any shared symbol tables.
@@ -64,11 +167,16 @@ func (d *Decoder) Decode() (interface{}, error) {
 		return nil, ErrNoInput
 	}
 
+	d.numValues = 0
 	return d.decode()
 }
 
 // Helper form of Decode for when you've already called Next.
 func (d *Decoder) decode() (interface{}, error) {
+	if err := d.count(); err != nil {
+		return nil, err
+	}
+
 	if d.r.IsNull() {
 		return nil, nil
 	}
@@ -96,6 +204,9 @@ func (d *Decoder) decode() (interface{}, error) {
 		return d.r.ByteValue()
 
 	case StructType:
+		if d.orderedStructs {
+			return d.decodeOrderedMap()
+		}
 		return d.decodeMap()
 
 	case ListType, SexpType:
@@ -148,6 +259,30 @@ func (d *Decoder) decodeMap() (map[string]interface{}, error) {
 	return result, nil
 }
 
+// DecodeOrderedMap decodes an Ion struct to an OrderedMap.
+func (d *Decoder) decodeOrderedMap() (OrderedMap, error) {
+	if err := d.r.StepIn(); err != nil {
+		return nil, err
+	}
+
+	result := OrderedMap{}
+
+	for d.r.Next() {
+		name := d.r.FieldName()
+		value, err := d.decode()
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, KeyValue{name, value})
+	}
+
+	if err := d.r.StepOut(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
 // DecodeSlice decodes an Ion list or sexp to a go slice.
 func (d *Decoder) decodeSlice() ([]interface{}, error) {
 	if err := d.r.StepIn(); err != nil {
@@ -189,10 +324,15 @@ func (d *Decoder) DecodeTo(v interface{}) error {
 		return ErrNoInput
 	}
 
+	d.numValues = 0
 	return d.decodeTo(rv)
 }
 
 func (d *Decoder) decodeTo(v reflect.Value) error {
+	if err := d.count(); err != nil {
+		return err
+	}
+
 	if !v.IsValid() {
 		// Don't actually have anywhere to put this value; skip it.
 		return nil
@@ -200,6 +340,20 @@ func (d *Decoder) decodeTo(v reflect.Value) error {
 
 	isNull := d.r.IsNull()
 	v = indirect(v, isNull)
+	if !isNull && v.Kind() == reflect.Interface {
+		if t, ok := d.registeredType(); ok {
+			return d.decodeRegisteredTo(v, t)
+		}
+	}
+	return d.decodeValueTo(v, isNull)
+}
+
+// DecodeValueTo decodes the current value to v, which indirect has already been
+// applied to.
+func (d *Decoder) decodeValueTo(v reflect.Value, isNull bool) error {
+	if d.isScannable(v, isNull) {
+		return d.decodeScannerTo(v)
+	}
 	if isNull {
 		v.Set(reflect.Zero(v.Type()))
 		return nil
@@ -238,6 +392,109 @@ func (d *Decoder) decodeTo(v reflect.Value) error {
 	}
 }
 
+// IsScannable returns true if the current value should be decoded to v by its Scan
+// method. Only nulls and scalars are: a struct or list goes to a struct or slice as
+// usual, even if it has a Scan method for some database column, and a string goes
+// to UnmarshalText in preference to Scan.
+func (d *Decoder) isScannable(v reflect.Value, isNull bool) bool {
+	if v.Kind() == reflect.Ptr || !v.CanAddr() || isIonNative(v.Type()) {
+		return false
+	}
+	pt := reflect.PtrTo(v.Type())
+	if !pt.Implements(scannerType) {
+		return false
+	}
+	if isNull {
+		return true
+	}
+
+	switch d.r.Type() {
+	case StructType, ListType, SexpType:
+		return false
+	case StringType, SymbolType:
+		return !pt.Implements(textUnmarshalerType)
+	}
+	return true
+}
+
+// RegisteredType returns the type registered for the first of the current value's
+// annotations that has one.
+func (d *Decoder) registeredType() (reflect.Type, bool) {
+	for _, a := range d.r.Annotations() {
+		if t, ok := d.types[a]; ok {
+			return t, true
+		}
+	}
+	return nil, false
+}
+
+// DecodeRegisteredTo decodes the current value to a new value of the registered
+// type t, and stores it in the interface v.
+func (d *Decoder) decodeRegisteredTo(v reflect.Value, t reflect.Type) error {
+	if !t.AssignableTo(v.Type()) {
+		return fmt.Errorf("ion: cannot decode %v to %v", t.String(), v.Type().String())
+	}
+
+	nv := reflect.New(t).Elem()
+	if err := d.decodeValueTo(indirect(nv, false), false); err != nil {
+		return err
+	}
+	v.Set(nv)
+	return nil
+}
+
+// DecodeScannerTo decodes the current value to a sql.Scanner, such as sql.NullInt64,
+// by passing the closest equivalent driver.Value to its Scan method: nil for nulls
+// of any type, and the usual Go types otherwise. Ints too big for an int64, and
+// decimals, are passed as strings of their value in Go syntax, such as "1.5e-7".
+func (d *Decoder) decodeScannerTo(v reflect.Value) error {
+	var val driver.Value
+	var err error
+
+	if !d.r.IsNull() {
+		switch d.r.Type() {
+		case BoolType:
+			val, err = d.r.BoolValue()
+
+		case IntType:
+			var bi *big.Int
+			if bi, err = d.r.BigIntValue(); err == nil {
+				if bi.IsInt64() {
+					val = bi.Int64()
+				} else {
+					val = bi.String()
+				}
+			}
+
+		case FloatType:
+			val, err = d.r.FloatValue()
+
+		case DecimalType:
+			var dec *Decimal
+			if dec, err = d.r.DecimalValue(); err == nil {
+				val = decimalNumber(dec)
+			}
+
+		case TimestampType:
+			val, err = d.r.TimeValue()
+
+		case StringType, SymbolType:
+			val, err = d.r.StringValue()
+
+		case BlobType, ClobType:
+			val, err = d.r.ByteValue()
+
+		default:
+			return fmt.Errorf("ion: cannot decode %v to %v", d.r.Type(), v.Type().String())
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return v.Addr().Interface().(sql.Scanner).Scan(val)
+}
+
 func (d *Decoder) decodeBoolTo(v reflect.Value) error {
 	val, err := d.r.BoolValue()
 	if err != nil {
@@ -259,8 +516,6 @@ func (d *Decoder) decodeBoolTo(v reflect.Value) error {
 	return fmt.Errorf("ion: cannot decode bool to %v", v.Type().String())
 }
 
-var bigIntType = reflect.TypeOf(big.Int{})
-
 func (d *Decoder) decodeIntTo(v reflect.Value) error {
 	switch v.Kind() {
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
@@ -407,6 +662,34 @@ func (d *Decoder) decodeStringTo(v reflect.Value) error {
 		return err
 	}
 
+	if d.timeStrings && v.Type() == timeType && d.r.Type() == StringType {
+		t, err := time.Parse(time.RFC3339Nano, val)
+		if err != nil {
+			return fmt.Errorf("ion: cannot decode string %q to time.Time: %v", val, err)
+		}
+		v.Set(reflect.ValueOf(t))
+		return nil
+	}
+
+	if v.Type() == durationType && d.r.Type() == StringType {
+		dur, err := time.ParseDuration(val)
+		if err != nil {
+			return fmt.Errorf("ion: cannot decode string %q to time.Duration: %v", val, err)
+		}
+		v.SetInt(int64(dur))
+		return nil
+	}
+
+	if parse, ok := d.enums[v.Type()]; ok {
+		return d.decodeEnumTo(v, val, parse)
+	}
+
+	if v.CanAddr() && !isIonNative(v.Type()) && reflect.PtrTo(v.Type()).Implements(textUnmarshalerType) {
+		// Let the type parse the string itself.
+		u := v.Addr().Interface().(encoding.TextUnmarshaler)
+		return u.UnmarshalText([]byte(val))
+	}
+
 	switch v.Kind() {
 	case reflect.String:
 		v.SetString(val)
@@ -421,12 +704,34 @@ func (d *Decoder) decodeStringTo(v reflect.Value) error {
 	return fmt.Errorf("ion: cannot decode string to %v", v.Type().String())
 }
 
+// DecodeEnumTo decodes the string val to v with the parse function registered for its
+// type.
+func (d *Decoder) decodeEnumTo(v reflect.Value, val string, parse func(string) (interface{}, error)) error {
+	e, err := parse(val)
+	if err != nil {
+		return fmt.Errorf("ion: cannot decode string %q to %v: %v", val, v.Type().String(), err)
+	}
+
+	ev := reflect.ValueOf(e)
+	if !ev.IsValid() || ev.Type() != v.Type() {
+		return fmt.Errorf("ion: parse function for %v returned a %T", v.Type().String(), e)
+	}
+	v.Set(ev)
+	return nil
+}
+
 func (d *Decoder) decodeLobTo(v reflect.Value) error {
 	val, err := d.r.ByteValue()
 	if err != nil {
 		return err
 	}
 
+	if d.r.Type() == BlobType && v.CanAddr() && reflect.PtrTo(v.Type()).Implements(binaryUnmarshalerType) {
+		// Let the type decode the bytes itself.
+		u := v.Addr().Interface().(encoding.BinaryUnmarshaler)
+		return u.UnmarshalBinary(val)
+	}
+
 	switch v.Kind() {
 	case reflect.Slice:
 		if v.Type().Elem().Kind() == reflect.Uint8 {
@@ -436,9 +741,11 @@ func (d *Decoder) decodeLobTo(v reflect.Value) error {
 
 	case reflect.Array:
 		if v.Type().Elem().Kind() == reflect.Uint8 {
-			i := reflect.Copy(v, reflect.ValueOf(val))
-			for ; i < v.Len(); i++ {
-				v.Index(i).SetUint(0)
+			if len(val) != v.Len() {
+				return fmt.Errorf("ion: cannot decode lob of length %v to %v", len(val), v.Type().String())
+			}
+			for i, b := range val {
+				v.Index(i).SetUint(uint64(b))
 			}
 			return nil
 		}
@@ -453,6 +760,15 @@ func (d *Decoder) decodeLobTo(v reflect.Value) error {
 }
 
 func (d *Decoder) decodeStructTo(v reflect.Value) error {
+	if v.Type() == orderedMapType {
+		m, err := d.decodeOrderedMap()
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(m))
+		return nil
+	}
+
 	switch v.Kind() {
 	case reflect.Struct:
 		return d.decodeStructToStruct(v)
@@ -462,7 +778,13 @@ func (d *Decoder) decodeStructTo(v reflect.Value) error {
 
 	case reflect.Interface:
 		if v.NumMethod() == 0 {
-			m, err := d.decodeMap()
+			var m interface{}
+			var err error
+			if d.orderedStructs {
+				m, err = d.decodeOrderedMap()
+			} else {
+				m, err = d.decodeMap()
+			}
 			if err != nil {
 				return err
 			}
@@ -473,16 +795,21 @@ func (d *Decoder) decodeStructTo(v reflect.Value) error {
 	return fmt.Errorf("ion: cannot decode struct to %v", v.Type().String())
 }
 
+// DecodeStructToStruct decodes the current struct to the Go struct v, matching each
+// field first by symbol ID, against fields tagged with a sid option, and then by
+// name. A symbol ID match takes priority over a field whose name matches the text.
 func (d *Decoder) decodeStructToStruct(v reflect.Value) error {
-	fields := fieldsFor(v.Type())
+	fields := d.names.fieldsFor(v.Type())
 
 	if err := d.r.StepIn(); err != nil {
 		return err
 	}
 
 	for d.r.Next() {
-		name := d.r.FieldName()
-		field := findField(fields, name)
+		field := findFieldBySID(fields, d.r.FieldNameSymbol().LocalSID)
+		if field == nil {
+			field = findField(fields, d.r.FieldName())
+		}
 		if field != nil {
 			subv, err := findSubvalue(v, field)
 			if err != nil {
@@ -512,6 +839,22 @@ func findField(fields []field, name string) *field {
 	return f
 }
 
+// FindFieldBySID returns the field tagged with the given symbol ID, if any.
+func findFieldBySID(fields []field, sid int64) *field {
+	if sid == SymbolIDUnknown {
+		return nil
+	}
+	for i := range fields {
+		if fields[i].sid == sid {
+			return &fields[i]
+		}
+	}
+	return nil
+}
+
+// FindSubvalue digs through v to find the value for the given field, allocating any
+// embedded struct pointers along the way. Embedded pointers are only allocated when
+// one of their fields is actually present, leaving them nil otherwise.
 func findSubvalue(v reflect.Value, f *field) (reflect.Value, error) {
 	for _, i := range f.path {
 		if v.Kind() == reflect.Ptr {
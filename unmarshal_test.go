@@ -2,9 +2,13 @@ package ion
 
 import (
 	"bytes"
+	"database/sql"
+	"fmt"
 	"math"
 	"math/big"
+	"net"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 )
@@ -373,6 +377,40 @@ func TestDecodeStringTo(t *testing.T) {
 	test("\"hello\"", "hello")
 }
 
+func TestDecodeTextUnmarshaler(t *testing.T) {
+	var ip net.IP
+	if err := UnmarshalStr(`"192.168.0.1"`, &ip); err != nil {
+		t.Fatal(err)
+	}
+	if !ip.Equal(net.IPv4(192, 168, 0, 1)) {
+		t.Errorf("expected 192.168.0.1, got %v", ip)
+	}
+
+	var val struct {
+		P  textPoint
+		PP *textPoint
+	}
+	if err := UnmarshalStr(`{P:"1,2",PP:'3,4'}`, &val); err != nil {
+		t.Fatal(err)
+	}
+	if val.P != (textPoint{1, 2}) {
+		t.Errorf("expected 1,2, got %v", val.P)
+	}
+	if val.PP == nil || *val.PP != (textPoint{3, 4}) {
+		t.Errorf("expected 3,4, got %v", val.PP)
+	}
+
+	if err := UnmarshalStr(`"bogus"`, &val.P); err == nil {
+		t.Error("expected an error from UnmarshalText")
+	}
+
+	// Ion-native types don't accept strings, even though they implement UnmarshalText.
+	var tm time.Time
+	if err := UnmarshalStr(`"2010-01-01T00:00:00Z"`, &tm); err == nil {
+		t.Error("expected an error decoding a string to a time.Time")
+	}
+}
+
 func TestDecodeLobTo(t *testing.T) {
 	testSlice := func(str string, eval []byte) {
 		t.Run(str, func(t *testing.T) {
@@ -410,7 +448,81 @@ func TestDecodeLobTo(t *testing.T) {
 		})
 	}
 	testArray("null", make([]byte, 8))
-	testArray("{{aGVsbG8=}}", append([]byte("hello"), []byte{0, 0, 0}...))
+	testArray("{{aGVsbG8gYm8=}}", []byte("hello bo"))
+
+	for _, str := range []string{"{{aGVsbG8=}}", "{{aGVsbG8gd29ybGQ=}}"} {
+		var val [8]byte
+		if err := UnmarshalStr(str, &val); err == nil {
+			t.Errorf("expected an error decoding %v to [8]byte, got %v", str, val)
+		}
+	}
+}
+
+func TestBinaryUUIDRoundTrip(t *testing.T) {
+	type record struct {
+		ID   [16]byte
+		Name string
+	}
+	in := record{
+		ID:   [16]byte{0x12, 0x3e, 0x45, 0x67, 0xe8, 0x9b, 0x12, 0xd3, 0xa4, 0x56, 0x42, 0x66, 0x14, 0x17, 0x40, 0x00},
+		Name: "bob",
+	}
+
+	bs, err := MarshalBinary(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := NewReaderBytes(bs)
+	_struct(t, r, func(t *testing.T, r Reader) {
+		_nextAF(t, r, BlobType, "ID", nil)
+		_nextAF(t, r, StringType, "Name", nil)
+	})
+
+	var out record
+	if err := Unmarshal(bs, &out); err != nil {
+		t.Fatal(err)
+	}
+	if out != in {
+		t.Errorf("expected %v, got %v", in, out)
+	}
+}
+
+type binaryPair struct {
+	A, B byte
+}
+
+func (p *binaryPair) UnmarshalBinary(data []byte) error {
+	if len(data) != 2 {
+		return fmt.Errorf("expected 2 bytes, got %v", len(data))
+	}
+	p.A, p.B = data[0], data[1]
+	return nil
+}
+
+func TestDecodeBinaryUnmarshaler(t *testing.T) {
+	type foo struct {
+		P  binaryPair
+		PP *binaryPair
+	}
+
+	var val foo
+	if err := UnmarshalStr("{P:{{AQI=}},PP:{{AwQ=}}}", &val); err != nil {
+		t.Fatal(err)
+	}
+	eval := foo{binaryPair{1, 2}, &binaryPair{3, 4}}
+	if !reflect.DeepEqual(val, eval) {
+		t.Errorf("expected %v, got %v", eval, val)
+	}
+
+	if err := UnmarshalStr("{P:{{AQID}}}", &val); err == nil {
+		t.Error("expected an error from UnmarshalBinary")
+	}
+
+	// Clobs aren't binary data, so they don't go through UnmarshalBinary.
+	if err := UnmarshalStr("{P:{{'''ab'''}}}", &val); err == nil {
+		t.Error("expected an error decoding a clob to a binaryPair")
+	}
 }
 
 func TestDecodeStructTo(t *testing.T) {
@@ -443,6 +555,151 @@ func TestDecodeStructTo(t *testing.T) {
 	test("{a:4,b:2}", &map[string]int{}, &map[string]int{"a": 4, "b": 2})
 }
 
+func TestDecodeStructBySID(t *testing.T) {
+	type record struct {
+		ID    int    `ion:",sid=12"`
+		Name  string `json:"bar" ion:",sid=13"`
+		Other string
+	}
+
+	// In binary, with $12 from an import whose text we don't have.
+	r := readBinary([]byte{
+		0xDA,             // {
+		0x8C, 0x21, 0x2A, // $12: 42
+		0xEF, 0x81, 'x', // bar: "x"
+		0x8D, 0x82, 'y', 'z', // $13: "yz"
+	})
+
+	var val record
+	if err := NewDecoder(r).DecodeTo(&val); err != nil {
+		t.Fatal(err)
+	}
+	if eval := (record{42, "yz", ""}); val != eval {
+		t.Errorf("expected %v, got %v", eval, val)
+	}
+
+	// And in text, where fields can still be named.
+	val = record{}
+	if err := UnmarshalStr(`{$12:42, Other:"x", bar:"y"}`, &val); err != nil {
+		t.Fatal(err)
+	}
+	if eval := (record{42, "y", "x"}); val != eval {
+		t.Errorf("expected %v, got %v", eval, val)
+	}
+}
+
+type EmbeddedInner struct {
+	C int `json:"c"`
+}
+
+type EmbeddedBase struct {
+	*EmbeddedInner
+	B int `json:"b"`
+}
+
+type embeddedPrivate struct {
+	P int `json:"p"`
+}
+
+func TestDecodeEmbeddedStructPtr(t *testing.T) {
+	type root struct {
+		*EmbeddedBase
+		A int `json:"a"`
+	}
+
+	test := func(str string, eval root) {
+		t.Run(str, func(t *testing.T) {
+			var val root
+			if err := UnmarshalStr(str, &val); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(val, eval) {
+				t.Errorf("expected %+v, got %+v", eval, val)
+			}
+		})
+	}
+
+	test("{a:1}", root{A: 1})
+	test("{a:1,bogus:2}", root{A: 1})
+	test("{a:1,b:2}", root{A: 1, EmbeddedBase: &EmbeddedBase{B: 2}})
+	test("{b:2}", root{EmbeddedBase: &EmbeddedBase{B: 2}})
+	test("{c:3}", root{EmbeddedBase: &EmbeddedBase{EmbeddedInner: &EmbeddedInner{C: 3}}})
+	test("{a:1,b:2,c:3}", root{A: 1, EmbeddedBase: &EmbeddedBase{EmbeddedInner: &EmbeddedInner{C: 3}, B: 2}})
+
+	t.Run("unexported", func(t *testing.T) {
+		var val struct {
+			*embeddedPrivate
+		}
+		if err := UnmarshalStr("{}", &val); err != nil {
+			t.Fatal(err)
+		}
+		if val.embeddedPrivate != nil {
+			t.Error("expected embedded pointer to be left nil")
+		}
+		if err := UnmarshalStr("{p:1}", &val); err == nil {
+			t.Error("expected an error setting an embedded pointer to an unexported struct")
+		}
+	})
+}
+
+type PromotedInner struct {
+	X      int `json:"x"`
+	Shadow int `json:"s"`
+}
+
+type PromotedMid struct {
+	PromotedInner
+	Y      int `json:"y"`
+	Shadow int `json:"s2"`
+}
+
+type PromotedTagged struct {
+	T int `json:"T"`
+}
+
+type PromotedUntagged struct {
+	T int
+	U int
+}
+
+type PromotedOther struct {
+	U int
+}
+
+func TestDecodeEmbeddedStructPromoted(t *testing.T) {
+	type root struct {
+		PromotedMid
+		PromotedTagged
+		PromotedUntagged
+		PromotedOther
+		Shadow string `json:"s"`
+	}
+
+	test := func(str string, eval root) {
+		t.Run(str, func(t *testing.T) {
+			var val root
+			if err := UnmarshalStr(str, &val); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(val, eval) {
+				t.Errorf("expected %+v, got %+v", eval, val)
+			}
+		})
+	}
+
+	// Fields are promoted through multiple levels of embedding.
+	test("{x:1,y:2,s2:3}", root{PromotedMid: PromotedMid{PromotedInner: PromotedInner{X: 1}, Y: 2, Shadow: 3}})
+
+	// The outer field shadows the embedded one.
+	test("{s:\"hi\"}", root{Shadow: "hi"})
+
+	// At the same depth, a tagged field wins over an untagged one of the same name.
+	test("{T:4}", root{PromotedTagged: PromotedTagged{T: 4}})
+
+	// At the same depth with no tags, the name is ambiguous and ignored.
+	test("{U:5}", root{})
+}
+
 func TestDecodeListTo(t *testing.T) {
 	test := func(str string, val, eval interface{}) {
 		t.Run(str, func(t *testing.T) {
@@ -537,3 +794,433 @@ func TestDecode(t *testing.T) {
 	test("()", []interface{}{})
 	test("(1 + two)", []interface{}{1, "+", "two"})
 }
+
+func TestUnmarshalTooManyValues(t *testing.T) {
+	// The list itself counts as a value, pushing this one over the limit.
+	const max = 1 << 20
+	data := "[" + strings.Repeat("0,", max) + "]"
+
+	// There's no limit by default.
+	var v []int
+	if err := UnmarshalStr(data, &v); err != nil {
+		t.Fatal(err)
+	}
+	if len(v) != max {
+		t.Errorf("expected %v values, got %v", max, len(v))
+	}
+
+	d := NewDecoder(NewReaderStr(data))
+	d.SetMaxTotalValues(max)
+	err := d.DecodeTo(&v)
+	if _, ok := err.(*TooManyValuesError); !ok {
+		t.Fatalf("expected TooManyValuesError, got %v", err)
+	}
+}
+
+func TestDecodeMaxTotalValues(t *testing.T) {
+	test := func(data string, max int, ok bool) {
+		t.Run(data, func(t *testing.T) {
+			d := NewDecoder(NewReaderStr(data))
+			d.SetMaxTotalValues(max)
+
+			var v interface{}
+			err := d.DecodeTo(&v)
+			if ok && err != nil {
+				t.Fatal(err)
+			}
+			if !ok {
+				if e, isTMV := err.(*TooManyValuesError); !isTMV || e.Max != max {
+					t.Fatalf("expected TooManyValuesError{%v}, got %v", max, err)
+				}
+			}
+
+			d = NewDecoder(NewReaderStr(data))
+			d.SetMaxTotalValues(max)
+
+			_, err = d.Decode()
+			if ok && err != nil {
+				t.Fatal(err)
+			}
+			if !ok {
+				if _, isTMV := err.(*TooManyValuesError); !isTMV {
+					t.Fatalf("expected TooManyValuesError, got %v", err)
+				}
+			}
+		})
+	}
+
+	test("[1, 2, 3]", 4, true)
+	test("[1, 2, 3]", 3, false)
+	test("{a:[1], b:{c:2}}", 5, true)
+	test("{a:[1], b:{c:2}}", 4, false)
+	test("[1, 2, 3]", 0, true)
+}
+
+func TestDecodeMaxTotalValuesPerCall(t *testing.T) {
+	d := NewDecoder(NewReaderStr("[1, 2] [3, 4]"))
+	d.SetMaxTotalValues(3)
+
+	for i := 0; i < 2; i++ {
+		if _, err := d.Decode(); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// WideStruct returns an Ion struct with 50 fields, F0 through F49, each holding
+// a small struct.
+func wideStruct() string {
+	buf := strings.Builder{}
+	buf.WriteString("{")
+	for i := 0; i < 50; i++ {
+		fmt.Fprintf(&buf, `F%v:{a:[1,2,3],b:"value %v",c:1.5e0},`, i, i)
+	}
+	buf.WriteString("}")
+	return buf.String()
+}
+
+type narrowStruct struct {
+	F1, F25, F49 struct {
+		B string
+	}
+}
+
+func TestDecodeSkipsUnknownFields(t *testing.T) {
+	// Fields the target doesn't have are skipped without being decoded, so they
+	// don't count towards the total.
+	d := NewDecoder(NewReaderStr(wideStruct()))
+	d.SetMaxTotalValues(7)
+
+	var v narrowStruct
+	if err := d.DecodeTo(&v); err != nil {
+		t.Fatal(err)
+	}
+	if v.F1.B != "value 1" || v.F25.B != "value 25" || v.F49.B != "value 49" {
+		t.Errorf("unexpected value %+v", v)
+	}
+}
+
+func BenchmarkDecodeNarrowStruct(b *testing.B) {
+	vs, err := ReadValues(NewReaderStr(wideStruct()))
+	if err != nil {
+		b.Fatal(err)
+	}
+	buf := bytes.Buffer{}
+	w := NewBinaryWriter(&buf)
+	vs[0].WriteTo(w)
+	if err := w.Finish(); err != nil {
+		b.Fatal(err)
+	}
+	data := buf.Bytes()
+
+	b.Run("narrow", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			var v narrowStruct
+			if err := Unmarshal(data, &v); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("wide", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			var v map[string]interface{}
+			if err := Unmarshal(data, &v); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func TestDecodeTimeStrings(t *testing.T) {
+	type event struct {
+		At time.Time
+	}
+	eval := time.Date(2019, 8, 4, 18, 15, 43, 863494000, time.FixedZone("fixed", 10*60*60))
+
+	test := func(data string) {
+		t.Run(data, func(t *testing.T) {
+			d := NewDecoder(NewReaderStr(data))
+			d.SetTimeStrings(true)
+
+			var v event
+			if err := d.DecodeTo(&v); err != nil {
+				t.Fatal(err)
+			}
+			if !v.At.Equal(eval) {
+				t.Errorf("expected %v, got %v", eval, v.At)
+			}
+		})
+	}
+
+	test(`{At:2019-08-04T18:15:43.863494+10:00}`)
+	test(`{At:"2019-08-04T18:15:43.863494+10:00"}`)
+	test(`{At:"2019-08-04T08:15:43.863494Z"}`)
+
+	// Off by default.
+	var v event
+	if err := UnmarshalStr(`{At:"2019-08-04T18:15:43.863494+10:00"}`, &v); err == nil {
+		t.Error("expected an error decoding a string to time.Time by default")
+	}
+
+	// Strings that aren't timestamps, and symbols, are still errors.
+	for _, data := range []string{`{At:"yesterday"}`, `{At:'2019-08-04T18:15:43Z'}`} {
+		d := NewDecoder(NewReaderStr(data))
+		d.SetTimeStrings(true)
+		if err := d.DecodeTo(&v); err == nil {
+			t.Errorf("expected an error decoding %v", data)
+		}
+	}
+}
+
+func TestDecodeDuration(t *testing.T) {
+	test := func(data string, eval time.Duration) {
+		t.Run(data, func(t *testing.T) {
+			var v time.Duration
+			if err := UnmarshalStr(data, &v); err != nil {
+				t.Fatal(err)
+			}
+			if v != eval {
+				t.Errorf("expected %v, got %v", eval, v)
+			}
+		})
+	}
+
+	test("duration::1000000000", time.Second)
+	test("1000000000", time.Second)
+	test(`"1s"`, time.Second)
+	test(`"-2m3.5s"`, -(2*time.Minute + 3500*time.Millisecond))
+
+	// Strings that aren't durations, and symbols, are errors.
+	for _, data := range []string{`"soon"`, `'1s'`} {
+		var v time.Duration
+		if err := UnmarshalStr(data, &v); err == nil {
+			t.Errorf("expected an error decoding %v", data)
+		}
+	}
+}
+
+func TestDecodeSQLNulls(t *testing.T) {
+	type row struct {
+		I  sql.NullInt64
+		S  sql.NullString
+		F  sql.NullFloat64
+		B  sql.NullBool
+		PI *sql.NullInt64
+	}
+
+	var v row
+	if err := UnmarshalStr(`{I:null.int, S:null, F:null.float, B:null.bool, PI:null}`, &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.I.Valid || v.S.Valid || v.F.Valid || v.B.Valid || v.PI != nil {
+		t.Errorf("expected all nulls, got %+v", v)
+	}
+
+	// Nulls also reset values that were already valid.
+	v = row{I: sql.NullInt64{Int64: 5, Valid: true}}
+	if err := UnmarshalStr(`{I:null.int}`, &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.I.Valid || v.I.Int64 != 0 {
+		t.Errorf("expected a null int, got %+v", v.I)
+	}
+
+	if err := UnmarshalStr(`{I:42, S:"hi", F:1.5e0, B:true, PI:-7}`, &v); err != nil {
+		t.Fatal(err)
+	}
+	expected := row{
+		I:  sql.NullInt64{Int64: 42, Valid: true},
+		S:  sql.NullString{String: "hi", Valid: true},
+		F:  sql.NullFloat64{Float64: 1.5, Valid: true},
+		B:  sql.NullBool{Bool: true, Valid: true},
+		PI: &sql.NullInt64{Int64: -7, Valid: true},
+	}
+	if !reflect.DeepEqual(v, expected) {
+		t.Errorf("expected %+v, got %+v", expected, v)
+	}
+
+	// Scan converts between types the way database/sql does.
+	if err := UnmarshalStr(`{I:12., S:sym, F:2.5d-1}`, &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.I.Int64 != 12 || v.S.String != "sym" || v.F.Float64 != 0.25 {
+		t.Errorf("unexpected values %+v", v)
+	}
+
+	var i sql.NullInt64
+	if err := UnmarshalStr(`18446744073709551616`, &i); err == nil {
+		t.Error("expected an error decoding a too-big int to sql.NullInt64")
+	}
+	if err := UnmarshalStr(`[1]`, &i); err == nil {
+		t.Error("expected an error decoding a list to sql.NullInt64")
+	}
+}
+
+// A scannedStruct has a Scan method for reading it from a database column, which
+// the decoder shouldn't use for structs, and a string decodes via UnmarshalText.
+type scannedStruct struct {
+	A    int
+	Text string
+}
+
+func (s *scannedStruct) Scan(src interface{}) error {
+	s.A = -1
+	return nil
+}
+
+func (s *scannedStruct) UnmarshalText(text []byte) error {
+	s.Text = string(text)
+	return nil
+}
+
+func TestDecodeScannerStruct(t *testing.T) {
+	var v struct{ M scannedStruct }
+	if err := UnmarshalStr(`{M:{A:1}}`, &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.M.A != 1 {
+		t.Errorf("expected A=1, got %+v", v.M)
+	}
+
+	if err := UnmarshalStr(`{M:"hi"}`, &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.M.Text != "hi" || v.M.A != 1 {
+		t.Errorf("expected Text=hi via UnmarshalText, got %+v", v.M)
+	}
+
+	// Other scalars, and nulls, still go to Scan.
+	if err := UnmarshalStr(`{M:5}`, &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.M.A != -1 {
+		t.Errorf("expected A=-1 via Scan, got %+v", v.M)
+	}
+}
+
+func TestDecodeOrderedStructs(t *testing.T) {
+	data := `{z:1,a:{y:"two",b:[{x:3,c:4}]},z:5,m:null}`
+
+	d := NewDecoder(NewReaderStr(data))
+	d.SetOrderedStructs(true)
+	v, err := d.Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	eval := OrderedMap{
+		{"z", int(1)},
+		{"a", OrderedMap{
+			{"y", "two"},
+			{"b", []interface{}{OrderedMap{{"x", int(3)}, {"c", int(4)}}}},
+		}},
+		{"z", int(5)},
+		{"m", nil},
+	}
+	if !reflect.DeepEqual(v, eval) {
+		t.Errorf("expected %v, got %v", eval, v)
+	}
+
+	// Encoding it again gives back the same struct, fields in the same order.
+	bs, err := MarshalText(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(bs) != data {
+		t.Errorf("expected %v, got %v", data, string(bs))
+	}
+
+	// Into an interface{}, too.
+	var i interface{}
+	d = NewDecoder(NewReaderStr(data))
+	d.SetOrderedStructs(true)
+	if err := d.DecodeTo(&i); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(i, eval) {
+		t.Errorf("expected %v, got %v", eval, i)
+	}
+
+	// An OrderedMap doesn't need the option, though anything nested in it does.
+	var om OrderedMap
+	if err := UnmarshalStr(`{b:1,a:{d:2,c:3}}`, &om); err != nil {
+		t.Fatal(err)
+	}
+	oeval := OrderedMap{{"b", int(1)}, {"a", map[string]interface{}{"d": int(2), "c": int(3)}}}
+	if !reflect.DeepEqual(om, oeval) {
+		t.Errorf("expected %v, got %v", oeval, om)
+	}
+
+	// Off by default.
+	v, err = NewDecoder(NewReaderStr(data)).Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := v.(map[string]interface{}); !ok {
+		t.Errorf("expected a map, got %T", v)
+	}
+}
+
+type animal interface {
+	Sound() string
+}
+
+type dog struct {
+	Name string `ion:"name"`
+}
+
+func (d *dog) Sound() string { return d.Name + " says woof" }
+
+type cat struct {
+	Lives int `ion:"lives"`
+}
+
+func (c cat) Sound() string { return fmt.Sprintf("meow (%v lives left)", c.Lives) }
+
+func TestDecodeRegisteredTypes(t *testing.T) {
+	data := `[Dog::{name:"Rex"}, Cat::{lives:9}, pet::Dog::{name:"Fido"}, Dog::null.struct]`
+
+	d := NewDecoder(NewReaderStr(data))
+	d.RegisterType("Dog", &dog{})
+	d.RegisterType("Cat", cat{})
+
+	var animals []animal
+	if err := d.DecodeTo(&animals); err != nil {
+		t.Fatal(err)
+	}
+
+	eval := []animal{&dog{"Rex"}, cat{9}, &dog{"Fido"}, nil}
+	if !reflect.DeepEqual(animals, eval) {
+		t.Fatalf("expected %v, got %v", eval, animals)
+	}
+	if s := animals[1].Sound(); s != "meow (9 lives left)" {
+		t.Errorf("unexpected sound %q", s)
+	}
+
+	// In an interface{}, too.
+	d = NewDecoder(NewReaderStr(`{pet:Cat::{lives:3}, other:{lives:2}}`))
+	d.RegisterType("Cat", cat{})
+	var m map[string]interface{}
+	if err := d.DecodeTo(&m); err != nil {
+		t.Fatal(err)
+	}
+	if m["pet"] != (cat{3}) {
+		t.Errorf("expected a cat, got %#v", m["pet"])
+	}
+	if _, ok := m["other"].(map[string]interface{}); !ok {
+		t.Errorf("expected a map, got %#v", m["other"])
+	}
+
+	// Unregistered annotations, and types that don't fit, are errors.
+	test := func(data string) {
+		d := NewDecoder(NewReaderStr(data))
+		d.RegisterType("Int", 0)
+		var animals []animal
+		if err := d.DecodeTo(&animals); err == nil {
+			t.Errorf("expected an error decoding %v", data)
+		}
+	}
+	test(`[Bird::{}]`)
+	test(`[Int::1]`)
+}
@@ -0,0 +1,358 @@
+package ion
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+)
+
+// A Value is an in-memory representation of an Ion value, including its field
+// name (if it was read from inside a struct) and annotations. Containers hold
+// their contents as child Values, making a Value a complete tree.
+type Value struct {
+	Type        Type
+	FieldName   string
+	Annotations []string
+
+	// Null is true if this value is an explicit null, including typed nulls
+	// like null.int.
+	Null bool
+
+	// Scalar holds the value of a non-null atomic value: a bool, an int64 or
+	// *big.Int, a float64, a *Decimal, a time.Time, a string, or a []byte,
+	// depending on Type. It is nil for nulls and containers.
+	Scalar interface{}
+
+	// Precision holds the precision of a non-null timestamp.
+	Precision TimestampPrecision
+
+	// Children holds the contents of a non-null list, sexp, or struct.
+	Children []Value
+}
+
+// ReadValue reads the value the Reader is currently positioned on into a Value,
+// recursively reading the contents of containers. On return the Reader is still
+// positioned on the value, so a subsequent call to Next moves on to the next one.
+// There is no limit on how many values it reads; use ReadValueMax for input that
+// can't be trusted not to be enormous.
+func ReadValue(r Reader) (Value, error) {
+	return readValue(r, nil)
+}
+
+// ReadValueMax reads the current value as ReadValue does, but gives up with a
+// TooManyValuesError once it has read more than max values, counting the value
+// itself and every value nested inside it. A max of zero or less means no limit.
+func ReadValueMax(r Reader, max int) (Value, error) {
+	return readValue(r, &collector{max: max})
+}
+
+// ReadValue reads the current value, adding it and any of the values nested
+// inside it that match to the collector, if there is one.
+func readValue(r Reader, c *collector) (Value, error) {
+	t := r.Type()
+	if t == NoType {
+		return Value{}, &UsageError{"ReadValue", "reader is not positioned on a value"}
+	}
+	if err := c.count(); err != nil {
+		return Value{}, err
+	}
+
+	// Reserve a spot for this value before reading its children, so collected
+	// values come out in document order.
+	idx := -1
+	if c != nil && c.match != nil && c.match(r) {
+		idx = len(c.vals)
+		c.vals = append(c.vals, Value{})
+	}
+
+	v := Value{
+		Type:        t,
+		FieldName:   r.FieldName(),
+		Annotations: r.Annotations(),
+	}
+	if r.IsNull() {
+		v.Null = true
+		if idx >= 0 {
+			c.vals[idx] = v
+		}
+		return v, nil
+	}
+
+	var err error
+	switch t {
+	case BoolType:
+		v.Scalar, err = r.BoolValue()
+	case IntType:
+		v.Scalar, err = readIntValue(r)
+	case FloatType:
+		v.Scalar, err = r.FloatValue()
+	case DecimalType:
+		v.Scalar, err = r.DecimalValue()
+	case TimestampType:
+		v.Scalar, err = r.TimeValue()
+		if err == nil {
+			v.Precision, err = r.TimestampPrecision()
+		}
+	case SymbolType, StringType:
+		v.Scalar, err = r.StringValue()
+	case ClobType, BlobType:
+		v.Scalar, err = r.ByteValue()
+	case ListType, SexpType, StructType:
+		v.Children, err = readChildren(r, c)
+	default:
+		panic(fmt.Sprintf("unexpected type %v", t))
+	}
+
+	if idx >= 0 {
+		c.vals[idx] = v
+	}
+	return v, err
+}
+
+// ReadIntValue reads an int as an int64 if it fits, or a *big.Int if it does not.
+func readIntValue(r Reader) (interface{}, error) {
+	size, err := r.IntSize()
+	if err != nil {
+		return nil, err
+	}
+
+	switch size {
+	case Int32, Int64:
+		return r.Int64Value()
+	default:
+		return r.BigIntValue()
+	}
+}
+
+// ReadChildren steps in to the current container and reads all of its values.
+func readChildren(r Reader, c *collector) ([]Value, error) {
+	if err := r.StepIn(); err != nil {
+		return nil, err
+	}
+
+	children := []Value{}
+	for r.Next() {
+		child, err := readValue(r, c)
+		if err != nil {
+			return nil, err
+		}
+		children = append(children, child)
+	}
+	if err := r.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := r.StepOut(); err != nil {
+		return nil, err
+	}
+	return children, nil
+}
+
+// ReadValues reads all of the remaining values from the given Reader.
+func ReadValues(r Reader) ([]Value, error) {
+	vs := []Value{}
+	for r.Next() {
+		v, err := ReadValue(r)
+		if err != nil {
+			return nil, err
+		}
+		vs = append(vs, v)
+	}
+	if err := r.Err(); err != nil {
+		return nil, err
+	}
+	return vs, nil
+}
+
+// Collect reads all of the remaining values from the given Reader, returning
+// every value, at any depth, for which match returns true. The match function
+// is called with the Reader positioned on each value in turn and must not move
+// it. Values are returned in document order, with a matching container coming
+// before any matching values nested inside it.
+func Collect(r Reader, match func(r Reader) bool) ([]Value, error) {
+	c := collector{match: match, vals: []Value{}}
+	for r.Next() {
+		if _, err := readValue(r, &c); err != nil {
+			return nil, err
+		}
+	}
+	if err := r.Err(); err != nil {
+		return nil, err
+	}
+	return c.vals, nil
+}
+
+// A collector collects values matching a predicate, if it has one, and counts the
+// values read against a limit, if it has one.
+type collector struct {
+	match func(r Reader) bool
+	vals  []Value
+
+	max int
+	n   int
+}
+
+// Count counts another value read against the limit, if any.
+func (c *collector) count() error {
+	if c == nil || c.max <= 0 {
+		return nil
+	}
+	c.n++
+	if c.n > c.max {
+		return &TooManyValuesError{c.max}
+	}
+	return nil
+}
+
+// WriteTo writes this value, including its annotations, to the given Writer.
+// The value's own field name is not written; when writing a struct, the field
+// names of its children are. If you're writing a Value inside a struct of your
+// own, call w.FieldName(v.FieldName) first.
+func (v *Value) WriteTo(w Writer) error {
+	if len(v.Annotations) > 0 {
+		if err := w.Annotations(v.Annotations...); err != nil {
+			return err
+		}
+	}
+
+	if v.Null {
+		if v.Type == NullType {
+			return w.WriteNull()
+		}
+		return w.WriteNullType(v.Type)
+	}
+
+	switch v.Type {
+	case BoolType:
+		return w.WriteBool(v.Scalar.(bool))
+
+	case IntType:
+		switch i := v.Scalar.(type) {
+		case int64:
+			return w.WriteInt(i)
+		case *big.Int:
+			return w.WriteBigInt(i)
+		}
+
+	case FloatType:
+		return w.WriteFloat(v.Scalar.(float64))
+
+	case DecimalType:
+		return w.WriteDecimal(v.Scalar.(*Decimal))
+
+	case TimestampType:
+		if v.Precision == TimestampNoPrecision {
+			return w.WriteTimestamp(v.Scalar.(time.Time))
+		}
+		return w.WriteTimestampWithPrecision(v.Scalar.(time.Time), v.Precision)
+
+	case SymbolType:
+		return w.WriteSymbol(v.Scalar.(string))
+
+	case StringType:
+		return w.WriteString(v.Scalar.(string))
+
+	case ClobType:
+		return w.WriteClob(v.Scalar.([]byte))
+
+	case BlobType:
+		return w.WriteBlob(v.Scalar.([]byte))
+
+	case ListType:
+		w.BeginList()
+		if err := v.writeChildren(w); err != nil {
+			return err
+		}
+		return w.EndList()
+
+	case SexpType:
+		w.BeginSexp()
+		if err := v.writeChildren(w); err != nil {
+			return err
+		}
+		return w.EndSexp()
+
+	case StructType:
+		w.BeginStruct()
+		if err := v.writeChildren(w); err != nil {
+			return err
+		}
+		return w.EndStruct()
+	}
+
+	return &UsageError{"Value.WriteTo", fmt.Sprintf("invalid %v value: %v", v.Type, v.Scalar)}
+}
+
+// WriteChildren writes the contents of a container value.
+func (v *Value) writeChildren(w Writer) error {
+	for i := range v.Children {
+		child := &v.Children[i]
+		if v.Type == StructType {
+			if err := w.FieldName(child.FieldName); err != nil {
+				return err
+			}
+		}
+		if err := child.WriteTo(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Canonicalize sorts the fields of v, and of any structs nested inside it, into
+// the order WithCanonical describes.
+func canonicalize(v *Value) {
+	for i := range v.Children {
+		canonicalize(&v.Children[i])
+	}
+	if v.Type != StructType {
+		return
+	}
+
+	fields := v.Children
+	sort.SliceStable(fields, func(i, j int) bool {
+		return fields[i].FieldName < fields[j].FieldName
+	})
+
+	// Repeated fields are ordered by their canonical text, so that the order they
+	// were given in doesn't matter.
+	for i := 0; i < len(fields); {
+		j := i + 1
+		for j < len(fields) && fields[j].FieldName == fields[i].FieldName {
+			j++
+		}
+		if j-i > 1 {
+			run := fields[i:j]
+			keys := make([]string, len(run))
+			for k := range run {
+				keys[k] = canonicalText(&run[k])
+			}
+			sort.Sort(byKey{run, keys})
+		}
+		i = j
+	}
+}
+
+// CanonicalText returns the Ion text form of an already-canonicalized value.
+func canonicalText(v *Value) string {
+	buf := strings.Builder{}
+	w := NewTextWriter(&buf)
+	v.WriteTo(w)
+	w.Finish()
+	return buf.String()
+}
+
+// ByKey sorts values by the corresponding strings in keys.
+type byKey struct {
+	vals []Value
+	keys []string
+}
+
+func (b byKey) Len() int           { return len(b.vals) }
+func (b byKey) Less(i, j int) bool { return b.keys[i] < b.keys[j] }
+func (b byKey) Swap(i, j int) {
+	b.vals[i], b.vals[j] = b.vals[j], b.vals[i]
+	b.keys[i], b.keys[j] = b.keys[j], b.keys[i]
+}
@@ -0,0 +1,303 @@
+package ion
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadValue(t *testing.T) {
+	r := NewReaderStr("a::{b:1, c:[null.int, 18446744073709551616], d:(e f)}")
+	if !r.Next() {
+		t.Fatal(r.Err())
+	}
+
+	v, err := ReadValue(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if v.Type != StructType || len(v.Annotations) != 1 || v.Annotations[0] != "a" {
+		t.Fatalf("unexpected value %+v", v)
+	}
+	if len(v.Children) != 3 {
+		t.Fatalf("expected 3 fields, got %v", len(v.Children))
+	}
+
+	b := v.Children[0]
+	if b.FieldName != "b" || b.Type != IntType || b.Scalar.(int64) != 1 {
+		t.Errorf("unexpected field %+v", b)
+	}
+
+	c := v.Children[1]
+	if c.FieldName != "c" || c.Type != ListType || len(c.Children) != 2 {
+		t.Fatalf("unexpected field %+v", c)
+	}
+	if !c.Children[0].Null || c.Children[0].Type != IntType {
+		t.Errorf("expected null.int, got %+v", c.Children[0])
+	}
+	if _, ok := c.Children[1].Scalar.(*big.Int); !ok {
+		t.Errorf("expected a *big.Int, got %T", c.Children[1].Scalar)
+	}
+
+	d := v.Children[2]
+	if d.FieldName != "d" || d.Type != SexpType || len(d.Children) != 2 {
+		t.Errorf("unexpected field %+v", d)
+	}
+
+	if r.Next() {
+		t.Errorf("expected no more values, got %v", r.Type())
+	}
+}
+
+func TestReadValueNotPositioned(t *testing.T) {
+	_, err := ReadValue(NewReaderStr("1"))
+	if _, ok := err.(*UsageError); !ok {
+		t.Errorf("expected a UsageError, got %v", err)
+	}
+}
+
+func TestReadValueMax(t *testing.T) {
+	test := func(max int, ok bool) {
+		t.Run(fmt.Sprint(max), func(t *testing.T) {
+			// Five values: the struct, the list, and its three ints.
+			r := NewReaderStr("{a:[1,2,3]}")
+			r.Next()
+			v, err := ReadValueMax(r, max)
+			if ok {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(v.Children) != 1 || len(v.Children[0].Children) != 3 {
+					t.Errorf("expected the whole value, got %+v", v)
+				}
+				return
+			}
+			if e, isTMV := err.(*TooManyValuesError); !isTMV || e.Max != max {
+				t.Errorf("expected TooManyValuesError{%v}, got %v", max, err)
+			}
+		})
+	}
+
+	test(0, true)
+	test(-1, true)
+	test(5, true)
+	test(4, false)
+	test(1, false)
+}
+
+func TestReaderValue(t *testing.T) {
+	in := `a::1 18446744073709551616 2.5e0 1.5 2019-08-04T18:15Z foo "bar" {{aGk=}} null.string [1] b::{c:d}`
+
+	test := func(t *testing.T, r Reader) {
+		// Scalars match what ReadValue makes of them.
+		for i := 0; i < 9; i++ {
+			if !r.Next() {
+				t.Fatal(r.Err())
+			}
+			v, err := r.Value()
+			if err != nil {
+				t.Fatal(err)
+			}
+			ev, err := ReadValue(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(v, ev) {
+				t.Errorf("expected %+v, got %+v", ev, v)
+			}
+		}
+
+		// Containers are marked by their type, and stepped in to as usual.
+		_next(t, r, ListType)
+		v, err := r.Value()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v.Type != ListType || v.Null || v.Children != nil {
+			t.Errorf("expected a list marker, got %+v", v)
+		}
+		r.StepIn()
+		_int(t, r, 1)
+		r.StepOut()
+
+		_nextAF(t, r, StructType, "", []string{"b"})
+		if v, _ := r.Value(); v.Type != StructType || len(v.Annotations) != 1 {
+			t.Errorf("expected an annotated struct marker, got %+v", v)
+		}
+		r.StepIn()
+		_nextAF(t, r, SymbolType, "c", nil)
+		if v, _ := r.Value(); v.FieldName != "c" || v.Scalar != "d" {
+			t.Errorf("expected c:d, got %+v", v)
+		}
+		r.StepOut()
+
+		_eof(t, r)
+		if _, err := r.Value(); err == nil {
+			t.Error("expected an error with no current value")
+		}
+	}
+
+	t.Run("text", func(t *testing.T) {
+		test(t, NewReaderStr(in))
+	})
+
+	t.Run("binary", func(t *testing.T) {
+		test(t, NewReaderBytes(toBinary(t, in)))
+	})
+}
+
+func BenchmarkReaderValue(b *testing.B) {
+	buf := strings.Builder{}
+	for i := 0; i < 10000; i++ {
+		fmt.Fprintf(&buf, "%v %v.5 \"s%v\" s%v %ve0 ", i, i, i, i, i)
+	}
+	bin := toBinary(b, buf.String())
+
+	b.Run("Value", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			r := NewReaderBytes(bin)
+			for r.Next() {
+				v, err := r.Value()
+				if err != nil {
+					b.Fatal(err)
+				}
+				switch v.Type {
+				case IntType:
+					_ = v.Scalar.(int64)
+				case DecimalType:
+					_ = v.Scalar.(*Decimal)
+				case StringType, SymbolType:
+					_ = v.Scalar.(string)
+				case FloatType:
+					_ = v.Scalar.(float64)
+				}
+			}
+		}
+	})
+
+	b.Run("Accessors", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			r := NewReaderBytes(bin)
+			for r.Next() {
+				var err error
+				switch r.Type() {
+				case IntType:
+					_, err = r.Int64Value()
+				case DecimalType:
+					_, err = r.DecimalValue()
+				case StringType, SymbolType:
+					_, err = r.StringValue()
+				case FloatType:
+					_, err = r.FloatValue()
+				}
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	})
+}
+
+// ToBinary converts the given text Ion to binary.
+func toBinary(t testing.TB, text string) []byte {
+	vs, err := ReadValues(NewReaderStr(text))
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := bytes.Buffer{}
+	w := NewBinaryWriter(&buf)
+	for i := range vs {
+		if err := vs[i].WriteTo(w); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Finish(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestValueWriteTo(t *testing.T) {
+	in := "a::{b:1,c:[null.int,null,18446744073709551616],d:(e f),g:\"h\"} 1.5 2e0 2000-01-01T00:00:00Z {{YWJj}} {{\"abc\"}} true"
+
+	vs, err := ReadValues(NewReaderStr(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buf := strings.Builder{}
+	w := NewTextWriter(&buf)
+	for i := range vs {
+		if err := vs[i].WriteTo(w); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Finish(); err != nil {
+		t.Fatal(err)
+	}
+
+	eq, err := Equal([]byte(in), []byte(buf.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !eq {
+		t.Errorf("expected %v, got %v", in, buf.String())
+	}
+}
+
+func TestCollect(t *testing.T) {
+	r := NewReaderStr(`"a" {b:"c", d:[1, "e", (f "g")], h:null.string} ["i"] 2`)
+
+	vs, err := Collect(r, func(r Reader) bool {
+		return r.Type() == StringType && !r.IsNull()
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"a", "c", "e", "g", "i"}
+	if len(vs) != len(expected) {
+		t.Fatalf("expected %v values, got %v", len(expected), len(vs))
+	}
+	for i, e := range expected {
+		if vs[i].Type != StringType || vs[i].Scalar.(string) != e {
+			t.Errorf("expected %v, got %+v", e, vs[i])
+		}
+	}
+}
+
+func TestCollectContainers(t *testing.T) {
+	r := NewReaderStr(`[1, [2, [3]]] 4`)
+
+	vs, err := Collect(r, func(r Reader) bool {
+		return r.Type() == ListType
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(vs) != 3 {
+		t.Fatalf("expected 3 values, got %v", len(vs))
+	}
+	for i, n := range []int{2, 2, 1} {
+		if len(vs[i].Children) != n {
+			t.Errorf("expected value %v to have %v children, got %v", i, n, len(vs[i].Children))
+		}
+	}
+}
+
+func TestCollectNone(t *testing.T) {
+	vs, err := Collect(NewReaderStr("1 2 3"), func(r Reader) bool {
+		return false
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(vs) != 0 {
+		t.Errorf("expected no values, got %v", vs)
+	}
+}
@@ -1,9 +1,14 @@
 package ion
 
 import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
 	"errors"
+	"fmt"
 	"io"
 	"math/big"
+	"strings"
 	"time"
 )
 
@@ -63,7 +68,9 @@ type Writer interface {
 
 	// WriteNull writes an untyped null value.
 	WriteNull() error
-	// WriteNullType writes a null value with a type qualifier, e.g. null.bool.
+	// WriteNullType writes a null value with a type qualifier, e.g. null.bool. Given a
+	// container type, it writes a null container, such as null.struct, which is not
+	// the same as an empty one: write {} with BeginStruct and EndStruct instead.
 	WriteNullType(t Type) error
 
 	// WriteBool writes a boolean value.
@@ -79,12 +86,23 @@ type Writer interface {
 	WriteFloat(val float64) error
 	// WriteDecimal writes an arbitrary-precision decimal value.
 	WriteDecimal(val *Decimal) error
+	// WriteMoney writes a monetary amount, given as a decimal string such as "12.50",
+	// as a decimal value annotated with the given annotation, typically a currency
+	// code: USD::12.50. The amount's precision is preserved.
+	WriteMoney(annotation string, amount string) error
 
 	// WriteTimestamp writes a timestamp value.
 	WriteTimestamp(val time.Time) error
+	// WriteTimestampWithPrecision writes a timestamp value, omitting any components
+	// finer than the given precision. Timestamps with a precision of a day or coarser
+	// have no offset, so their date is taken from val's own location.
+	WriteTimestampWithPrecision(val time.Time, precision TimestampPrecision) error
 
 	// WriteSymbol writes a symbol value.
 	WriteSymbol(val string) error
+	// WriteSymbolByID writes a symbol value by its symbol ID. Notably, this is the only
+	// way to write $0, the symbol with unknown text.
+	WriteSymbolByID(id uint64) error
 	// WriteString writes a string value.
 	WriteString(val string) error
 
@@ -92,6 +110,36 @@ type Writer interface {
 	WriteClob(val []byte) error
 	// WriteBlob writes a blob value.
 	WriteBlob(val []byte) error
+	// WriteBlobString writes a blob value given as a base64 string, in the standard
+	// alphabet with padding (base64.StdEncoding), such as "aGk=". Strings in the
+	// URL-safe alphabet, or without padding, are an error.
+	WriteBlobString(base64Str string) error
+
+	// WriteIntList writes a list of int values in one go. It's equivalent to calling
+	// BeginList, WriteInt for each value, and EndList, but cheaper for long lists.
+	WriteIntList(vals []int64) error
+	// WriteFloatList writes a list of float values in one go, like WriteIntList.
+	WriteFloatList(vals []float64) error
+	// WriteStringList writes a list of string values in one go, like WriteIntList.
+	WriteStringList(vals []string) error
+
+	// WriteRawText writes a value given in Ion text, such as a cached fragment of a
+	// document, after checking that it holds exactly one well-formed value. Text writers
+	// splice it in verbatim; other writers write the value it holds. Any field name or
+	// annotations set beforehand apply to it as usual, ahead of any annotations of its
+	// own. Text writers reject a string written as a long string, such as '''x''',
+	// which could run together with a following one.
+	WriteRawText(s string) error
+
+	// WritePadding writes n bytes of NOP padding, which readers skip over, at the
+	// current position, for example to align what follows for memory-mapped access.
+	// Inside a struct, padding takes a field name of its own, so it must be at least
+	// two bytes long, and it leaves any field name or annotations already set for
+	// the next value. Binary writers buffer containers and any local symbol table
+	// they build until they know their lengths, so positions in the output are
+	// only predictable at the top level, with no symbol table to write or a fixed
+	// one. Padding is only supported by binary writers; text writers return an error.
+	WritePadding(n int) error
 
 	// BeginList begins writing a list value.
 	BeginList() error
@@ -110,6 +158,12 @@ type Writer interface {
 
 	// Finish finishes writing values and flushes any buffered data.
 	Finish() error
+
+	// SymbolTable returns the local symbol table that values have been written with, or
+	// nil if there isn't one. Text Writers do not have an associated symbol table. Binary
+	// Writers do; once finished, it can be passed to NewBinaryWriterAppend to continue the
+	// stream in a subsequent document without repeating symbols that are already defined.
+	SymbolTable() SymbolTable
 }
 
 // A writer holds shared stuff for all writers.
@@ -120,6 +174,174 @@ type writer struct {
 
 	fieldName   string
 	annotations []string
+
+	topLevelSep        string
+	allowedAnnotations map[string]bool
+
+	pretty  bool
+	indent  string
+	blobHex bool
+	omitLST bool
+
+	canonical bool
+
+	// The version of Ion to write, or 0.0 for the default.
+	major, minor byte
+
+	errMode ErrorMode
+
+	finalizeHook func([]byte) []byte
+	finalOut     io.Writer
+	finalBuf     *bytes.Buffer
+}
+
+// A WriterOption configures optional behavior of a Writer.
+type WriterOption func(*writer)
+
+// WithTopLevelSeparator makes a text writer write the given separator after each
+// top-level value, instead of its default of writing a newline between them. For
+// example, passing "\n" puts each top-level value on its own line, as soon as it
+// is written, which is handy for logs. Since the output must still parse as a
+// sequence of values, the separator must be non-empty and consist only of Ion
+// whitespace. Values nested inside containers are unaffected, and binary writers
+// ignore this option.
+func WithTopLevelSeparator(sep string) WriterOption {
+	return func(w *writer) {
+		if !isWhitespaceString(sep) {
+			w.err = &UsageError{"WithTopLevelSeparator", fmt.Sprintf("separator %q is not whitespace", sep)}
+			return
+		}
+		w.topLevelSep = sep
+	}
+}
+
+// WithIndent makes a text writer pretty-print its output, writing each value
+// inside a container on its own line, indented by one copy of indent per level
+// of nesting: for example "  ", "    ", or "\t". The indent may be empty, in
+// which case nested values are put on their own lines but not indented, but
+// otherwise must consist only of Ion whitespace. Binary writers ignore this
+// option.
+func WithIndent(indent string) WriterOption {
+	return func(w *writer) {
+		if indent != "" && !isWhitespaceString(indent) {
+			w.err = &UsageError{"WithIndent", fmt.Sprintf("indent %q is not whitespace", indent)}
+			return
+		}
+		w.pretty = true
+		w.indent = indent
+	}
+}
+
+// WithBlobHex makes a text writer write blobs in hexadecimal, such as {{cafe}},
+// instead of base64, for consumers that find hex easier to work with. This is
+// not standard Ion: it can only be read back by a reader given the matching
+// WithBlobHexInput option. Worse, since hex digits are also base64 digits, other
+// readers may silently misread such blobs rather than rejecting them, so use it
+// only where every reader is known to be in on it. Binary writers ignore this
+// option.
+func WithBlobHex() WriterOption {
+	return func(w *writer) {
+		w.blobHex = true
+	}
+}
+
+// WithAllowedAnnotations restricts the annotations a writer will accept to those
+// in the given set, enforcing a controlled vocabulary. Passing any other
+// annotation to Annotation or Annotations is an error, which is returned by
+// that call and by every subsequent call, including the following value write.
+func WithAllowedAnnotations(set map[string]bool) WriterOption {
+	return func(w *writer) {
+		w.allowedAnnotations = set
+	}
+}
+
+// WithFinalizeHook makes a writer buffer its output until Finish is called, then
+// pass the complete document to hook and write whatever it returns instead, for
+// example to append a checksum or wrap the document in an envelope. The hook is
+// called once per call to Finish, with the bytes written since the previous call.
+// It's the caller's responsibility to ensure that anything the hook returns is
+// still valid Ion, or is otherwise understood by whatever reads it.
+func WithFinalizeHook(hook func([]byte) []byte) WriterOption {
+	return func(w *writer) {
+		w.finalizeHook = hook
+		w.finalOut = w.out
+		w.finalBuf = &bytes.Buffer{}
+		w.out = w.finalBuf
+	}
+}
+
+// WithVersion sets the version of Ion a binary writer writes, in its binary
+// version markers. Only 1.0, the default, is currently supported; asking for any
+// other version is an error, returned by every subsequent call, rather than output
+// that claims to be a version it isn't. Text writers ignore this option.
+func WithVersion(major, minor int) WriterOption {
+	return func(w *writer) {
+		if !supportedVersion(major, minor) {
+			w.err = &UsageError{"WithVersion", fmt.Sprintf("unsupported version %v.%v", major, minor)}
+			return
+		}
+		w.major, w.minor = byte(major), byte(minor)
+	}
+}
+
+// WithoutLST makes a binary writer given a fixed local symbol table, by
+// NewBinaryWriterLST, leave the table out of its output, writing only a binary
+// version marker ahead of the values that use it. This lets many small documents
+// share one table: write the first with NewBinaryWriterLST as usual, so it carries
+// the table, and the rest with this option, so they don't. Each of the rest then
+// reads as intended only by a reader given the same table with WithSymbolTable.
+// Writers that build their own table, and text writers, ignore this option.
+func WithoutLST() WriterOption {
+	return func(w *writer) {
+		w.omitLST = true
+	}
+}
+
+// WithCanonical makes a binary writer write values in a canonical form, so that
+// equivalent values are written as identical bytes, for reproducible hashing or
+// signing. Each top-level value is held in memory until it's complete, then
+// written with the fields of its structs sorted by name, in byte order, with
+// repeated fields in the order of their own canonical forms, and with floats in
+// four bytes rather than eight when that loses nothing. Symbols are added to the
+// local symbol table in the order they're then written, which no longer depends
+// on the order the fields were given in. Other values, and lengths, are always
+// written in their shortest forms; decimals and timestamps are written as given,
+// since their precision and offset are part of their values. Padding is left out.
+// Text writers ignore this option.
+func WithCanonical() WriterOption {
+	return func(w *writer) {
+		w.canonical = true
+	}
+}
+
+// An ErrorMode determines what a Writer does when one of its calls fails.
+type ErrorMode uint8
+
+const (
+	// StopOnError, the default, makes a writer keep the first error it encounters
+	// and return it from every subsequent call, writing nothing more.
+	StopOnError ErrorMode = iota
+
+	// CollectErrors makes a writer return each error from the call that caused it,
+	// then carry on as best it can, so that one run turns up every problem rather
+	// than just the first. Finish returns a *MultiError holding all of them, and
+	// starts afresh for any values written after it. Output
+	// written after an error may not be valid Ion, so this is a debugging aid, not
+	// a way to recover from errors.
+	CollectErrors
+
+	// PanicOnError makes a writer panic with the first error it encounters, rather
+	// than returning it, so that it can't go unnoticed. An error from an invalid
+	// WriterOption panics as soon as the writer is created.
+	PanicOnError
+)
+
+// WithErrorMode sets what a writer does when one of its calls fails. See the
+// documentation of each ErrorMode for details.
+func WithErrorMode(mode ErrorMode) WriterOption {
+	return func(w *writer) {
+		w.errMode = mode
+	}
 }
 
 // FieldName sets the field name for the next value written.
@@ -139,6 +361,9 @@ func (w *writer) FieldName(val string) error {
 
 // Annotation adds an annotation to the next value written.
 func (w *writer) Annotation(val string) error {
+	if w.err == nil {
+		w.err = w.checkAnnotations("Writer.Annotation", val)
+	}
 	if w.err == nil {
 		w.annotations = append(w.annotations, val)
 	}
@@ -147,12 +372,131 @@ func (w *writer) Annotation(val string) error {
 
 // Annotations adds one or more annotations to the next value written.
 func (w *writer) Annotations(val ...string) error {
+	if w.err == nil {
+		w.err = w.checkAnnotations("Writer.Annotations", val...)
+	}
 	if w.err == nil {
 		w.annotations = append(w.annotations, val...)
 	}
 	return w.err
 }
 
+// CheckAnnotations returns an error if any of the given annotations are not
+// in the allowed set, if there is one.
+func (w *writer) checkAnnotations(api string, vals ...string) error {
+	if w.allowedAnnotations == nil {
+		return nil
+	}
+	for _, val := range vals {
+		if !w.allowedAnnotations[val] {
+			return &UsageError{api, fmt.Sprintf("annotation %q is not allowed", val)}
+		}
+	}
+	return nil
+}
+
+// ParseMoney parses the amount passed to WriteMoney, remembering any error.
+func (w *writer) parseMoney(amount string) (*Decimal, error) {
+	if w.err != nil {
+		return nil, w.err
+	}
+
+	d, err := ParseDecimal(amount)
+	if err != nil {
+		w.err = &UsageError{"Writer.WriteMoney", err.Error()}
+		return nil, w.err
+	}
+	return d, nil
+}
+
+// ParseBlobString decodes the base64 string given to WriteBlobString.
+func (w *writer) parseBlobString(str string) ([]byte, error) {
+	if w.err != nil {
+		return nil, w.err
+	}
+
+	val, err := base64.StdEncoding.DecodeString(str)
+	if err != nil {
+		w.err = &UsageError{"Writer.WriteBlobString", err.Error()}
+		return nil, w.err
+	}
+	return val, nil
+}
+
+// ParseRawText reads the Ion text given to WriteRawText, which must hold exactly
+// one value and no system values. It also returns whether the value is a long
+// string, which would run together with a long string written next to it.
+func parseRawText(s string) (Value, bool, error) {
+	ivm := false
+	r := newTextReaderBuf(bufio.NewReader(strings.NewReader(s)), withVersionMarkerHook(func(int, int) {
+		ivm = true
+	})).(*textReader)
+
+	if !r.Next() {
+		if err := r.Err(); err != nil {
+			return Value{}, false, &UsageError{"Writer.WriteRawText", err.Error()}
+		}
+		if ivm {
+			return Value{}, false, &UsageError{"Writer.WriteRawText", "version marker given"}
+		}
+		return Value{}, false, &UsageError{"Writer.WriteRawText", "no value given"}
+	}
+	long := r.longString
+
+	val, err := ReadValue(r)
+	if err != nil {
+		return Value{}, false, &UsageError{"Writer.WriteRawText", err.Error()}
+	}
+
+	if r.Next() {
+		return Value{}, false, &UsageError{"Writer.WriteRawText", "more than one value given"}
+	}
+	if err := r.Err(); err != nil {
+		return Value{}, false, &UsageError{"Writer.WriteRawText", err.Error()}
+	}
+
+	// The reader consumes version markers silently, but spliced in to the output
+	// they'd reset its symbol table, as would a local symbol table.
+	if ivm {
+		return Value{}, false, &UsageError{"Writer.WriteRawText", "version marker given"}
+	}
+	if val.Type == StructType && len(val.Annotations) > 0 && val.Annotations[0] == "$ion_symbol_table" {
+		return Value{}, false, &UsageError{"Writer.WriteRawText", "local symbol table given"}
+	}
+	return val, long, nil
+}
+
+// Version returns the version of Ion to write.
+func (w *writer) version() (byte, byte) {
+	if w.major == 0 {
+		return versionMajor, versionMinor
+	}
+	return w.major, w.minor
+}
+
+// Finalize passes the output buffered since the last call through the finalize
+// hook, if there is one, and writes the result.
+func (w *writer) finalize() error {
+	if w.finalizeHook == nil {
+		return nil
+	}
+
+	bs := w.finalizeHook(w.finalBuf.Bytes())
+	if _, err := w.finalOut.Write(bs); err != nil {
+		return err
+	}
+	w.finalBuf.Reset()
+	return nil
+}
+
+// CheckPrecision returns an error if the given timestamp precision isn't valid.
+func checkPrecision(api string, precision TimestampPrecision) error {
+	if precision < TimestampPrecisionYear || precision > TimestampPrecisionNanosecond {
+		return &UsageError{api, fmt.Sprintf("invalid timestamp precision %v", precision)}
+	}
+	return nil
+}
+
 // InStruct returns true if we're currently writing a struct.
 func (w *writer) inStruct() bool {
 	return w.ctx.peek() == ctxInStruct